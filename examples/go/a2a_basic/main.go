@@ -19,6 +19,8 @@ import (
 	"google.golang.org/genai"
 )
 
+var newSessionService = session.InMemoryService
+
 // --- Local Roll Agent ---
 
 type rollDieToolArgs struct {
@@ -116,7 +118,7 @@ func main() {
 		log.Fatalf("Failed to create root agent: %v", err)
 	}
 
-	sessionService := session.InMemoryService()
+	sessionService := newSessionService()
 	artifactService := artifact.InMemoryService()
 
 	_, err = sessionService.Create(ctx, &session.CreateRequest{
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+// TestExample stays with the local roll_agent, so the remote prime_agent does
+// not need to be running. The script asks for a one-sided die to keep the
+// roll deterministic.
+func TestExample(t *testing.T) {
+	golden.CheckMain(t, "a2a_basic", &newSessionService, main)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+// TestExample runs the agent main serves over A2A in process, on top of a
+// recording session service, instead of starting the launcher.
+func TestExample(t *testing.T) {
+	m := golden.UseScript(t, "testdata/fakellm_script.json")
+	primeTool, err := functiontool.New(functiontool.Config{
+		Name:        "prime_checking",
+		Description: "Check if numbers in a list are prime using efficient mathematical algorithms",
+	}, checkPrimeTool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	primeAgent, err := llmagent.New(llmagent.Config{
+		Name:  "check_prime_agent",
+		Model: m,
+		Tools: []tool.Tool{primeTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &golden.Recorder{}
+	sessionService := rec.InMemoryService()
+	s, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "check_prime_agent", UserID: "user-123"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "check_prime_agent", Agent: primeAgent, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Run(t, r, "user-123", s.Session.ID(), "Which of 4, 7 and 11 are prime?")
+
+	golden.Check(t, "check_prime_agent", rec.Events())
+}
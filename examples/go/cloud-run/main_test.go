@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+// TestExample runs the agent main hands to the launcher in process, on top
+// of a recording session service.
+func TestExample(t *testing.T) {
+	m := golden.UseScript(t, "testdata/fakellm_script.json")
+	capitalTool, err := functiontool.New(functiontool.Config{
+		Name:        "get_capital_city",
+		Description: "Retrieves the capital city for a given country.",
+	}, getCapitalCity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	capitalAgent, err := llmagent.New(llmagent.Config{
+		Name:  "capital_agent",
+		Model: m,
+		Tools: []tool.Tool{capitalTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &golden.Recorder{}
+	sessionService := rec.InMemoryService()
+	s, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "capital_agent", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "capital_agent", Agent: capitalAgent, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Run(t, r, "user", s.Session.ID(), "What is the capital of Japan?")
+	golden.Run(t, r, "user", s.Session.ID(), "And of Atlantis?")
+
+	golden.Check(t, "cloud_run", rec.Events())
+}
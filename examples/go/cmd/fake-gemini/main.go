@@ -0,0 +1,83 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command fake-gemini serves a fakellm script or a cassette as the Gemini
+// API, so that any example can run against it without an API key or network
+// access:
+//
+//	go run ./cmd/fake-gemini -script snippets/sessions/memory_example/testdata/fakellm_script.json &
+//	cd snippets/sessions/memory_example
+//	GOOGLE_GEMINI_BASE_URL=http://localhost:8089 GOOGLE_API_KEY=fake go run .
+//
+// With -cassette, it replays the cassette instead, or with -record, records
+// it from the live -model, using the GOOGLE_API_KEY of its own environment.
+//
+// A script or cassette is played back once; restart the server to run the
+// example again.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/gemini"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/cassette"
+	"github.com/google/adk-docs/examples/go/internal/fakellm"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8089", "address to listen on")
+	script := flag.String("script", "", "path of a fakellm script to play back")
+	cassettePath := flag.String("cassette", "", "path of a cassette to replay, or to record with -record")
+	record := flag.Bool("record", false, "record the cassette from the live model")
+	modelName := flag.String("model", "gemini-2.5-flash", "live model to record from")
+	flag.Parse()
+
+	var m model.LLM
+	switch {
+	case *script != "" && *cassettePath == "":
+		fm, err := fakellm.Load(*script)
+		if err != nil {
+			log.Fatal(err)
+		}
+		m = fm
+	case *cassettePath != "" && *script == "":
+		mode := cassette.ModeReplay
+		var live model.LLM
+		if *record {
+			mode = cassette.ModeRecord
+			var err error
+			if live, err = gemini.NewModel(context.Background(), *modelName, &genai.ClientConfig{}); err != nil {
+				log.Fatal(err)
+			}
+		}
+		// Each interaction is written as it is recorded, so the cassette
+		// needs no closing when the server is stopped.
+		c, err := cassette.Open(*cassettePath, mode)
+		if err != nil {
+			log.Fatal(err)
+		}
+		m = c.Wrap(live)
+	default:
+		log.Fatal("exactly one of -script and -cassette is required")
+	}
+
+	log.Printf("serving on http://%s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, fakellm.Handler(m)))
+}
@@ -0,0 +1,213 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command session-archive copies sessions between the session stores of
+// these examples through a portable JSON archive.
+//
+//	go run ./cmd/session-archive export -store files:/tmp/sessions -app my_app > sessions.json
+//	go run ./cmd/session-archive import -store sqlite:/tmp/sessions.db < sessions.json
+//
+// A store is sqlite:<file>, for a sqlitesession database, or files:<dir>,
+// for a filesession directory such as one written with ADK_SESSION_DIR.
+//
+// export archives every session of -app, or only those of -user, or the one
+// session -session names. import creates every archived session in the store
+// and lists what the store did not keep; it fails on a session that already
+// exists unless -replace is set.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/session"
+
+	"github.com/google/adk-docs/examples/go/internal/filesession"
+	"github.com/google/adk-docs/examples/go/internal/sessionarchive"
+	"github.com/google/adk-docs/examples/go/internal/sqlitesession"
+)
+
+const usage = `usage: session-archive command [flags]
+
+commands:
+  export -store spec -app name [-user id] [-session id] [-out file]
+        write the sessions of an app as an archive
+  import -store spec [-replace] [-in file]
+        create the sessions of an archive and report what was not kept
+
+a store spec is sqlite:<file> or files:<dir>
+`
+
+// errUsage is returned by run for an unknown command.
+var errUsage = errors.New("unknown command")
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(flag.CommandLine.Output(), usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(context.Background(), flag.Arg(0), flag.Args()[1:]); errors.Is(err, errUsage) {
+		flag.Usage()
+		os.Exit(2)
+	} else if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run runs cmd with its arguments. Unlike log.Fatal, returning an error lets
+// the deferred calls close the store and the files first.
+func run(ctx context.Context, cmd string, args []string) error {
+	switch cmd {
+	case "export":
+		return export(ctx, args)
+	case "import":
+		return importArchive(ctx, args)
+	}
+	return errUsage
+}
+
+func export(ctx context.Context, args []string) (err error) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	store := fs.String("store", "", "session store to read")
+	app := fs.String("app", "", "app whose sessions to export")
+	user := fs.String("user", "", "only export the sessions of this user")
+	id := fs.String("session", "", "only export this session; needs -user")
+	out := fs.String("out", "", "file to write, instead of stdout")
+	fs.Parse(args)
+	if *app == "" {
+		return errors.New("export needs -app")
+	}
+	if *id != "" && *user == "" {
+		return errors.New("-session needs -user")
+	}
+	svc, closeStore, err := openStore(*store)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	a := &sessionarchive.Archive{ExportTime: time.Now()}
+	if *id != "" {
+		s, err := sessionarchive.Export(ctx, svc, *app, *user, *id)
+		if err != nil {
+			return fmt.Errorf("cannot export session %s: %w", *id, err)
+		}
+		a.Sessions = []*sessionarchive.Session{s}
+	} else {
+		sessions, err := sessionarchive.ExportAll(ctx, svc, *app, *user)
+		if err != nil {
+			return fmt.Errorf("cannot export sessions: %w", err)
+		}
+		a.Sessions = sessions
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("cannot create archive: %w", err)
+		}
+		defer func() {
+			if cerr := f.Close(); cerr != nil && err == nil {
+				err = fmt.Errorf("cannot write archive: %w", cerr)
+			}
+		}()
+		w = f
+	}
+	if err := sessionarchive.Write(w, a); err != nil {
+		return fmt.Errorf("cannot write archive: %w", err)
+	}
+	log.Printf("exported %d sessions", len(a.Sessions))
+	return nil
+}
+
+func importArchive(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	store := fs.String("store", "", "session store to write")
+	replace := fs.Bool("replace", false, "delete sessions that already exist first")
+	in := fs.String("in", "", "archive to read, instead of stdin")
+	fs.Parse(args)
+	svc, closeStore, err := openStore(*store)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	r := io.Reader(os.Stdin)
+	if *in != "" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return fmt.Errorf("cannot open archive: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	a, err := sessionarchive.Read(r)
+	if err != nil {
+		return err
+	}
+
+	losses := 0
+	for _, s := range a.Sessions {
+		if *replace {
+			err := svc.Delete(ctx, &session.DeleteRequest{AppName: s.AppName, UserID: s.UserID, SessionID: s.ID})
+			if err != nil {
+				return fmt.Errorf("cannot delete session %s: %w", s.ID, err)
+			}
+		}
+		report, err := sessionarchive.Import(ctx, svc, s)
+		if err != nil {
+			return fmt.Errorf("cannot import session %s: %w", s.ID, err)
+		}
+		for _, l := range report.Losses {
+			fmt.Printf("%s/%s/%s: %s\n", s.AppName, s.UserID, s.ID, l)
+		}
+		losses += len(report.Losses)
+	}
+	log.Printf("imported %d sessions, %d fields not kept", len(a.Sessions), losses)
+	return nil
+}
+
+// openStore opens the session store spec names, and returns a function that
+// closes it.
+func openStore(spec string) (session.Service, func(), error) {
+	kind, arg, ok := strings.Cut(spec, ":")
+	if !ok || arg == "" {
+		return nil, nil, fmt.Errorf("invalid -store %q, want sqlite:<file> or files:<dir>", spec)
+	}
+	switch kind {
+	case "sqlite":
+		svc, err := sqlitesession.Open(arg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot open %s: %w", arg, err)
+		}
+		return svc, func() { svc.Close() }, nil
+	case "files":
+		svc, err := filesession.Open(arg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot open %s: %w", arg, err)
+		}
+		return svc, func() {}, nil
+	}
+	return nil, nil, fmt.Errorf("unknown store kind %q, want sqlite or files", kind)
+}
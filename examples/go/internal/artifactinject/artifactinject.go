@@ -0,0 +1,316 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifactinject adds the artifacts a conversation refers to to the
+// requests sent to the model, in a form the model accepts.
+//
+// New returns a BeforeModelCallback:
+//
+//	a, err := llmagent.New(llmagent.Config{
+//		Name:  "reporting_agent",
+//		Model: model,
+//		BeforeModelCallbacks: []llmagent.BeforeModelCallback{
+//			artifactinject.New(artifactinject.Config{MaxBytes: 2 << 20}),
+//		},
+//	})
+//
+// An artifact is referred to when the message of the user names it, as a
+// whole word or path element, or when the state key Config.StateKey holds
+// its name or a list of names. Each one is loaded and then, in order:
+//
+//   - sent as it is if the model supports its MIME type;
+//   - otherwise converted by the Converter for its MIME type: CSV becomes a
+//     text table, a DOCX document its text, JSON and other text its text;
+//   - an image over Config.MaxImageBytes, or of a type the model does not
+//     support, is scaled down as needed and sent as JPEG;
+//   - whatever is still unsupported or over the byte budget of the request
+//     is replaced by its summary from Config.Summarizer, or else by a note
+//     that it was left out, so that the model knows it exists.
+package artifactinject
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// DefaultStateKey is the default Config.StateKey.
+const DefaultStateKey = "artifacts"
+
+// DefaultMIMETypes are the MIME types sent to the model as they are unless
+// Config.MIMETypes says otherwise.
+var DefaultMIMETypes = []string{
+	"text/plain",
+	"application/pdf",
+	"image/png",
+	"image/jpeg",
+	"image/webp",
+	"audio/*",
+	"video/*",
+}
+
+// Converter turns an artifact the model does not support into one it does,
+// typically text.
+type Converter func(name string, blob *genai.Blob) (*genai.Part, error)
+
+// Summarizer returns a short text standing in for an artifact that does not
+// fit the request.
+type Summarizer func(ctx agent.CallbackContext, name string, part *genai.Part) (string, error)
+
+// Config configures the callback.
+type Config struct {
+	// StateKey is the state key that may hold the names of artifacts to
+	// send with every request. It defaults to DefaultStateKey.
+	StateKey string
+	// MIMETypes are the MIME types the model supports; a type may end in
+	// "/*". They default to DefaultMIMETypes.
+	MIMETypes []string
+	// Converters convert artifacts by MIME type, on top of the default
+	// ones for CSV, DOCX and JSON.
+	Converters map[string]Converter
+	// MaxBytes is the most bytes of artifacts added to one request. It
+	// defaults to 4 MiB.
+	MaxBytes int
+	// MaxImageBytes is the size over which images are scaled down. It
+	// defaults to 1 MiB.
+	MaxImageBytes int
+	// Summarizer, if set, summarizes the artifacts that do not fit.
+	Summarizer Summarizer
+}
+
+// New returns a callback that adds the artifacts the conversation refers to
+// to the request, as described in the package documentation. It logs
+// artifacts it fails to load and leaves them out.
+func New(cfg Config) llmagent.BeforeModelCallback {
+	if cfg.StateKey == "" {
+		cfg.StateKey = DefaultStateKey
+	}
+	if cfg.MIMETypes == nil {
+		cfg.MIMETypes = DefaultMIMETypes
+	}
+	converters := map[string]Converter{
+		"text/csv":         ConvertCSV,
+		"application/json": ConvertText,
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document": ConvertDOCX,
+	}
+	for mimeType, c := range cfg.Converters {
+		converters[mimeType] = c
+	}
+	cfg.Converters = converters
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = 4 << 20
+	}
+	if cfg.MaxImageBytes <= 0 {
+		cfg.MaxImageBytes = 1 << 20
+	}
+	return func(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+		names, err := references(ctx, cfg.StateKey)
+		if err != nil {
+			return nil, err
+		}
+		budget := cfg.MaxBytes
+		var parts []*genai.Part
+		for _, name := range names {
+			resp, err := ctx.Artifacts().Load(ctx, name)
+			if err != nil {
+				log.Printf("Failed to load artifact %s: %v", name, err)
+				continue
+			}
+			part, note := cfg.prepare(name, resp.Part, budget)
+			if part == nil && cfg.Summarizer != nil {
+				summary, err := cfg.Summarizer(ctx, name, resp.Part)
+				if err != nil {
+					log.Printf("Failed to summarize artifact %s: %v", name, err)
+				} else if len(summary) <= budget {
+					part, note = genai.NewPartFromText(summary), "summary"
+				}
+			}
+			if part == nil {
+				parts = append(parts, genai.NewPartFromText(fmt.Sprintf("Artifact %s was left out: %s.", name, note)))
+				continue
+			}
+			budget -= size(part)
+			header := "Artifact " + name + " is:"
+			if note != "" {
+				header = fmt.Sprintf("Artifact %s (%s) is:", name, note)
+			}
+			parts = append(parts, genai.NewPartFromText(header), part)
+		}
+		if len(parts) > 0 {
+			addParts(req, parts)
+		}
+		return nil, nil
+	}
+}
+
+// prepare returns part in a form the model supports and that fits budget,
+// with a note on how it was converted, or nil and the reason it cannot.
+func (cfg *Config) prepare(name string, part *genai.Part, budget int) (*genai.Part, string) {
+	if part.InlineData != nil {
+		blob := part.InlineData
+		limit := min(cfg.MaxImageBytes, budget)
+		switch {
+		case strings.HasPrefix(blob.MIMEType, "image/") && (len(blob.Data) > limit || !cfg.supports(blob.MIMEType)):
+			scaled, err := ScaleImage(blob, limit)
+			if err != nil {
+				return nil, fmt.Sprintf("%s image of %d bytes could not be converted: %v", blob.MIMEType, len(blob.Data), err)
+			}
+			if len(scaled.InlineData.Data) < len(blob.Data) {
+				return scaled, "scaled down"
+			}
+			return scaled, "converted from " + blob.MIMEType
+		case !cfg.supports(blob.MIMEType):
+			convert, ok := cfg.Converters[blob.MIMEType]
+			if !ok && strings.HasPrefix(blob.MIMEType, "text/") {
+				convert, ok = ConvertText, true
+			}
+			if !ok {
+				return nil, fmt.Sprintf("the model does not support %s", blob.MIMEType)
+			}
+			converted, err := convert(name, blob)
+			if err != nil {
+				return nil, fmt.Sprintf("%s could not be converted: %v", blob.MIMEType, err)
+			}
+			if size(converted) > budget {
+				return nil, fmt.Sprintf("its text of %d bytes is over the budget of %d", size(converted), budget)
+			}
+			return converted, "converted from " + blob.MIMEType
+		}
+	}
+	if n := size(part); n > budget {
+		return nil, fmt.Sprintf("its %d bytes are over the budget of %d", n, budget)
+	}
+	return part, ""
+}
+
+func (cfg *Config) supports(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	for _, pattern := range cfg.MIMETypes {
+		if ok, _ := path.Match(pattern, mimeType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// references returns the artifacts named in the message of the user or in
+// the state key.
+func references(ctx agent.CallbackContext, stateKey string) ([]string, error) {
+	var names []string
+	add := func(name string) {
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	switch v, _ := ctx.State().Get(stateKey); v := v.(type) {
+	case string:
+		add(v)
+	case []string:
+		for _, name := range v {
+			add(name)
+		}
+	case []any:
+		for _, name := range v {
+			if name, ok := name.(string); ok {
+				add(name)
+			}
+		}
+	}
+
+	var text strings.Builder
+	if c := ctx.UserContent(); c != nil {
+		for _, p := range c.Parts {
+			text.WriteString(p.Text + "\n")
+		}
+	}
+	if text.Len() == 0 {
+		return names, nil
+	}
+	resp, err := ctx.Artifacts().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	for _, name := range resp.FileNames {
+		if mentions(text.String(), strings.TrimPrefix(name, "user:")) {
+			add(name)
+		}
+	}
+	return names, nil
+}
+
+// mentions reports whether text names name as a whole word or path
+// element: "a.csv" is in "Read a.csv." and "reports/a.csv", but not in
+// "data.csv" or "a.csv.bak".
+func mentions(text, name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; ; {
+		j := strings.Index(text[i:], name)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(name)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		if !nameRune(before) && before != '.' && !continues(text[end:]) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+// continues reports whether rest, the text after a match, continues the
+// name matched. A final period ends a sentence, not the name.
+func continues(rest string) bool {
+	r, n := utf8.DecodeRuneInString(rest)
+	if r == '.' {
+		r, _ = utf8.DecodeRuneInString(rest[n:])
+	}
+	return nameRune(r)
+}
+
+// nameRune reports whether r may be part of a word of a name.
+func nameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+}
+
+// size returns the bytes part adds to a request.
+func size(part *genai.Part) int {
+	if part.InlineData != nil {
+		return len(part.InlineData.Data)
+	}
+	return len(part.Text)
+}
+
+// addParts adds parts to the message of the user at the end of req, or in a
+// message of their own. The contents of req may be shared with the session,
+// so the message is copied rather than changed.
+func addParts(req *model.LLMRequest, parts []*genai.Part) {
+	if n := len(req.Contents); n > 0 && req.Contents[n-1] != nil && req.Contents[n-1].Role == genai.RoleUser {
+		last := *req.Contents[n-1]
+		last.Parts = append(slices.Clip(last.Parts), parts...)
+		req.Contents = append(slices.Clip(req.Contents[:n-1]), &last)
+		return
+	}
+	req.Contents = append(req.Contents, &genai.Content{Role: genai.RoleUser, Parts: parts})
+}
@@ -0,0 +1,232 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactinject
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/png"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/fakellm"
+)
+
+// run saves artifacts to a new session with state, sends prompt to an agent
+// with the callback and returns the parts the callback added to the request.
+func run(t *testing.T, cfg Config, artifacts map[string]*genai.Part, state map[string]any, prompt string) []*genai.Part {
+	t.Helper()
+	arts := artifact.InMemoryService()
+	for name, part := range artifacts {
+		if _, err := arts.Save(t.Context(), &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "s1", FileName: name, Part: part}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	llm := fakellm.New("fake", fakellm.Text("OK."))
+	a, err := llmagent.New(llmagent.Config{Name: "assistant", Model: llm, BeforeModelCallbacks: []llmagent.BeforeModelCallback{New(cfg)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := session.InMemoryService()
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessions, ArtifactService: arts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1", State: state}); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range r.Run(t.Context(), "user", "s1", genai.NewContentFromText(prompt, genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() failed: %v", err)
+		}
+	}
+
+	// The callback must not change the message stored in the session.
+	resp, err := sessions.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(resp.Session.Events().At(0).Content.Parts); n != 1 {
+		t.Errorf("stored message of the user has %d parts, want 1", n)
+	}
+
+	reqs := llm.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("model was called %d times, want 1", len(reqs))
+	}
+	return added(reqs[0])
+}
+
+// added returns the parts after the prompt in the last content of req.
+func added(req *model.LLMRequest) []*genai.Part {
+	last := req.Contents[len(req.Contents)-1]
+	return last.Parts[1:]
+}
+
+func texts(parts []*genai.Part) string {
+	var b strings.Builder
+	for _, p := range parts {
+		b.WriteString(p.Text + "\n")
+	}
+	return b.String()
+}
+
+func TestMentioned(t *testing.T) {
+	pdf := genai.NewPartFromBytes([]byte("%PDF-1.7 report"), "application/pdf")
+	got := run(t, Config{}, map[string]*genai.Part{
+		"generated_report.pdf": pdf,
+		"other.pdf":            genai.NewPartFromBytes([]byte("%PDF-1.7 other"), "application/pdf"),
+		"user:settings.json":   genai.NewPartFromBytes([]byte(`{"theme":"dark"}`), "application/json"),
+	}, nil, "Summarize generated_report.pdf using my settings.json please.")
+
+	if len(got) != 4 {
+		t.Fatalf("added %d parts, want a header and a part for 2 artifacts: %q", len(got), texts(got))
+	}
+	if got[0].Text != "Artifact generated_report.pdf is:" || got[1].InlineData == nil || string(got[1].InlineData.Data) != "%PDF-1.7 report" {
+		t.Errorf("first artifact = %q, %+v, want the PDF as it is", got[0].Text, got[1].InlineData)
+	}
+	if got[2].Text != "Artifact user:settings.json (converted from application/json) is:" || got[3].Text != `{"theme":"dark"}` {
+		t.Errorf("second artifact = %q, %q, want the JSON as text", got[2].Text, got[3].Text)
+	}
+}
+
+func TestMentions(t *testing.T) {
+	for _, c := range []struct {
+		text, name string
+		want       bool
+	}{
+		{"Read a.csv please.", "a.csv", true},
+		{"Read a.csv.", "a.csv", true},
+		{"a.csv", "a.csv", true},
+		{"Compare reports/a.csv and b.csv", "a.csv", true},
+		{"(a.csv)", "a.csv", true},
+		{"Read data.csv.", "a.csv", false},
+		{"Read a.csv.bak", "a.csv", false},
+		{"Read a.csvx", "a.csv", false},
+		{"Read my_a.csv", "a.csv", false},
+		{"Read x.a.csv", "a.csv", false},
+		// A later mention counts when the first is part of another name.
+		{"data.csv, then a.csv", "a.csv", true},
+		{"Read reports/a.csv", "reports/a.csv", true},
+		{"Read old-reports/a.csv", "reports/a.csv", false},
+		{"anything", "", false},
+	} {
+		if got := mentions(c.text, c.name); got != c.want {
+			t.Errorf("mentions(%q, %q) = %v, want %v", c.text, c.name, got, c.want)
+		}
+	}
+}
+
+func TestStateAndConversions(t *testing.T) {
+	got := run(t, Config{}, map[string]*genai.Part{
+		"sales.csv":  genai.NewPartFromBytes([]byte("region,total\nnorth,10\n\"a|b\",20\n"), "text/csv"),
+		"memo.docx":  genai.NewPartFromBytes(docx(t, "First paragraph.", "Second one."), "application/vnd.openxmlformats-officedocument.wordprocessingml.document"),
+		"bundle.zip": genai.NewPartFromBytes([]byte("PK"), "application/zip"),
+	}, map[string]any{"artifacts": []any{"sales.csv", "memo.docx", "bundle.zip"}}, "Compare the files.")
+
+	want := "Artifact sales.csv (converted from text/csv) is:\n" +
+		"| region | total |\n| --- | --- |\n| north | 10 |\n| a\\|b | 20 |\n\n" +
+		"Artifact memo.docx (converted from application/vnd.openxmlformats-officedocument.wordprocessingml.document) is:\n" +
+		"First paragraph.\nSecond one.\n\n" +
+		"Artifact bundle.zip was left out: the model does not support application/zip.\n"
+	if got := texts(got); got != want {
+		t.Errorf("added parts =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLargeImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 256, 256))
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(rng.IntN(256))
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	got := run(t, Config{MaxImageBytes: 16 << 10}, map[string]*genai.Part{
+		"photo.png": genai.NewPartFromBytes(buf.Bytes(), "image/png"),
+	}, nil, "What is in photo.png?")
+
+	if len(got) != 2 || got[0].Text != "Artifact photo.png (scaled down) is:" {
+		t.Fatalf("added parts = %q, want the scaled down photo", texts(got))
+	}
+	blob := got[1].InlineData
+	if blob.MIMEType != "image/jpeg" || len(blob.Data) > 16<<10 {
+		t.Errorf("scaled image is %s of %d bytes, want JPEG of at most %d", blob.MIMEType, len(blob.Data), 16<<10)
+	}
+	scaled, _, err := image.Decode(bytes.NewReader(blob.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := scaled.Bounds().Dx(); w >= 256 {
+		t.Errorf("scaled image is %d pixels wide, want less than 256", w)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	artifacts := map[string]*genai.Part{
+		"a.txt": genai.NewPartFromText(strings.Repeat("a", 60)),
+		"b.txt": genai.NewPartFromText(strings.Repeat("b", 60)),
+	}
+	state := map[string]any{"artifacts": []string{"a.txt", "b.txt"}}
+
+	got := run(t, Config{MaxBytes: 100}, artifacts, state, "Read them.")
+	if len(got) != 3 || got[1].Text != strings.Repeat("a", 60) || got[2].Text != "Artifact b.txt was left out: its 60 bytes are over the budget of 40." {
+		t.Errorf("added parts = %q, want a.txt and a note on b.txt", texts(got))
+	}
+
+	summarize := func(ctx agent.CallbackContext, name string, part *genai.Part) (string, error) {
+		return "sixty b's", nil
+	}
+	got = run(t, Config{MaxBytes: 100, Summarizer: summarize}, artifacts, state, "Read them.")
+	if len(got) != 4 || got[2].Text != "Artifact b.txt (summary) is:" || got[3].Text != "sixty b's" {
+		t.Errorf("added parts = %q, want a.txt and a summary of b.txt", texts(got))
+	}
+}
+
+// docx returns a minimal Word document with paragraphs.
+func docx(t *testing.T, paragraphs ...string) []byte {
+	t.Helper()
+	var body strings.Builder
+	for _, p := range paragraphs {
+		body.WriteString("<w:p><w:r><w:t>" + p + "</w:t></w:r></w:p>")
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>` +
+		`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		body.String() + `</w:body></w:document>`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
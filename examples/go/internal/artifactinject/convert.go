@@ -0,0 +1,157 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactinject
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // for image.Decode
+	"image/jpeg"
+	_ "image/png" // for image.Decode
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/genai"
+)
+
+// ConvertText returns the content of a text artifact as a text part.
+func ConvertText(name string, blob *genai.Blob) (*genai.Part, error) {
+	if !utf8.Valid(blob.Data) {
+		return nil, errors.New("not UTF-8 text")
+	}
+	return genai.NewPartFromText(string(blob.Data)), nil
+}
+
+// ConvertCSV returns a CSV artifact as a Markdown table.
+func ConvertCSV(name string, blob *genai.Blob) (*genai.Part, error) {
+	r := csv.NewReader(bytes.NewReader(blob.Data))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	for i, record := range records {
+		b.WriteString("|")
+		for _, field := range record {
+			b.WriteString(" " + strings.ReplaceAll(strings.ReplaceAll(field, "|", `\|`), "\n", " ") + " |")
+		}
+		b.WriteString("\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", len(record)) + "\n")
+		}
+	}
+	return genai.NewPartFromText(b.String()), nil
+}
+
+// ConvertDOCX returns the text of a Word document, a paragraph per line.
+func ConvertDOCX(name string, blob *genai.Blob) (*genai.Part, error) {
+	zr, err := zip.NewReader(bytes.NewReader(blob.Data), int64(len(blob.Data)))
+	if err != nil {
+		return nil, err
+	}
+	f, err := zr.Open("word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	d := xml.NewDecoder(f)
+	inText := false
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse document: %w", err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			switch tok.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteString("\t")
+			case "br":
+				b.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch tok.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(tok)
+			}
+		}
+	}
+	return genai.NewPartFromText(strings.TrimSpace(b.String()) + "\n"), nil
+}
+
+// ScaleImage re-encodes a PNG, JPEG or GIF image as JPEG, halving its size
+// until it takes at most maxBytes.
+func ScaleImage(blob *genai.Blob, maxBytes int) (*genai.Part, error) {
+	img, _, err := image.Decode(bytes.NewReader(blob.Data))
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, err
+		}
+		if buf.Len() <= maxBytes {
+			return &genai.Part{InlineData: &genai.Blob{MIMEType: "image/jpeg", DisplayName: blob.DisplayName, Data: buf.Bytes()}}, nil
+		}
+		b := img.Bounds()
+		if b.Dx() < 32 || b.Dy() < 32 {
+			return nil, fmt.Errorf("does not fit in %d bytes", maxBytes)
+		}
+		img = half(img)
+	}
+}
+
+// half scales img down to half its width and height, averaging every 2x2
+// block of pixels.
+func half(img image.Image) image.Image {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx()/2, b.Dy()/2))
+	for y := range out.Rect.Dy() {
+		for x := range out.Rect.Dx() {
+			var r, g, bl, a uint32
+			for _, d := range [4][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				pr, pg, pb, pa := img.At(b.Min.X+2*x+d[0], b.Min.Y+2*y+d[1]).RGBA()
+				r, g, bl, a = r+pr, g+pg, bl+pb, a+pa
+			}
+			i := out.PixOffset(x, y)
+			out.Pix[i+0] = uint8(r / 4 >> 8)
+			out.Pix[i+1] = uint8(g / 4 >> 8)
+			out.Pix[i+2] = uint8(bl / 4 >> 8)
+			out.Pix[i+3] = uint8(a / 4 >> 8)
+		}
+	}
+	return out
+}
@@ -0,0 +1,185 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifactlist lists artifacts with their metadata, a page at a
+// time, where artifact.Service.List only returns their names.
+//
+// A Lister wraps the artifact.Service the runner uses, and lists the
+// artifacts of the session of any tool.Context or agent.CallbackContext:
+//
+//	lister := artifactlist.New(artifactService)
+//	...
+//	page, err := lister.List(ctx, &artifactlist.Query{Pattern: "*.pdf", PageSize: 20})
+//	for _, f := range page.Files {
+//		fmt.Printf("%s v%d %s %d bytes\n", f.Name, f.Version, f.MIMEType, f.Size)
+//	}
+//
+// Services that implement Describer, such as fileartifact.Service, describe
+// an artifact from its metadata. For others, the Lister loads the latest
+// version, and the times are unknown.
+package artifactlist
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+)
+
+// Info describes an artifact.
+type Info struct {
+	Name string
+	// Version is the latest version, and Versions the number of versions.
+	Version  int64
+	Versions int
+	// MIMEType and Size are those of the latest version. The MIME type of
+	// a text artifact is "text/plain".
+	MIMEType string
+	Size     int64
+	// CreateTime is when the first version still stored was saved, and
+	// UpdateTime when the latest was. They are zero if the service does not
+	// record them.
+	CreateTime, UpdateTime time.Time
+}
+
+// Describer is implemented by services that can describe an artifact
+// without loading it.
+type Describer interface {
+	Describe(ctx context.Context, req *artifact.VersionsRequest) (*Info, error)
+}
+
+// Query selects a page of artifacts.
+type Query struct {
+	// Prefix, if set, only matches names that start with it.
+	Prefix string
+	// Pattern, if set, only matches names that match it as in path.Match,
+	// such as "*.pdf" or "user:*".
+	Pattern string
+	// PageSize is the most artifacts in a page. It defaults to 50.
+	PageSize int
+	// PageToken is the NextPageToken of the previous page, or empty for the
+	// first page.
+	PageToken string
+}
+
+// Page is a page of artifacts, sorted by name.
+type Page struct {
+	Files []Info
+	// NextPageToken gets the next page, or is empty if this is the last.
+	NextPageToken string
+}
+
+// Lister lists the artifacts of a service with their metadata.
+type Lister struct {
+	svc artifact.Service
+}
+
+// New returns a Lister of the artifacts of svc.
+func New(svc artifact.Service) *Lister {
+	return &Lister{svc: svc}
+}
+
+// List returns a page of the artifacts of the session of ctx, including
+// those of its user.
+func (l *Lister) List(ctx agent.ReadonlyContext, q *Query) (*Page, error) {
+	return l.ListSession(ctx, ctx.AppName(), ctx.UserID(), ctx.SessionID(), q)
+}
+
+// ListSession returns a page of the artifacts of a session, including those
+// of its user.
+func (l *Lister) ListSession(ctx context.Context, appName, userID, sessionID string, q *Query) (*Page, error) {
+	if q.Pattern != "" {
+		if _, err := path.Match(q.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", q.Pattern, err)
+		}
+	}
+	after := ""
+	if q.PageToken != "" {
+		b, err := base64.RawURLEncoding.DecodeString(q.PageToken)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page token %q", q.PageToken)
+		}
+		after = string(b)
+	}
+	pageSize := q.PageSize
+	if pageSize <= 0 {
+		pageSize = 50
+	}
+
+	resp, err := l.svc.List(ctx, &artifact.ListRequest{AppName: appName, UserID: userID, SessionID: sessionID})
+	if err != nil {
+		return nil, err
+	}
+	// The page tokens rely on the names being sorted, which not every service
+	// guarantees.
+	fileNames := slices.Clone(resp.FileNames)
+	slices.Sort(fileNames)
+	var names []string
+	for _, name := range fileNames {
+		if q.PageToken != "" && name <= after {
+			continue
+		}
+		if !strings.HasPrefix(name, q.Prefix) {
+			continue
+		}
+		if ok, _ := path.Match(q.Pattern, name); q.Pattern != "" && !ok {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	page := &Page{Files: []Info{}}
+	if len(names) > pageSize {
+		names = names[:pageSize]
+		page.NextPageToken = base64.RawURLEncoding.EncodeToString([]byte(names[len(names)-1]))
+	}
+	for _, name := range names {
+		info, err := l.describe(ctx, &artifact.VersionsRequest{AppName: appName, UserID: userID, SessionID: sessionID, FileName: name})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe artifact %s: %w", name, err)
+		}
+		page.Files = append(page.Files, *info)
+	}
+	return page, nil
+}
+
+func (l *Lister) describe(ctx context.Context, req *artifact.VersionsRequest) (*Info, error) {
+	if d, ok := l.svc.(Describer); ok {
+		return d.Describe(ctx, req)
+	}
+	versions, err := l.svc.Versions(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := l.svc.Load(ctx, &artifact.LoadRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName})
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{Name: req.FileName, Versions: len(versions.Versions)}
+	for _, v := range versions.Versions {
+		info.Version = max(info.Version, v)
+	}
+	if blob := resp.Part.InlineData; blob != nil {
+		info.MIMEType, info.Size = blob.MIMEType, int64(len(blob.Data))
+	} else {
+		info.MIMEType, info.Size = "text/plain", int64(len(resp.Part.Text))
+	}
+	return info, nil
+}
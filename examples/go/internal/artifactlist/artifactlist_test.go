@@ -0,0 +1,234 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactlist_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/artifactlist"
+	"github.com/google/adk-docs/examples/go/internal/fakellm"
+	"github.com/google/adk-docs/examples/go/internal/fileartifact"
+)
+
+var services = map[string]func(t *testing.T) artifact.Service{
+	"memory": func(t *testing.T) artifact.Service { return artifact.InMemoryService() },
+	"files": func(t *testing.T) artifact.Service {
+		s, err := fileartifact.Open(t.TempDir(), fileartifact.Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	},
+}
+
+func save(t *testing.T, svc artifact.Service, sessionID, name string, part *genai.Part) {
+	t.Helper()
+	if _, err := svc.Save(t.Context(), &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: sessionID, FileName: name, Part: part}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func names(page *artifactlist.Page) []string {
+	var names []string
+	for _, f := range page.Files {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func TestList(t *testing.T) {
+	for name, newService := range services {
+		t.Run(name, func(t *testing.T) {
+			svc := newService(t)
+			save(t, svc, "s1", "report.pdf", genai.NewPartFromBytes([]byte("%PDF-1"), "application/pdf"))
+			save(t, svc, "s1", "report.pdf", genai.NewPartFromBytes([]byte("%PDF-1.7"), "application/pdf"))
+			save(t, svc, "s1", "notes.txt", genai.NewPartFromText("hello"))
+			save(t, svc, "s1", "user:settings.json", genai.NewPartFromBytes([]byte("{}"), "application/json"))
+			save(t, svc, "s2", "other.pdf", genai.NewPartFromBytes([]byte("%PDF"), "application/pdf"))
+			l := artifactlist.New(svc)
+
+			page, err := l.ListSession(t.Context(), "app", "user", "s1", &artifactlist.Query{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := names(page), []string{"notes.txt", "report.pdf", "user:settings.json"}; !slices.Equal(got, want) || page.NextPageToken != "" {
+				t.Fatalf("ListSession() = %q, %q, want %q on one page", got, page.NextPageToken, want)
+			}
+			report := page.Files[1]
+			if report.Version != 2 || report.Versions != 2 || report.MIMEType != "application/pdf" || report.Size != 8 {
+				t.Errorf("report.pdf = %+v, want version 2 of 2, application/pdf, 8 bytes", report)
+			}
+			if notes := page.Files[0]; notes.MIMEType != "text/plain" || notes.Size != 5 {
+				t.Errorf("notes.txt = %+v, want text/plain, 5 bytes", notes)
+			}
+			if _, ok := svc.(artifactlist.Describer); ok {
+				if report.CreateTime.IsZero() || report.UpdateTime.Before(report.CreateTime) {
+					t.Errorf("report.pdf times = %v, %v, want the first and the latest save", report.CreateTime, report.UpdateTime)
+				}
+			}
+
+			for _, tc := range []struct {
+				q    artifactlist.Query
+				want []string
+			}{
+				{artifactlist.Query{Pattern: "*.pdf"}, []string{"report.pdf"}},
+				{artifactlist.Query{Prefix: "user:"}, []string{"user:settings.json"}},
+				{artifactlist.Query{Prefix: "n", Pattern: "*.txt"}, []string{"notes.txt"}},
+			} {
+				page, err := l.ListSession(t.Context(), "app", "user", "s1", &tc.q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := names(page); !slices.Equal(got, tc.want) {
+					t.Errorf("ListSession(%+v) = %q, want %q", tc.q, got, tc.want)
+				}
+			}
+			if _, err := l.ListSession(t.Context(), "app", "user", "s1", &artifactlist.Query{Pattern: "["}); err == nil {
+				t.Error("ListSession() with a bad pattern succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestPages(t *testing.T) {
+	svc := artifact.InMemoryService()
+	var want []string
+	for i := range 7 {
+		name := fmt.Sprintf("file%d.txt", i)
+		save(t, svc, "s1", name, genai.NewPartFromText(name))
+		want = append(want, name)
+	}
+	l := artifactlist.New(svc)
+
+	var got []string
+	q := &artifactlist.Query{PageSize: 3}
+	for pages := 1; ; pages++ {
+		page, err := l.ListSession(t.Context(), "app", "user", "s1", q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, names(page)...)
+		if page.NextPageToken == "" {
+			if pages != 3 {
+				t.Errorf("listed %d pages, want 3", pages)
+			}
+			break
+		}
+		// A file added meanwhile before the page token does not shift the
+		// next page.
+		if pages == 1 {
+			save(t, svc, "s1", "file0a.txt", genai.NewPartFromText("late"))
+		}
+		q.PageToken = page.NextPageToken
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("pages = %q, want %q", got, want)
+	}
+	if _, err := l.ListSession(t.Context(), "app", "user", "s1", &artifactlist.Query{PageToken: "!"}); err == nil {
+		t.Error("ListSession() with a bad page token succeeded, want an error")
+	}
+}
+
+// reversed is an artifact.Service that lists names in reverse order.
+type reversed struct {
+	artifact.Service
+}
+
+func (r reversed) List(ctx context.Context, req *artifact.ListRequest) (*artifact.ListResponse, error) {
+	resp, err := r.Service.List(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(resp.FileNames)
+	return resp, nil
+}
+
+func TestPagesUnsorted(t *testing.T) {
+	svc := artifact.InMemoryService()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		save(t, svc, "s1", name, genai.NewPartFromText(name))
+	}
+	l := artifactlist.New(reversed{svc})
+
+	var got []string
+	q := &artifactlist.Query{PageSize: 2}
+	for {
+		page, err := l.ListSession(t.Context(), "app", "user", "s1", q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, names(page)...)
+		if page.NextPageToken == "" {
+			break
+		}
+		q.PageToken = page.NextPageToken
+	}
+	if want := []string{"a.txt", "b.txt", "c.txt"}; !slices.Equal(got, want) {
+		t.Errorf("pages = %q, want %q", got, want)
+	}
+}
+
+func TestListFromCallback(t *testing.T) {
+	arts := artifact.InMemoryService()
+	save(t, arts, "s1", "report.pdf", genai.NewPartFromBytes([]byte("%PDF"), "application/pdf"))
+	save(t, arts, "other", "hidden.pdf", genai.NewPartFromBytes([]byte("%PDF"), "application/pdf"))
+	l := artifactlist.New(arts)
+
+	var got []string
+	a, err := llmagent.New(llmagent.Config{
+		Name:  "assistant",
+		Model: fakellm.New("fake", fakellm.Text("OK.")),
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{
+			func(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+				page, err := l.List(ctx, &artifactlist.Query{})
+				if err != nil {
+					return nil, err
+				}
+				got = names(page)
+				return nil, nil
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := session.InMemoryService()
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessions, ArtifactService: arts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range r.Run(t.Context(), "user", "s1", genai.NewContentFromText("What files do I have?", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !slices.Equal(got, []string{"report.pdf"}) {
+		t.Errorf("List() in a callback = %q, want the artifacts of the session", got)
+	}
+}
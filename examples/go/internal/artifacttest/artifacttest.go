@@ -0,0 +1,367 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifacttest checks that an artifact.Service behaves like
+// artifact.InMemoryService, which the suite is also run against.
+//
+//	func TestService(t *testing.T) {
+//		artifacttest.Run(t, func(t *testing.T) artifact.Service {
+//			return newService(t)
+//		})
+//	}
+//
+// It pins down the scoping rules the GCS service follows: an artifact
+// belongs to its app, user and session, except that one named "user:..."
+// belongs to its app and user and is shared by all their sessions.
+package artifacttest
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sync"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+)
+
+const (
+	appName = "test_app"
+	userID  = "user1"
+)
+
+// Run runs the suite, calling newService for a fresh, empty service in
+// every subtest.
+func Run(t *testing.T, newService func(t *testing.T) artifact.Service) {
+	t.Run("SaveLoad", func(t *testing.T) { testSaveLoad(t, newService) })
+	t.Run("Versions", func(t *testing.T) { testVersions(t, newService) })
+	t.Run("Missing", func(t *testing.T) { testMissing(t, newService) })
+	t.Run("Scoping", func(t *testing.T) { testScoping(t, newService) })
+	t.Run("List", func(t *testing.T) { testList(t, newService) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newService) })
+	t.Run("Validation", func(t *testing.T) { testValidation(t, newService) })
+	t.Run("Concurrent", func(t *testing.T) { testConcurrent(t, newService) })
+}
+
+// key identifies an artifact.
+type key struct {
+	app, user, session, name string
+}
+
+func (k key) String() string {
+	return fmt.Sprintf("%s/%s/%s/%s", k.app, k.user, k.session, k.name)
+}
+
+func at(session, name string) key {
+	return key{appName, userID, session, name}
+}
+
+func testSaveLoad(t *testing.T, newService func(t *testing.T) artifact.Service) {
+	t.Run("text", func(t *testing.T) {
+		s := newService(t)
+		save(t, s, at("s1", "notes.txt"), genai.NewPartFromText("hello"))
+		if got := load(t, s, at("s1", "notes.txt"), 0); got.Text != "hello" {
+			t.Errorf("Load() = %q, want %q", got.Text, "hello")
+		}
+	})
+
+	t.Run("inline data", func(t *testing.T) {
+		s := newService(t)
+		want := genai.NewPartFromBytes([]byte{0x89, 'P', 'N', 'G', 0}, "image/png")
+		save(t, s, at("s1", "chart.png"), want)
+		got := load(t, s, at("s1", "chart.png"), 0)
+		if got.InlineData == nil || got.InlineData.MIMEType != "image/png" || string(got.InlineData.Data) != string(want.InlineData.Data) {
+			t.Errorf("Load() = %+v, want the PNG bytes", got)
+		}
+	})
+
+	t.Run("versions count from one", func(t *testing.T) {
+		s := newService(t)
+		for i := range 3 {
+			if v := save(t, s, at("s1", "a.txt"), text(i)); v != int64(i+1) {
+				t.Errorf("Save() #%d = version %d, want %d", i+1, v, i+1)
+			}
+		}
+		if v := save(t, s, at("s1", "b.txt"), text(0)); v != 1 {
+			t.Errorf("Save() of another artifact = version %d, want 1", v)
+		}
+	})
+
+	t.Run("loads a version or the latest", func(t *testing.T) {
+		s := newService(t)
+		for i := range 3 {
+			save(t, s, at("s1", "a.txt"), text(i))
+		}
+		if got := load(t, s, at("s1", "a.txt"), 0); got.Text != "v2" {
+			t.Errorf("Load(latest) = %q, want v2", got.Text)
+		}
+		if got := load(t, s, at("s1", "a.txt"), 2); got.Text != "v1" {
+			t.Errorf("Load(version 2) = %q, want v1", got.Text)
+		}
+	})
+}
+
+func testVersions(t *testing.T, newService func(t *testing.T) artifact.Service) {
+	t.Run("latest first", func(t *testing.T) {
+		s := newService(t)
+		for i := range 3 {
+			save(t, s, at("s1", "a.txt"), text(i))
+		}
+		if got := versions(t, s, at("s1", "a.txt")); !slices.Equal(got, []int64{3, 2, 1}) {
+			t.Errorf("Versions() = %v, want [3 2 1]", got)
+		}
+	})
+
+	t.Run("user artifact", func(t *testing.T) {
+		s := newService(t)
+		save(t, s, at("s1", "user:a.txt"), text(0))
+		save(t, s, at("s2", "user:a.txt"), text(1))
+		if got := versions(t, s, at("s3", "user:a.txt")); !slices.Equal(got, []int64{2, 1}) {
+			t.Errorf("Versions() from another session = %v, want [2 1]", got)
+		}
+	})
+}
+
+func testMissing(t *testing.T, newService func(t *testing.T) artifact.Service) {
+	s := newService(t)
+	save(t, s, at("s1", "a.txt"), text(0))
+	for _, tt := range []struct {
+		name    string
+		k       key
+		version int64
+	}{
+		{"file", at("s1", "b.txt"), 0},
+		{"version", at("s1", "a.txt"), 2},
+		{"session", at("s2", "a.txt"), 0},
+		{"user artifact", at("s1", "user:a.txt"), 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Load(t.Context(), &artifact.LoadRequest{AppName: tt.k.app, UserID: tt.k.user, SessionID: tt.k.session, FileName: tt.k.name, Version: tt.version})
+			if !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Load(%s, version %d) = %v, want fs.ErrNotExist", tt.k, tt.version, err)
+			}
+			if tt.version != 0 {
+				return
+			}
+			_, err = s.Versions(t.Context(), &artifact.VersionsRequest{AppName: tt.k.app, UserID: tt.k.user, SessionID: tt.k.session, FileName: tt.k.name})
+			if !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Versions(%s) = %v, want fs.ErrNotExist", tt.k, err)
+			}
+		})
+	}
+
+	t.Run("list of an empty session", func(t *testing.T) {
+		if got := list(t, s, at("s2", "")); len(got) != 0 {
+			t.Errorf("List() = %q, want none", got)
+		}
+	})
+}
+
+func testScoping(t *testing.T, newService func(t *testing.T) artifact.Service) {
+	s := newService(t)
+	save(t, s, at("s1", "summary.txt"), genai.NewPartFromText("session"))
+	save(t, s, at("s1", "user:settings.json"), genai.NewPartFromText("user"))
+
+	for _, tt := range []struct {
+		name string
+		k    key
+		want bool
+	}{
+		{"session artifact in its session", at("s1", "summary.txt"), true},
+		{"session artifact in another session", at("s2", "summary.txt"), false},
+		{"user artifact in its session", at("s1", "user:settings.json"), true},
+		{"user artifact in another session", at("s2", "user:settings.json"), true},
+		{"user artifact of another user", key{appName, "user2", "s1", "user:settings.json"}, false},
+		{"user artifact in another app", key{"other_app", userID, "s1", "user:settings.json"}, false},
+		{"session artifact of another user", key{appName, "user2", "s1", "summary.txt"}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Load(t.Context(), &artifact.LoadRequest{AppName: tt.k.app, UserID: tt.k.user, SessionID: tt.k.session, FileName: tt.k.name})
+			if got := err == nil; got != tt.want {
+				t.Errorf("Load(%s) error = %v, want found = %v", tt.k, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("user artifact is shared", func(t *testing.T) {
+		if v := save(t, s, at("s2", "user:settings.json"), genai.NewPartFromText("updated")); v != 2 {
+			t.Errorf("Save() from another session = version %d, want 2", v)
+		}
+		if got := load(t, s, at("s1", "user:settings.json"), 0); got.Text != "updated" {
+			t.Errorf("Load() = %q, want the version saved from the other session", got.Text)
+		}
+	})
+}
+
+func testList(t *testing.T, newService func(t *testing.T) artifact.Service) {
+	s := newService(t)
+	for _, name := range []string{"c.txt", "user:b.json", "a.txt", "c.txt", "user:a.json"} {
+		save(t, s, at("s1", name), text(0))
+	}
+	save(t, s, at("s2", "other.txt"), text(0))
+	save(t, s, key{appName, "user2", "s1", "user:z.json"}, text(0))
+
+	t.Run("session and user artifacts sorted", func(t *testing.T) {
+		want := []string{"a.txt", "c.txt", "user:a.json", "user:b.json"}
+		if got := list(t, s, at("s1", "")); !slices.Equal(got, want) {
+			t.Errorf("List(s1) = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("user artifacts in another session", func(t *testing.T) {
+		want := []string{"other.txt", "user:a.json", "user:b.json"}
+		if got := list(t, s, at("s2", "")); !slices.Equal(got, want) {
+			t.Errorf("List(s2) = %q, want %q", got, want)
+		}
+	})
+}
+
+func testDelete(t *testing.T, newService func(t *testing.T) artifact.Service) {
+	t.Run("all versions", func(t *testing.T) {
+		s := newService(t)
+		save(t, s, at("s1", "a.txt"), text(0))
+		save(t, s, at("s1", "a.txt"), text(1))
+		save(t, s, at("s1", "b.txt"), text(0))
+		del(t, s, at("s1", "a.txt"), 0)
+		if got := list(t, s, at("s1", "")); !slices.Equal(got, []string{"b.txt"}) {
+			t.Errorf("List() after Delete() = %q, want [b.txt]", got)
+		}
+	})
+
+	t.Run("one version", func(t *testing.T) {
+		s := newService(t)
+		for i := range 3 {
+			save(t, s, at("s1", "a.txt"), text(i))
+		}
+		del(t, s, at("s1", "a.txt"), 2)
+		if got := versions(t, s, at("s1", "a.txt")); !slices.Equal(got, []int64{3, 1}) {
+			t.Errorf("Versions() after deleting version 2 = %v, want [3 1]", got)
+		}
+		del(t, s, at("s1", "a.txt"), 3)
+		if got := load(t, s, at("s1", "a.txt"), 0); got.Text != "v0" {
+			t.Errorf("Load(latest) after deleting the latest version = %q, want v0", got.Text)
+		}
+	})
+
+	t.Run("user artifact from another session", func(t *testing.T) {
+		s := newService(t)
+		save(t, s, at("s1", "user:a.txt"), text(0))
+		del(t, s, at("s2", "user:a.txt"), 0)
+		if got := list(t, s, at("s1", "")); len(got) != 0 {
+			t.Errorf("List() after Delete() = %q, want none", got)
+		}
+	})
+
+	t.Run("no error when not found", func(t *testing.T) {
+		s := newService(t)
+		del(t, s, at("s1", "missing.txt"), 0)
+	})
+}
+
+func testValidation(t *testing.T, newService func(t *testing.T) artifact.Service) {
+	s := newService(t)
+	ctx := t.Context()
+	if _, err := s.Save(ctx, &artifact.SaveRequest{AppName: appName, UserID: userID, FileName: "a.txt", Part: text(0)}); err == nil {
+		t.Error("Save() without a session succeeded, want an error")
+	}
+	if _, err := s.Save(ctx, &artifact.SaveRequest{AppName: appName, UserID: userID, SessionID: "s1", FileName: "a.txt"}); err == nil {
+		t.Error("Save() without a part succeeded, want an error")
+	}
+	if _, err := s.Load(ctx, &artifact.LoadRequest{AppName: appName, SessionID: "s1", FileName: "a.txt"}); err == nil {
+		t.Error("Load() without a user succeeded, want an error")
+	}
+	if _, err := s.List(ctx, &artifact.ListRequest{UserID: userID, SessionID: "s1"}); err == nil {
+		t.Error("List() without an app succeeded, want an error")
+	}
+}
+
+func testConcurrent(t *testing.T, newService func(t *testing.T) artifact.Service) {
+	const goroutines = 10
+	s := newService(t)
+	got := make([]int64, goroutines)
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			k := at("s1", "log.txt")
+			resp, err := s.Save(t.Context(), &artifact.SaveRequest{AppName: k.app, UserID: k.user, SessionID: k.session, FileName: k.name, Part: text(g)})
+			if err != nil {
+				t.Errorf("Save() failed: %v", err)
+				return
+			}
+			got[g] = resp.Version
+		}()
+	}
+	wg.Wait()
+	for g, v := range got {
+		if part := load(t, s, at("s1", "log.txt"), v); part.Text != text(g).Text {
+			t.Errorf("Load(version %d) = %q, want %q", v, part.Text, text(g).Text)
+		}
+	}
+	slices.Sort(got)
+	if want := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}; !slices.Equal(got, want) {
+		t.Errorf("concurrent Save() versions = %v, want %v", got, want)
+	}
+}
+
+func text(i int) *genai.Part {
+	return genai.NewPartFromText(fmt.Sprintf("v%d", i))
+}
+
+func save(t *testing.T, s artifact.Service, k key, part *genai.Part) int64 {
+	t.Helper()
+	resp, err := s.Save(t.Context(), &artifact.SaveRequest{AppName: k.app, UserID: k.user, SessionID: k.session, FileName: k.name, Part: part})
+	if err != nil {
+		t.Fatalf("Save(%s) failed: %v", k, err)
+	}
+	return resp.Version
+}
+
+func load(t *testing.T, s artifact.Service, k key, version int64) *genai.Part {
+	t.Helper()
+	resp, err := s.Load(t.Context(), &artifact.LoadRequest{AppName: k.app, UserID: k.user, SessionID: k.session, FileName: k.name, Version: version})
+	if err != nil {
+		t.Fatalf("Load(%s, version %d) failed: %v", k, version, err)
+	}
+	return resp.Part
+}
+
+func versions(t *testing.T, s artifact.Service, k key) []int64 {
+	t.Helper()
+	resp, err := s.Versions(t.Context(), &artifact.VersionsRequest{AppName: k.app, UserID: k.user, SessionID: k.session, FileName: k.name})
+	if err != nil {
+		t.Fatalf("Versions(%s) failed: %v", k, err)
+	}
+	return resp.Versions
+}
+
+// list lists the artifacts of the session of k.
+func list(t *testing.T, s artifact.Service, k key) []string {
+	t.Helper()
+	resp, err := s.List(t.Context(), &artifact.ListRequest{AppName: k.app, UserID: k.user, SessionID: k.session})
+	if err != nil {
+		t.Fatalf("List(%s/%s/%s) failed: %v", k.app, k.user, k.session, err)
+	}
+	return resp.FileNames
+}
+
+func del(t *testing.T, s artifact.Service, k key, version int64) {
+	t.Helper()
+	if err := s.Delete(t.Context(), &artifact.DeleteRequest{AppName: k.app, UserID: k.user, SessionID: k.session, FileName: k.name, Version: version}); err != nil {
+		t.Fatalf("Delete(%s, version %d) failed: %v", k, version, err)
+	}
+}
@@ -0,0 +1,388 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bm25memory provides a memory.Service that ranks memories with
+// BM25 over an inverted index of the text of the sessions added to it.
+//
+// Unlike memory.InMemoryService, which returns every event sharing a word
+// with the query, it returns the best passages first, weighing rare words
+// above common ones, and shows each as a snippet around the words that
+// matched, marked in **bold**.
+//
+// A query may narrow the search with filters, which is how a tool that only
+// passes a query string can use them:
+//
+//	author:user after:2025-06-01 before:2025-07-01 favorite project
+//
+// after is inclusive and before exclusive; both take a date or an RFC 3339
+// time. SearchHits takes a Query with the same filters and also returns the
+// score and the matched terms of every passage.
+package bm25memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/memorychunk"
+)
+
+// Config configures a Service.
+type Config struct {
+	// Chunking configures how sessions are split into the passages that
+	// are scored.
+	Chunking memorychunk.Options
+	// Limit is the most memories a search returns. It defaults to 5.
+	Limit int
+	// SnippetWords is the length of a snippet. It defaults to 30.
+	SnippetWords int
+	// K1 and B are the BM25 parameters. They default to 1.2 and 0.75.
+	K1, B float64
+}
+
+// Service is a memory.Service with BM25 ranking. It is safe for concurrent
+// use.
+type Service struct {
+	cfg Config
+
+	mu     sync.RWMutex
+	scopes map[scope]*index
+}
+
+type scope struct{ appName, userID string }
+
+// New returns an empty Service.
+func New(cfg Config) *Service {
+	if cfg.Limit <= 0 {
+		cfg.Limit = 5
+	}
+	if cfg.SnippetWords <= 0 {
+		cfg.SnippetWords = 30
+	}
+	if cfg.K1 == 0 {
+		cfg.K1 = 1.2
+	}
+	if cfg.B == 0 {
+		cfg.B = 0.75
+	}
+	return &Service{cfg: cfg, scopes: map[scope]*index{}}
+}
+
+// AddSession indexes the text of sess, replacing what was indexed for it
+// before.
+func (s *Service) AddSession(ctx context.Context, sess session.Session) error {
+	chunks := memorychunk.Split(sess, s.cfg.Chunking)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := scope{sess.AppName(), sess.UserID()}
+	idx, ok := s.scopes[k]
+	if !ok {
+		idx = newIndex()
+		s.scopes[k] = idx
+	}
+	idx.replace(sess.ID(), chunks)
+	return nil
+}
+
+// Query is a search with filters.
+type Query struct {
+	AppName, UserID string
+	// Text holds the words to look for.
+	Text string
+	// Author, if set, only matches passages of this author.
+	Author string
+	// After and Before, if set, only match passages from this time on and
+	// before this time.
+	After, Before time.Time
+	// Limit overrides Config.Limit if positive.
+	Limit int
+}
+
+// ParseQuery returns the Query a memory.SearchRequest stands for, taking the
+// author:, after: and before: filters out of its text.
+func ParseQuery(req *memory.SearchRequest) (*Query, error) {
+	q := &Query{AppName: req.AppName, UserID: req.UserID}
+	var words []string
+	for _, f := range strings.Fields(req.Query) {
+		name, value, ok := strings.Cut(f, ":")
+		if !ok || value == "" {
+			words = append(words, f)
+			continue
+		}
+		var err error
+		switch strings.ToLower(name) {
+		case "author":
+			q.Author = value
+		case "after":
+			q.After, err = parseTime(value)
+		case "before":
+			q.Before, err = parseTime(value)
+		default:
+			words = append(words, f)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter %s: %w", f, err)
+		}
+	}
+	q.Text = strings.Join(words, " ")
+	return q, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// Hit is a passage that matches a Query.
+type Hit struct {
+	memorychunk.Chunk
+	// Score is the BM25 score of the passage.
+	Score float64
+	// Terms are the indexed terms of the query the passage contains.
+	Terms []string
+	// Snippet is the part of the passage with the most matches, with the
+	// matching words in **bold**.
+	Snippet string
+}
+
+// Search implements memory.Service. It returns the snippet of every hit as
+// the content of its memory.
+func (s *Service) Search(ctx context.Context, req *memory.SearchRequest) (*memory.SearchResponse, error) {
+	q, err := ParseQuery(req)
+	if err != nil {
+		return nil, err
+	}
+	hits := s.SearchHits(q)
+	resp := &memory.SearchResponse{Memories: make([]memory.Entry, 0, len(hits))}
+	for _, h := range hits {
+		resp.Memories = append(resp.Memories, memory.Entry{
+			Content:   genai.NewContentFromText(h.Snippet, genai.Role(h.Role)),
+			Author:    h.Author,
+			Timestamp: h.Timestamp,
+		})
+	}
+	return resp, nil
+}
+
+// SearchHits returns the passages matching q, best first.
+func (s *Service) SearchHits(q *Query) []Hit {
+	terms := uniqueTerms(q.Text)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	idx, ok := s.scopes[scope{q.AppName, q.UserID}]
+	if !ok || len(terms) == 0 {
+		return []Hit{}
+	}
+
+	scores := map[int]float64{}
+	matched := map[int][]string{}
+	n := float64(len(idx.docs))
+	avgLen := float64(idx.totalLen) / n
+	for _, term := range terms {
+		postings := idx.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range postings {
+			d := idx.docs[id]
+			if !q.matches(d) {
+				continue
+			}
+			f := float64(tf)
+			scores[id] += idf * f * (s.cfg.K1 + 1) / (f + s.cfg.K1*(1-s.cfg.B+s.cfg.B*float64(d.length)/avgLen))
+			matched[id] = append(matched[id], term)
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		d := idx.docs[id]
+		hits = append(hits, Hit{Chunk: d.chunk, Score: score, Terms: matched[id], Snippet: snippet(d.chunk.Text, matched[id], s.cfg.SnippetWords)})
+	}
+	slices.SortFunc(hits, func(a, b Hit) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), b.Timestamp.Compare(a.Timestamp), cmp.Compare(a.EventID, b.EventID))
+	})
+	limit := s.cfg.Limit
+	if q.Limit > 0 {
+		limit = q.Limit
+	}
+	return hits[:min(len(hits), limit)]
+}
+
+func (q *Query) matches(d *doc) bool {
+	if q.Author != "" && !strings.EqualFold(q.Author, d.chunk.Author) {
+		return false
+	}
+	if !q.After.IsZero() && d.chunk.Timestamp.Before(q.After) {
+		return false
+	}
+	if !q.Before.IsZero() && !d.chunk.Timestamp.Before(q.Before) {
+		return false
+	}
+	return true
+}
+
+// index is an inverted index of the passages of one scope.
+type index struct {
+	nextID   int
+	docs     map[int]*doc
+	sessions map[string][]int       // doc IDs by session
+	postings map[string]map[int]int // term frequency by doc ID, by term
+	totalLen int
+}
+
+type doc struct {
+	chunk  memorychunk.Chunk
+	terms  map[string]int
+	length int
+}
+
+func newIndex() *index {
+	return &index{docs: map[int]*doc{}, sessions: map[string][]int{}, postings: map[string]map[int]int{}}
+}
+
+func (idx *index) replace(sessionID string, chunks []memorychunk.Chunk) {
+	for _, id := range idx.sessions[sessionID] {
+		d := idx.docs[id]
+		for term := range d.terms {
+			delete(idx.postings[term], id)
+			if len(idx.postings[term]) == 0 {
+				delete(idx.postings, term)
+			}
+		}
+		idx.totalLen -= d.length
+		delete(idx.docs, id)
+	}
+	delete(idx.sessions, sessionID)
+
+	for _, c := range chunks {
+		d := &doc{chunk: c, terms: map[string]int{}}
+		for _, w := range memorychunk.Words(c.Text) {
+			d.terms[stem(w)]++
+			d.length++
+		}
+		if d.length == 0 {
+			continue
+		}
+		id := idx.nextID
+		idx.nextID++
+		idx.docs[id] = d
+		idx.sessions[sessionID] = append(idx.sessions[sessionID], id)
+		idx.totalLen += d.length
+		for term, tf := range d.terms {
+			if idx.postings[term] == nil {
+				idx.postings[term] = map[int]int{}
+			}
+			idx.postings[term][id] = tf
+		}
+	}
+}
+
+// uniqueTerms returns the terms of text in order, without repeats.
+func uniqueTerms(text string) []string {
+	var terms []string
+	for _, w := range memorychunk.Words(text) {
+		if t := stem(w); !slices.Contains(terms, t) {
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+// stem folds the common English plural endings, so that "projects" finds
+// "project".
+func stem(w string) string {
+	switch {
+	case len(w) > 4 && strings.HasSuffix(w, "ies"):
+		return w[:len(w)-3] + "y"
+	case len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && !strings.HasSuffix(w, "us"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+// snippet returns the window of size words of text with the most words
+// whose term is in terms, with those words in bold.
+func snippet(text string, terms []string, size int) string {
+	words := strings.Fields(text)
+	hit := make([]bool, len(words))
+	for i, w := range words {
+		for _, part := range memorychunk.Words(w) {
+			if slices.Contains(terms, stem(part)) {
+				hit[i] = true
+			}
+		}
+	}
+
+	start, best := 0, -1
+	for i := 0; i == 0 || i+size <= len(words); i++ {
+		n := 0
+		for _, h := range hit[i:min(i+size, len(words))] {
+			if h {
+				n++
+			}
+		}
+		if n > best {
+			start, best = i, n
+		}
+	}
+	// Center the matches of the window.
+	first, last := -1, -1
+	for i := start; i < min(start+size, len(words)); i++ {
+		if hit[i] {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first >= 0 {
+		start = max(min((first+last)/2-size/2, len(words)-size), 0)
+	}
+	end := min(start+size, len(words))
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("… ")
+	}
+	for i := start; i < end; i++ {
+		if i > start {
+			b.WriteByte(' ')
+		}
+		if hit[i] {
+			b.WriteString("**" + words[i] + "**")
+		} else {
+			b.WriteString(words[i])
+		}
+	}
+	if end < len(words) {
+		b.WriteString(" …")
+	}
+	return b.String()
+}
+
+var _ memory.Service = (*Service)(nil)
@@ -0,0 +1,167 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bm25memory
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/localsession"
+)
+
+var day1 = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+type message struct {
+	author string
+	text   string
+}
+
+func newSession(userID, id string, start time.Time, messages ...message) session.Session {
+	var events []*session.Event
+	for i, m := range messages {
+		e := session.NewEvent("inv")
+		e.ID = fmt.Sprintf("%s-%d", id, i)
+		e.Timestamp = start.Add(time.Duration(i) * time.Minute)
+		e.Author = m.author
+		e.Content = genai.NewContentFromText(m.text, genai.RoleUser)
+		events = append(events, e)
+	}
+	return localsession.New("app", userID, id, nil, events, start)
+}
+
+func populate(t *testing.T, svc *Service) {
+	t.Helper()
+	for _, s := range []session.Session{
+		newSession("alice", "s1", day1,
+			message{"user", "My favorite project is the garden planner, it tracks tomatoes and beans."},
+			message{"agent", "Noted: your favorite project is the garden planner."},
+			message{"user", "The weather is nice and the weather report says the weather stays nice."}),
+		newSession("alice", "s2", day1.AddDate(0, 0, 7),
+			message{"user", "I started two new projects: a compiler and a chess engine."},
+			message{"user", "Lunch was pasta again."}),
+		newSession("bob", "s3", day1,
+			message{"user", "My favorite project is a kernel."}),
+	} {
+		if err := svc.AddSession(t.Context(), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func eventIDs(hits []Hit) []string {
+	var ids []string
+	for _, h := range hits {
+		ids = append(ids, h.EventID)
+	}
+	return ids
+}
+
+func TestRanking(t *testing.T) {
+	svc := New(Config{})
+	populate(t, svc)
+
+	hits := svc.SearchHits(&Query{AppName: "app", UserID: "alice", Text: "What is my favorite project?"})
+	if ids := eventIDs(hits); len(ids) < 3 || !slices.Contains(ids[:2], "s1-0") || !slices.Contains(ids[:2], "s1-1") {
+		t.Fatalf("SearchHits() = %v, want s1-0 and s1-1 first", ids)
+	}
+	// "projects" in s2 matches through stemming, but only one term.
+	if hits[2].EventID != "s2-0" || !slices.Equal(hits[2].Terms, []string{"project"}) {
+		t.Errorf("third hit = %s with terms %v, want s2-0 with [project]", hits[2].EventID, hits[2].Terms)
+	}
+	for i := 1; i < len(hits); i++ {
+		if hits[i].Score > hits[i-1].Score {
+			t.Errorf("hits are not sorted by score: %v", eventIDs(hits))
+		}
+	}
+
+	// Repeating a common word does not beat a rarer one.
+	hits = svc.SearchHits(&Query{AppName: "app", UserID: "alice", Text: "weather tomatoes"})
+	if len(hits) != 2 {
+		t.Fatalf("SearchHits(weather tomatoes) = %v, want 2 hits", eventIDs(hits))
+	}
+
+	if ids := eventIDs(svc.SearchHits(&Query{AppName: "app", UserID: "bob", Text: "favorite project"})); !slices.Equal(ids, []string{"s3-0"}) {
+		t.Errorf("SearchHits() for bob = %v, want [s3-0]", ids)
+	}
+	if hits := svc.SearchHits(&Query{AppName: "app", UserID: "alice", Text: "nothing matches this"}); len(hits) != 0 {
+		t.Errorf("SearchHits() without matches = %v, want none", eventIDs(hits))
+	}
+}
+
+func TestFilters(t *testing.T) {
+	svc := New(Config{})
+	populate(t, svc)
+	for _, tc := range []struct {
+		query string
+		want  []string
+	}{
+		{"author:agent favorite project", []string{"s1-1"}},
+		{"after:2025-06-02 project", []string{"s2-0"}},
+		{"before:2025-06-02 project", []string{"s1-0", "s1-1"}},
+		{"before:2025-06-01T12:01:00Z project", []string{"s1-0"}},
+	} {
+		q, err := ParseQuery(&memory.SearchRequest{AppName: "app", UserID: "alice", Query: tc.query})
+		if err != nil {
+			t.Fatalf("ParseQuery(%q) failed: %v", tc.query, err)
+		}
+		ids := eventIDs(svc.SearchHits(q))
+		slices.Sort(ids)
+		if !slices.Equal(ids, tc.want) {
+			t.Errorf("SearchHits(%q) = %v, want %v", tc.query, ids, tc.want)
+		}
+	}
+	if _, err := ParseQuery(&memory.SearchRequest{Query: "after:yesterday x"}); err == nil {
+		t.Error("ParseQuery() with a bad date succeeded, want an error")
+	}
+}
+
+func TestSnippets(t *testing.T) {
+	svc := New(Config{SnippetWords: 6})
+	populate(t, svc)
+	resp, err := svc.Search(t.Context(), &memory.SearchRequest{AppName: "app", UserID: "alice", Query: "tomatoes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Memories) != 1 {
+		t.Fatalf("Search() returned %d memories, want 1", len(resp.Memories))
+	}
+	m := resp.Memories[0]
+	if got, want := m.Content.Parts[0].Text, "… planner, it tracks **tomatoes** and beans."; got != want {
+		t.Errorf("Search() snippet = %q, want %q", got, want)
+	}
+	if m.Author != "user" || !m.Timestamp.Equal(day1) {
+		t.Errorf("Search() memory author, time = %s, %v, want user, %v", m.Author, m.Timestamp, day1)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	svc := New(Config{})
+	populate(t, svc)
+	if err := svc.AddSession(t.Context(), newSession("alice", "s1", day1, message{"user", "Forget it."})); err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range svc.SearchHits(&Query{AppName: "app", UserID: "alice", Text: "favorite garden planner"}) {
+		if strings.HasPrefix(h.EventID, "s1-") {
+			t.Errorf("SearchHits() returned %s of the replaced session", h.EventID)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cassette provides a model.LLM decorator that records the requests
+// and responses of a live model to a JSONL file, and replays them later
+// without calling the model.
+//
+// A recorded cassette turns a snippet into a deterministic regression test:
+// a replayed run fails with a *MismatchError as soon as the snippet sends
+// the model something it did not send when the cassette was recorded.
+//
+// The examples build their models with gemini.NewModel, so a cassette is
+// served to them as the Gemini API, with fakellm.Handler: golden.UseCassette
+// does so in the tests, and cmd/fake-gemini records a cassette from the live
+// model or replays it from the command line:
+//
+//	GOOGLE_API_KEY=... go run ./cmd/fake-gemini -cassette snippets/tools-custom/weather_sentiment/testdata/cassette.jsonl -record &
+//	cd snippets/tools-custom/weather_sentiment
+//	GOOGLE_GEMINI_BASE_URL=http://localhost:8089 GOOGLE_API_KEY=fake go run .
+package cassette
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"google.golang.org/adk/model"
+)
+
+// Mode selects whether a cassette talks to the live model.
+type Mode string
+
+const (
+	// ModeReplay answers every request from the cassette and never calls
+	// the live model.
+	ModeReplay Mode = "replay"
+	// ModeRecord forwards every request to the live model and appends the
+	// exchange to the cassette, replacing whatever it held before.
+	ModeRecord Mode = "record"
+)
+
+// Interaction is one line of a cassette: a request and everything the model
+// yielded for it.
+type Interaction struct {
+	// Key is the hash of Request, see Key.
+	Key       string               `json:"key"`
+	Model     string               `json:"model,omitempty"`
+	Stream    bool                 `json:"stream,omitempty"`
+	Request   *Request             `json:"request"`
+	Responses []*model.LLMResponse `json:"responses,omitempty"`
+	// Error is the error the model yielded after Responses, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Cassette is a JSONL file of interactions. It is safe for concurrent use,
+// so several models in one program may share it.
+type Cassette struct {
+	path string
+	mode Mode
+
+	mu           sync.Mutex
+	file         *os.File
+	interactions []*Interaction
+	used         []bool
+}
+
+// Open opens the cassette at path. In ModeRecord the file is created, or
+// truncated if it exists; in ModeReplay it must exist.
+func Open(path string, mode Mode) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode}
+	switch mode {
+	case ModeRecord:
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create cassette directory: %w", err)
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cassette: %w", err)
+		}
+		c.file = f
+	case ModeReplay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			var in Interaction
+			if err := json.Unmarshal([]byte(line), &in); err != nil {
+				return nil, fmt.Errorf("cassette %s: line %d: %w", path, i+1, err)
+			}
+			c.interactions = append(c.interactions, &in)
+		}
+		c.used = make([]bool, len(c.interactions))
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q", mode)
+	}
+	return c, nil
+}
+
+// Mode reports the mode the cassette was opened in.
+func (c *Cassette) Mode() Mode {
+	return c.mode
+}
+
+// Unused reports how many recorded interactions have not been replayed yet.
+// A replayed run that leaves some unused sent fewer requests than the
+// recorded one.
+func (c *Cassette) Unused() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, u := range c.used {
+		if !u {
+			n++
+		}
+	}
+	return n
+}
+
+// Close closes the underlying file of a recording cassette.
+func (c *Cassette) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+// Wrap returns a model that records live's traffic to c, or replays it from
+// c. In ModeReplay live may be nil, since it is never called.
+func (c *Cassette) Wrap(live model.LLM) *Model {
+	return &Model{cassette: c, live: live}
+}
+
+func (c *Cassette) record(in *Interaction) error {
+	line, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode interaction: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return fmt.Errorf("cassette %s is closed", c.path)
+	}
+	if _, err := c.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// lookup returns the first unused interaction recorded for req. Requests
+// with equal keys are replayed in the order they were recorded.
+func (c *Cassette) lookup(req *Request, key string) (*Interaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	next := -1
+	for i, in := range c.interactions {
+		if c.used[i] {
+			continue
+		}
+		if next < 0 {
+			next = i
+		}
+		if in.Key == key {
+			c.used[i] = true
+			return in, nil
+		}
+	}
+	if next < 0 {
+		return nil, fmt.Errorf("%w: all %d interactions in %s have been replayed", ErrExhausted, len(c.interactions), c.path)
+	}
+	want := c.interactions[next]
+	path, got, wantPart := diff(req, want.Request)
+	return nil, &MismatchError{
+		Cassette:    c.path,
+		Interaction: next,
+		Path:        path,
+		Got:         got,
+		Want:        wantPart,
+	}
+}
+
+// ErrExhausted is returned when a replayed run sends more requests than were
+// recorded.
+var ErrExhausted = errors.New("cassette exhausted")
+
+// MismatchError is returned when a replayed request matches no recorded
+// interaction. It describes the first difference between the request and
+// the next interaction that has not been replayed yet.
+type MismatchError struct {
+	Cassette string
+	// Interaction is the index of the interaction compared against.
+	Interaction int
+	// Path locates the first difference, e.g. "contents[2].parts[0]".
+	Path string
+	// Got and Want are the JSON encodings of the differing values.
+	Got, Want string
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("cassette %s: request does not match interaction %d: first difference at %s:\n\tgot:  %s\n\twant: %s",
+		e.Cassette, e.Interaction, e.Path, e.Got, e.Want)
+}
+
+// Model is a model.LLM that records to, or replays from, a Cassette.
+type Model struct {
+	cassette *Cassette
+	live     model.LLM
+}
+
+// Name implements model.LLM.
+func (m *Model) Name() string {
+	if m.live == nil {
+		return "cassette"
+	}
+	return m.live.Name()
+}
+
+// GenerateContent implements model.LLM.
+func (m *Model) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	// Normalize before calling the live model, which may modify req.
+	nreq, key, err := normalize(req)
+	if err != nil {
+		return func(yield func(*model.LLMResponse, error) bool) {
+			yield(nil, err)
+		}
+	}
+	if m.cassette.mode == ModeRecord {
+		return m.record(ctx, req, nreq, key, stream)
+	}
+	return m.replay(ctx, nreq, key, stream)
+}
+
+func (m *Model) record(ctx context.Context, req *model.LLMRequest, nreq *Request, key string, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		if m.live == nil {
+			yield(nil, errors.New("cassette: no live model to record"))
+			return
+		}
+		in := &Interaction{Key: key, Model: m.live.Name(), Stream: stream, Request: nreq}
+		stopped := false
+		for resp, err := range m.live.GenerateContent(ctx, req, stream) {
+			if err != nil {
+				in.Error = err.Error()
+			} else {
+				in.Responses = append(in.Responses, resp)
+			}
+			if !yield(resp, err) {
+				stopped = true
+				break
+			}
+			if err != nil {
+				break
+			}
+		}
+		if err := m.cassette.record(in); err != nil {
+			// yield must not be called again once it returned false.
+			if stopped {
+				log.Printf("cassette: %v", err)
+				return
+			}
+			yield(nil, err)
+		}
+	}
+}
+
+func (m *Model) replay(ctx context.Context, nreq *Request, key string, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		in, err := m.cassette.lookup(nreq, key)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for _, resp := range in.Responses {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(resp, nil) {
+				return
+			}
+		}
+		if in.Error != "" {
+			yield(nil, errors.New(in.Error))
+		}
+	}
+}
+
+var _ model.LLM = (*Model)(nil)
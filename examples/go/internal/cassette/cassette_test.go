@@ -0,0 +1,212 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassette_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/cassette"
+	"github.com/google/adk-docs/examples/go/internal/fakellm"
+)
+
+// request returns a request with the given contents.
+func request(contents ...*genai.Content) *model.LLMRequest {
+	return &model.LLMRequest{
+		Contents: contents,
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("Be brief.", genai.RoleUser),
+			HTTPOptions:       &genai.HTTPOptions{Headers: map[string][]string{"X-Run": {"1"}}},
+		},
+	}
+}
+
+// call returns a model content calling get_weather, and the user content
+// answering it, with the given call ID.
+func call(id string) (*genai.Content, *genai.Content) {
+	return &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{{FunctionCall: &genai.FunctionCall{
+		ID: id, Name: "get_weather", Args: map[string]any{"city": "Paris"},
+	}}}}, &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{
+		ID: id, Name: "get_weather", Response: map[string]any{"weather": "sunny"},
+	}}}}
+}
+
+// generate returns the texts m responds to req with.
+func generate(t *testing.T, m model.LLM, req *model.LLMRequest, stream bool) ([]string, error) {
+	t.Helper()
+	var texts []string
+	for resp, err := range m.GenerateContent(t.Context(), req, stream) {
+		if err != nil {
+			return texts, err
+		}
+		texts = append(texts, resp.Content.Parts[0].Text)
+	}
+	return texts, nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	question := genai.NewContentFromText("Weather in Paris?", genai.RoleUser)
+	fc, fr := call("adk-1")
+	live := fakellm.New("fake",
+		fakellm.Chunks("It is ", "sunny."),
+		fakellm.Text("Sunny in Paris."),
+		fakellm.Fail(errors.New("quota exceeded")),
+	)
+
+	c, err := cassette.Open(path, cassette.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := c.Wrap(live)
+	recorded := [][]string{}
+	for i, req := range []*model.LLMRequest{request(question), request(question, fc, fr), request(question)} {
+		texts, err := generate(t, m, req, i == 0)
+		if i == 2 {
+			if err == nil || err.Error() != "quota exceeded" {
+				t.Errorf("recorded call %d = %v, want the error of the model", i, err)
+			}
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		recorded = append(recorded, texts)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = cassette.Open(path, cassette.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = c.Wrap(nil)
+	// The IDs of the calls, generated anew by each run, do not matter.
+	fc, fr = call("adk-2")
+	replayed := [][]string{}
+	for i, req := range []*model.LLMRequest{request(question), request(question, fc, fr), request(question)} {
+		texts, err := generate(t, m, req, i == 0)
+		if i == 2 {
+			if err == nil || err.Error() != "quota exceeded" {
+				t.Errorf("replayed call %d = %v, want the recorded error", i, err)
+			}
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		replayed = append(replayed, texts)
+	}
+	if diff := cmp.Diff(recorded, replayed); diff != "" {
+		t.Errorf("replayed responses mismatch (-recorded +replayed):\n%s", diff)
+	}
+	want := [][]string{{"It is ", "sunny.", "It is sunny."}, {"Sunny in Paris."}, nil}
+	if diff := cmp.Diff(want, replayed); diff != "" {
+		t.Errorf("replayed responses mismatch (-want +got):\n%s", diff)
+	}
+	if n := c.Unused(); n != 0 {
+		t.Errorf("Unused() = %d, want 0", n)
+	}
+
+	if _, err := generate(t, m, request(question), false); !errors.Is(err, cassette.ErrExhausted) {
+		t.Errorf("GenerateContent() after the cassette = %v, want ErrExhausted", err)
+	}
+}
+
+func TestKey(t *testing.T) {
+	question := genai.NewContentFromText("Weather in Paris?", genai.RoleUser)
+	fc1, fr1 := call("adk-1")
+	fc2, fr2 := call("adk-2")
+	k1, err := cassette.Key(request(question, fc1, fr1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, err := cassette.Key(request(question, fc2, fr2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k1 != k2 {
+		t.Errorf("keys of requests differing in call IDs and HTTP options differ: %s, %s", k1, k2)
+	}
+	k3, err := cassette.Key(request(genai.NewContentFromText("Weather in Rome?", genai.RoleUser), fc1, fr1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k1 == k3 {
+		t.Error("keys of requests differing in contents are equal")
+	}
+}
+
+func TestMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.jsonl")
+	c, err := cassette.Open(path, cassette.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc, fr := call("adk-1")
+	question := genai.NewContentFromText("Weather in Paris?", genai.RoleUser)
+	if _, err := generate(t, c.Wrap(fakellm.New("fake", fakellm.Text("Sunny."))), request(question, fc, fr), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = cassette.Open(path, cassette.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc.Parts[0].FunctionCall.Args["city"] = "Rome"
+	_, err = generate(t, c.Wrap(nil), request(question, fc, fr), false)
+	var mismatch *cassette.MismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("GenerateContent() = %v, want a *MismatchError", err)
+	}
+	want := &cassette.MismatchError{
+		Cassette:    path,
+		Interaction: 0,
+		Path:        "contents[1].parts[0]",
+		Got:         `{"functionCall":{"args":{"city":"Rome"},"name":"get_weather"}}`,
+		Want:        `{"functionCall":{"args":{"city":"Paris"},"name":"get_weather"}}`,
+	}
+	if diff := cmp.Diff(want, mismatch); diff != "" {
+		t.Errorf("MismatchError mismatch (-want +got):\n%s", diff)
+	}
+	if n := c.Unused(); n != 1 {
+		t.Errorf("Unused() = %d, want 1", n)
+	}
+}
+
+// TestRecordStopped stops iterating before a recording fails to be
+// written, which must not call yield again.
+func TestRecordStopped(t *testing.T) {
+	c, err := cassette.Open(filepath.Join(t.TempDir(), "cassette.jsonl"), cassette.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := c.Wrap(fakellm.New("fake", fakellm.Chunks("a", "b"), fakellm.Text("c")))
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for range m.GenerateContent(t.Context(), request(), true) {
+		break
+	}
+	// Still iterating, the consumer gets the error.
+	if _, err := generate(t, m, request(), false); err == nil {
+		t.Error("GenerateContent() on a closed cassette succeeded, want an error")
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Request is the normalized form of a model.LLMRequest that is stored in a
+// cassette and hashed to match replayed requests against recorded ones.
+//
+// Normalization drops everything that legitimately changes between two runs
+// of the same program: function call and response IDs, which the ADK
+// generates randomly, and the HTTP options of the config. Tools are reduced
+// to their sorted names; their declarations are part of Config.
+type Request struct {
+	Contents []*genai.Content             `json:"contents,omitempty"`
+	Config   *genai.GenerateContentConfig `json:"config,omitempty"`
+	Tools    []string                     `json:"tools,omitempty"`
+}
+
+// Key returns the hex SHA-256 of the normalized form of req.
+func Key(req *model.LLMRequest) (string, error) {
+	_, key, err := normalize(req)
+	return key, err
+}
+
+func normalize(req *model.LLMRequest) (*Request, string, error) {
+	n := &Request{}
+	if err := clone(req.Contents, &n.Contents); err != nil {
+		return nil, "", fmt.Errorf("failed to normalize contents: %w", err)
+	}
+	for _, c := range n.Contents {
+		stripIDs(c)
+	}
+	if req.Config != nil {
+		if err := clone(req.Config, &n.Config); err != nil {
+			return nil, "", fmt.Errorf("failed to normalize config: %w", err)
+		}
+		n.Config.HTTPOptions = nil
+		stripIDs(n.Config.SystemInstruction)
+	}
+	for name := range req.Tools {
+		n.Tools = append(n.Tools, name)
+	}
+	slices.Sort(n.Tools)
+
+	data, err := json.Marshal(n)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return n, hex.EncodeToString(sum[:]), nil
+}
+
+func clone(src, dst any) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func stripIDs(c *genai.Content) {
+	if c == nil {
+		return
+	}
+	for _, p := range c.Parts {
+		if p == nil {
+			continue
+		}
+		if p.FunctionCall != nil {
+			p.FunctionCall.ID = ""
+		}
+		if p.FunctionResponse != nil {
+			p.FunctionResponse.ID = ""
+		}
+	}
+}
+
+// diff returns the location and JSON encodings of the first difference
+// between two normalized requests.
+func diff(got, want *Request) (path, gotJSON, wantJSON string) {
+	for i := 0; i < max(len(got.Contents), len(want.Contents)); i++ {
+		if i >= len(got.Contents) || i >= len(want.Contents) {
+			return fmt.Sprintf("contents[%d]", i), encodeAt(got.Contents, i), encodeAt(want.Contents, i)
+		}
+		g, w := got.Contents[i], want.Contents[i]
+		if g == nil || w == nil || g.Role != w.Role {
+			return fmt.Sprintf("contents[%d]", i), encode(g), encode(w)
+		}
+		for j := 0; j < max(len(g.Parts), len(w.Parts)); j++ {
+			gp, wp := encodeAt(g.Parts, j), encodeAt(w.Parts, j)
+			if gp != wp {
+				return fmt.Sprintf("contents[%d].parts[%d]", i, j), gp, wp
+			}
+		}
+	}
+	if g, w := encode(got.Tools), encode(want.Tools); g != w {
+		return "tools", g, w
+	}
+	if got.Config != nil && want.Config != nil {
+		if g, w := encode(got.Config.SystemInstruction), encode(want.Config.SystemInstruction); g != w {
+			return "config.systemInstruction", g, w
+		}
+	}
+	if g, w := encode(got.Config), encode(want.Config); g != w {
+		return "config", g, w
+	}
+	// The requests are equal, so the recorded key was computed differently,
+	// e.g. by an older version of this package or by hand editing.
+	return "key", "<equal requests>", "<stale key>"
+}
+
+func encodeAt[T any](s []T, i int) string {
+	if i >= len(s) {
+		return "<missing>"
+	}
+	return encode(s[i])
+}
+
+func encode(v any) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	return string(bytes.TrimSpace(buf.Bytes()))
+}
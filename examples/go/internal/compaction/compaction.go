@@ -0,0 +1,373 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compaction keeps the history of long-lived sessions short by
+// replacing runs of old events with a summary event.
+//
+// Wrap a session service and hand it to the runner:
+//
+//	svc := compaction.Wrap(session.InMemoryService(), compaction.Config{
+//		Summarizer: compaction.AgentSummarizer(summarizerAgent),
+//		MaxEvents:  20,
+//	})
+//	r, err := runner.New(runner.Config{AppName: appName, Agent: a, SessionService: svc})
+//
+// Nothing is deleted from the wrapped service: the summary is appended like
+// any other event and records which events it replaces. The sessions the
+// wrapper returns show the compacted view, in which the summary stands in
+// for those events, so that is all agents send to the model.
+package compaction
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"time"
+
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/localsession"
+)
+
+// MetadataKey is the key of a summary event's CustomMetadata that holds its
+// Range.
+const MetadataKey = "compaction"
+
+// SummaryPrefix starts the text of every summary event.
+const SummaryPrefix = "Summary of the earlier conversation:\n\n"
+
+// Range describes the events a summary event replaces.
+type Range struct {
+	// StartEventID and EndEventID are the IDs of the first and the last
+	// stored event replaced.
+	StartEventID string `json:"startEventId"`
+	EndEventID   string `json:"endEventId"`
+	// StateDelta is the combined state delta of the replaced events. The
+	// summary carries it in the compacted view, so that the view still
+	// adds up to the session state.
+	StateDelta map[string]any `json:"stateDelta,omitempty"`
+}
+
+// Config configures when and how sessions are compacted.
+type Config struct {
+	// Summarizer writes the summaries. It is required.
+	Summarizer Summarizer
+	// MaxEvents compacts a session once its view holds more events than
+	// this. Zero means no limit.
+	MaxEvents int
+	// MaxTokens compacts a session once its view is estimated to take more
+	// tokens than this. Zero means no limit.
+	MaxTokens int
+	// KeepRecent is how many of the latest events are never compacted. It
+	// defaults to 2.
+	KeepRecent int
+	// Tokens estimates the tokens events take. It defaults to
+	// EstimateTokens.
+	Tokens func([]*session.Event) int
+}
+
+// Service is a session.Service that compacts the sessions of another one.
+type Service struct {
+	session.Service
+	cfg Config
+}
+
+// Wrap returns a Service that stores sessions in svc and compacts them as
+// cfg says. Automatic compaction runs after an agent appends a final
+// response, so it never interrupts a tool call.
+func Wrap(svc session.Service, cfg Config) *Service {
+	if cfg.KeepRecent <= 0 {
+		cfg.KeepRecent = 2
+	}
+	if cfg.Tokens == nil {
+		cfg.Tokens = EstimateTokens
+	}
+	return &Service{Service: svc, cfg: cfg}
+}
+
+func (s *Service) Create(ctx context.Context, req *session.CreateRequest) (*session.CreateResponse, error) {
+	resp, err := s.Service.Create(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &session.CreateResponse{Session: &viewSession{Session: resp.Session}}, nil
+}
+
+// Get returns the compacted view of a session. NumRecentEvents and After
+// apply to the view.
+func (s *Service) Get(ctx context.Context, req *session.GetRequest) (*session.GetResponse, error) {
+	full := *req
+	full.NumRecentEvents, full.After = 0, time.Time{}
+	resp, err := s.Service.Get(ctx, &full)
+	if err != nil {
+		return nil, err
+	}
+	filter := *req
+	return &session.GetResponse{Session: &viewSession{Session: resp.Session, filter: &filter}}, nil
+}
+
+func (s *Service) List(ctx context.Context, req *session.ListRequest) (*session.ListResponse, error) {
+	resp, err := s.Service.List(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]session.Session, len(resp.Sessions))
+	for i, sess := range resp.Sessions {
+		sessions[i] = &viewSession{Session: sess}
+	}
+	return &session.ListResponse{Sessions: sessions}, nil
+}
+
+// AppendEvent appends e, then compacts the session if e is an agent's final
+// response and the session has grown past the limits of the Config. A
+// failed compaction is logged; e is appended all the same.
+func (s *Service) AppendEvent(ctx context.Context, sess session.Session, e *session.Event) error {
+	inner := unwrap(sess)
+	if err := s.Service.AppendEvent(ctx, inner, e); err != nil {
+		return err
+	}
+	if e.Partial || e.Author == "user" || !e.IsFinalResponse() || !s.exceeded(inner) {
+		return nil
+	}
+	if _, err := s.Compact(ctx, sess); err != nil {
+		log.Printf("Failed to compact session %s: %v", sess.ID(), err)
+	}
+	return nil
+}
+
+func (s *Service) exceeded(sess session.Session) bool {
+	view := View(all(sess))
+	return (s.cfg.MaxEvents > 0 && len(view) > s.cfg.MaxEvents) ||
+		(s.cfg.MaxTokens > 0 && s.cfg.Tokens(view) > s.cfg.MaxTokens)
+}
+
+// Compact replaces the events of the session's view that come before the
+// KeepRecent latest ones with a summary, whatever the limits. It moves the
+// cut earlier rather than separate a function call from its response, and
+// reports whether it found at least two events to replace.
+func (s *Service) Compact(ctx context.Context, sess session.Session) (bool, error) {
+	inner := unwrap(sess)
+	view := View(all(inner))
+	events := view[:compactible(view, len(view)-s.cfg.KeepRecent)]
+	if len(events) < 2 {
+		return false, nil
+	}
+
+	text, err := s.cfg.Summarizer.Summarize(ctx, events)
+	if err != nil {
+		return false, fmt.Errorf("failed to summarize %d events: %w", len(events), err)
+	}
+	r := Range{
+		StartEventID: bounds(events[0]).StartEventID,
+		EndEventID:   bounds(events[len(events)-1]).EndEventID,
+		StateDelta:   map[string]any{},
+	}
+	for _, e := range events {
+		maps.Copy(r.StateDelta, e.Actions.StateDelta)
+	}
+	meta, err := toMap(r)
+	if err != nil {
+		return false, err
+	}
+
+	summary := session.NewEvent(events[len(events)-1].InvocationID)
+	summary.Author = "user"
+	summary.Content = genai.NewContentFromText(SummaryPrefix+text, genai.RoleUser)
+	summary.CustomMetadata = map[string]any{MetadataKey: meta}
+	if err := s.Service.AppendEvent(ctx, inner, summary); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// compactible returns the largest n <= end such that every function call in
+// view[:n] has its response in view[:n] too.
+func compactible(view []*session.Event, end int) int {
+	n := 0
+	open := map[string]bool{}
+	for i, e := range view[:max(end, 0)] {
+		if e.Content != nil {
+			for _, p := range e.Content.Parts {
+				if p.FunctionCall != nil && p.FunctionCall.ID != "" {
+					open[p.FunctionCall.ID] = true
+				}
+				if p.FunctionResponse != nil {
+					delete(open, p.FunctionResponse.ID)
+				}
+			}
+		}
+		if len(open) == 0 {
+			n = i + 1
+		}
+	}
+	return n
+}
+
+// View returns the compacted view of stored events: every summary event
+// takes the place of the events it replaces, with their combined state
+// delta. When summaries overlap, the latest wins; it covers the earlier
+// ones, as it was made from a view that already had them.
+func View(events []*session.Event) []*session.Event {
+	index := map[string]int{}
+	for i, e := range events {
+		if _, ok := index[e.ID]; !ok {
+			index[e.ID] = i
+		}
+	}
+
+	type span struct {
+		start, end, summary int
+		r                   *Range
+	}
+	isSummary := map[int]bool{}
+	spans := map[int]span{} // by start
+	var taken []span
+	for i := len(events) - 1; i >= 0; i-- {
+		r, ok := RangeOf(events[i])
+		if !ok {
+			continue
+		}
+		isSummary[i] = true
+		start, ok1 := index[r.StartEventID]
+		end, ok2 := index[r.EndEventID]
+		if !ok1 || !ok2 || start > end || end >= i {
+			continue
+		}
+		sp := span{start, end, i, r}
+		overlaps := false
+		for _, t := range taken {
+			if sp.start <= t.end && t.start <= sp.end {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			taken = append(taken, sp)
+			spans[start] = sp
+		}
+	}
+	if len(isSummary) == 0 {
+		return events
+	}
+
+	var view []*session.Event
+	for i := 0; i < len(events); i++ {
+		if sp, ok := spans[i]; ok {
+			e := *events[sp.summary]
+			e.Timestamp = events[sp.end].Timestamp
+			e.Actions.StateDelta = sp.r.StateDelta
+			view = append(view, &e)
+			i = sp.end
+			continue
+		}
+		if !isSummary[i] {
+			view = append(view, events[i])
+		}
+	}
+	return view
+}
+
+// RangeOf returns the Range of a summary event.
+func RangeOf(e *session.Event) (*Range, bool) {
+	v, ok := e.CustomMetadata[MetadataKey]
+	if !ok {
+		return nil, false
+	}
+	// Services that store events as JSON give the metadata back as a
+	// map[string]any, so go through JSON in every case.
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	var r Range
+	if err := json.Unmarshal(data, &r); err != nil || r.StartEventID == "" || r.EndEventID == "" {
+		return nil, false
+	}
+	return &r, true
+}
+
+// bounds returns the stored events an event of a view stands for.
+func bounds(e *session.Event) *Range {
+	if r, ok := RangeOf(e); ok {
+		return r
+	}
+	return &Range{StartEventID: e.ID, EndEventID: e.ID}
+}
+
+func toMap(r Range) (map[string]any, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode compaction range: %w", err)
+	}
+	var m map[string]any
+	return m, json.Unmarshal(data, &m)
+}
+
+// EstimateTokens estimates the tokens events take at four bytes of text or
+// JSON per token.
+func EstimateTokens(events []*session.Event) int {
+	n := 0
+	for _, e := range events {
+		if e.Content == nil {
+			continue
+		}
+		for _, p := range e.Content.Parts {
+			n += len(p.Text)
+			if p.FunctionCall != nil {
+				data, _ := json.Marshal(p.FunctionCall.Args)
+				n += len(p.FunctionCall.Name) + len(data)
+			}
+			if p.FunctionResponse != nil {
+				data, _ := json.Marshal(p.FunctionResponse.Response)
+				n += len(p.FunctionResponse.Name) + len(data)
+			}
+		}
+	}
+	return n / 4
+}
+
+// viewSession shows the compacted view of a stored session. The view is
+// recomputed from the stored events on every call, so it follows appends.
+type viewSession struct {
+	session.Session
+	filter *session.GetRequest // from Get, or nil
+}
+
+func (s *viewSession) Events() session.Events {
+	view := View(all(s.Session))
+	if s.filter != nil {
+		view = localsession.FilterEvents(view, s.filter)
+	}
+	return localsession.Events(view)
+}
+
+func unwrap(sess session.Session) session.Session {
+	if v, ok := sess.(*viewSession); ok {
+		return v.Session
+	}
+	return sess
+}
+
+func all(sess session.Session) []*session.Event {
+	events := make([]*session.Event, 0, sess.Events().Len())
+	for e := range sess.Events().All() {
+		events = append(events, e)
+	}
+	return events
+}
+
+var _ session.Service = (*Service)(nil)
@@ -0,0 +1,290 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compaction
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/fakellm"
+	"github.com/google/adk-docs/examples/go/internal/filesession"
+)
+
+// countingSummarizer summarizes events as the list of their labels.
+var countingSummarizer = SummarizerFunc(func(ctx context.Context, events []*session.Event) (string, error) {
+	var labels []string
+	for _, e := range events {
+		labels = append(labels, label(e))
+	}
+	return strings.Join(labels, ","), nil
+})
+
+// label returns the ID of an event, or "summary(<text>)" for a summary.
+func label(e *session.Event) string {
+	if _, ok := RangeOf(e); ok {
+		return "summary(" + strings.TrimPrefix(e.Content.Parts[0].Text, SummaryPrefix) + ")"
+	}
+	return e.ID
+}
+
+func newSession(t *testing.T, svc session.Service) session.Session {
+	t.Helper()
+	resp, err := svc.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Session
+}
+
+func appendText(t *testing.T, svc session.Service, sess session.Session, id, author string, delta map[string]any) {
+	t.Helper()
+	e := session.NewEvent("inv")
+	e.ID = id
+	e.Author = author
+	role := genai.RoleModel
+	if author == "user" {
+		role = genai.RoleUser
+	}
+	e.Content = genai.NewContentFromText("text of "+id, genai.Role(role))
+	maps.Copy(e.Actions.StateDelta, delta)
+	if err := svc.AppendEvent(t.Context(), sess, e); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func appendParts(t *testing.T, svc session.Service, sess session.Session, id string, parts ...*genai.Part) {
+	t.Helper()
+	e := session.NewEvent("inv")
+	e.ID = id
+	e.Author = "agent"
+	e.Content = &genai.Content{Role: genai.RoleModel, Parts: parts}
+	if err := svc.AppendEvent(t.Context(), sess, e); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// viewIDs returns the labels of the view of the stored session.
+func viewIDs(t *testing.T, svc session.Service) []string {
+	t.Helper()
+	resp, err := svc.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for e := range resp.Session.Events().All() {
+		ids = append(ids, label(e))
+	}
+	return ids
+}
+
+func TestAutomaticCompaction(t *testing.T) {
+	svc := Wrap(session.InMemoryService(), Config{Summarizer: countingSummarizer, MaxEvents: 4, KeepRecent: 2})
+	sess := newSession(t, svc)
+	appendText(t, svc, sess, "u1", "user", nil)
+	appendText(t, svc, sess, "a1", "agent", map[string]any{"k": "1", "first": "yes"})
+	appendText(t, svc, sess, "u2", "user", nil)
+	appendText(t, svc, sess, "a2", "agent", map[string]any{"k": "2"})
+	if diff := cmp.Diff([]string{"u1", "a1", "u2", "a2"}, viewIDs(t, svc)); diff != "" {
+		t.Fatalf("view below the limit mismatch (-want +got):\n%s", diff)
+	}
+
+	// A user event does not trigger a compaction, an agent's reply does.
+	appendText(t, svc, sess, "u3", "user", nil)
+	if n := len(viewIDs(t, svc)); n != 5 {
+		t.Fatalf("view has %d events after a user event, want 5", n)
+	}
+	appendText(t, svc, sess, "a3", "agent", map[string]any{"k": "3"})
+	want := []string{"summary(u1,a1,u2,a2)", "u3", "a3"}
+	if diff := cmp.Diff(want, viewIDs(t, svc)); diff != "" {
+		t.Errorf("compacted view mismatch (-want +got):\n%s", diff)
+	}
+	// The session passed to AppendEvent follows the compaction too.
+	if n := sess.Events().Len(); n != 3 {
+		t.Errorf("appended-to session shows %d events, want 3", n)
+	}
+
+	// The next compaction replaces the previous summary as well.
+	appendText(t, svc, sess, "u4", "user", nil)
+	appendText(t, svc, sess, "a4", "agent", map[string]any{"k": "4"})
+	want = []string{"summary(" + want[0] + ",u3,a3)", "u4", "a4"}
+	if diff := cmp.Diff(want, viewIDs(t, svc)); diff != "" {
+		t.Errorf("compacted view mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestViewPreservesState(t *testing.T) {
+	svc := Wrap(session.InMemoryService(), Config{Summarizer: countingSummarizer, KeepRecent: 1})
+	sess := newSession(t, svc)
+	appendText(t, svc, sess, "a1", "agent", map[string]any{"k": "1", "user:u": "1"})
+	appendText(t, svc, sess, "a2", "agent", map[string]any{"k": "2", "other": "x"})
+	appendText(t, svc, sess, "a3", "agent", map[string]any{"k": "3"})
+	if ok, err := svc.Compact(t.Context(), sess); err != nil || !ok {
+		t.Fatalf("Compact() = %v, %v, want true", ok, err)
+	}
+
+	resp, err := svc.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replaying the view gives the stored state.
+	replayed := map[string]any{}
+	for e := range resp.Session.Events().All() {
+		maps.Copy(replayed, e.Actions.StateDelta)
+	}
+	stored := maps.Collect(resp.Session.State().All())
+	if diff := cmp.Diff(stored, replayed); diff != "" {
+		t.Errorf("replayed view state mismatch (-stored +replayed):\n%s", diff)
+	}
+	if got := resp.Session.Events().At(0).Actions.StateDelta; !maps.Equal(got, map[string]any{"k": "2", "user:u": "1", "other": "x"}) {
+		t.Errorf("summary state delta = %v", got)
+	}
+}
+
+func TestFunctionCallPairing(t *testing.T) {
+	svc := Wrap(session.InMemoryService(), Config{Summarizer: countingSummarizer, KeepRecent: 2})
+	sess := newSession(t, svc)
+	call := &genai.FunctionCall{ID: "c1", Name: "lookup", Args: map[string]any{"q": "x"}}
+	resp := &genai.FunctionResponse{ID: "c1", Name: "lookup", Response: map[string]any{"r": "y"}}
+	appendText(t, svc, sess, "u1", "user", nil)
+	appendText(t, svc, sess, "a1", "agent", nil)
+	appendParts(t, svc, sess, "call", &genai.Part{FunctionCall: call})
+	appendParts(t, svc, sess, "resp", &genai.Part{FunctionResponse: resp})
+	appendText(t, svc, sess, "a2", "agent", nil)
+
+	// Keeping two events would cut between the call and its response, so
+	// the call stays too.
+	if _, err := svc.Compact(t.Context(), sess); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"summary(u1,a1)", "call", "resp", "a2"}
+	if diff := cmp.Diff(want, viewIDs(t, svc)); diff != "" {
+		t.Errorf("view mismatch (-want +got):\n%s", diff)
+	}
+
+	// A call still waiting for its response is never compacted.
+	appendParts(t, svc, sess, "pending", &genai.Part{FunctionCall: &genai.FunctionCall{ID: "c2", Name: "approve"}})
+	appendText(t, svc, sess, "u2", "user", nil)
+	appendText(t, svc, sess, "a3", "agent", nil)
+	if _, err := svc.Compact(t.Context(), sess); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"summary(" + want[0] + ",call,resp,a2)", "pending", "u2", "a3"}
+	if diff := cmp.Diff(want, viewIDs(t, svc)); diff != "" {
+		t.Errorf("view mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTokenLimit(t *testing.T) {
+	svc := Wrap(session.InMemoryService(), Config{Summarizer: countingSummarizer, MaxTokens: 8})
+	sess := newSession(t, svc)
+	for i := range 4 {
+		// "text of aN" is 10 bytes, so the fourth event takes the session
+		// to an estimated 10 tokens.
+		appendText(t, svc, sess, fmt.Sprintf("a%d", i), "agent", nil)
+	}
+	want := []string{"summary(a0,a1)", "a2", "a3"}
+	if diff := cmp.Diff(want, viewIDs(t, svc)); diff != "" {
+		t.Errorf("view mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStoredAsJSON(t *testing.T) {
+	// The range survives a service that stores events as JSON, and a
+	// restart.
+	dir := t.TempDir()
+	store, err := filesession.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := Wrap(store, Config{Summarizer: countingSummarizer, MaxEvents: 3, KeepRecent: 1})
+	sess := newSession(t, svc)
+	for _, id := range []string{"a1", "a2", "a3", "a4"} {
+		appendText(t, svc, sess, id, "agent", map[string]any{"k": id})
+	}
+
+	reopened, err := filesession.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"summary(a1,a2,a3)", "a4"}
+	if diff := cmp.Diff(want, viewIDs(t, Wrap(reopened, Config{Summarizer: countingSummarizer}))); diff != "" {
+		t.Errorf("view mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestAgentSummarizer(t *testing.T) {
+	llm := fakellm.New("fake", fakellm.Text("The user asked about Paris; it is sunny."), fakellm.Text("Second summary."))
+	summarizer, err := llmagent.New(llmagent.Config{
+		Name:        "summarizer",
+		Model:       llm,
+		Instruction: "Summarize the conversation you are given.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assistantLLM := fakellm.New("fake", fakellm.Text("It is sunny."), fakellm.Text("You're welcome."), fakellm.Text("Bye."))
+	assistant, err := llmagent.New(llmagent.Config{Name: "assistant", Model: assistantLLM})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := Wrap(session.InMemoryService(), Config{Summarizer: AgentSummarizer(summarizer), MaxEvents: 3})
+	r, err := runner.New(runner.Config{AppName: "app", Agent: assistant, SessionService: svc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	newSession(t, svc)
+	for _, prompt := range []string{"Weather in Paris?", "Thanks!", "Goodbye."} {
+		for _, err := range r.Run(t.Context(), "user", "s1", genai.NewContentFromText(prompt, genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				t.Fatalf("Run(%q) failed: %v", prompt, err)
+			}
+		}
+	}
+
+	// The summarizer got the transcript of the first turn after the second
+	// one, and again after the third.
+	reqs := llm.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("summarizer was called %d times, want 2", len(reqs))
+	}
+	got := reqs[0].Contents[len(reqs[0].Contents)-1].Parts[0].Text
+	want := "[user]: Weather in Paris?\n[assistant]: It is sunny.\n"
+	if got != want {
+		t.Errorf("summarizer prompt = %q, want %q", got, want)
+	}
+
+	// The assistant's last request carried the summary instead of the
+	// first turn.
+	reqs = assistantLLM.Requests()
+	var texts []string
+	for _, c := range reqs[len(reqs)-1].Contents {
+		texts = append(texts, c.Parts[0].Text)
+	}
+	wantTexts := []string{SummaryPrefix + "The user asked about Paris; it is sunny.", "Thanks!", "You're welcome.", "Goodbye."}
+	if diff := cmp.Diff(wantTexts, texts); diff != "" {
+		t.Errorf("last assistant request mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compaction
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// Summarizer writes the summary that replaces events.
+type Summarizer interface {
+	Summarize(ctx context.Context, events []*session.Event) (string, error)
+}
+
+// SummarizerFunc adapts a function to a Summarizer.
+type SummarizerFunc func(ctx context.Context, events []*session.Event) (string, error)
+
+func (f SummarizerFunc) Summarize(ctx context.Context, events []*session.Event) (string, error) {
+	return f(ctx, events)
+}
+
+// AgentSummarizer returns a Summarizer that sends the Transcript of the
+// events to a, typically an llmagent instructed to summarize, in a
+// throwaway session, and uses the text of its last reply.
+func AgentSummarizer(a agent.Agent) Summarizer {
+	return SummarizerFunc(func(ctx context.Context, events []*session.Event) (string, error) {
+		const appName, userID = "compaction", "compaction"
+		svc := session.InMemoryService()
+		r, err := runner.New(runner.Config{AppName: appName, Agent: a, SessionService: svc})
+		if err != nil {
+			return "", err
+		}
+		resp, err := svc.Create(ctx, &session.CreateRequest{AppName: appName, UserID: userID})
+		if err != nil {
+			return "", err
+		}
+
+		var summary string
+		msg := genai.NewContentFromText(Transcript(events), genai.RoleUser)
+		for e, err := range r.Run(ctx, userID, resp.Session.ID(), msg, agent.RunConfig{}) {
+			if err != nil {
+				return "", err
+			}
+			if e.Author != a.Name() || e.Partial || e.Content == nil {
+				continue
+			}
+			var b strings.Builder
+			for _, p := range e.Content.Parts {
+				b.WriteString(p.Text)
+			}
+			if text := strings.TrimSpace(b.String()); text != "" {
+				summary = text
+			}
+		}
+		if summary == "" {
+			return "", fmt.Errorf("agent %s replied without text", a.Name())
+		}
+		return summary, nil
+	})
+}
+
+// Transcript renders events as text for a summarizer, one line per part:
+//
+//	[user]: What is the weather in Paris?
+//	[weather_agent] called get_weather({"city":"Paris"})
+//	[weather_agent] got get_weather: {"report":"sunny"}
+//	[weather_agent]: It is sunny in Paris.
+func Transcript(events []*session.Event) string {
+	var b strings.Builder
+	for _, e := range events {
+		if e.Content == nil {
+			continue
+		}
+		for _, p := range e.Content.Parts {
+			switch {
+			case p.Text != "":
+				fmt.Fprintf(&b, "[%s]: %s\n", e.Author, strings.TrimSpace(p.Text))
+			case p.FunctionCall != nil:
+				args, _ := json.Marshal(p.FunctionCall.Args)
+				fmt.Fprintf(&b, "[%s] called %s(%s)\n", e.Author, p.FunctionCall.Name, args)
+			case p.FunctionResponse != nil:
+				resp, _ := json.Marshal(p.FunctionResponse.Response)
+				fmt.Fprintf(&b, "[%s] got %s: %s\n", e.Author, p.FunctionResponse.Name, resp)
+			}
+		}
+	}
+	return b.String()
+}
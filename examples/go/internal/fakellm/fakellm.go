@@ -0,0 +1,200 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakellm provides a model.LLM that plays back a fixed script of
+// responses, so the Go snippets can run without network access or an API key.
+//
+// The examples build their models with gemini.NewModel, as the docs show.
+// Handler serves a model as the Gemini API, so that they talk to a script
+// once $GOOGLE_GEMINI_BASE_URL points at it: golden.UseScript does so in
+// the tests, and cmd/fake-gemini from the command line.
+package fakellm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+	"sync"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// ErrScriptExhausted is returned when the model is called more times than
+// the script has turns.
+var ErrScriptExhausted = errors.New("fakellm: script exhausted")
+
+// Turn is the scripted reply to a single GenerateContent call.
+type Turn struct {
+	// Responses are yielded in order. Partial responses are only yielded
+	// when the caller asked for a streamed response.
+	Responses []*model.LLMResponse
+	// Err, if set, is yielded after Responses.
+	Err error
+}
+
+// Text returns a turn that replies with a single text part.
+func Text(text string) Turn {
+	return Turn{Responses: []*model.LLMResponse{final(genai.NewPartFromText(text))}}
+}
+
+// Chunks returns a turn that streams text as partial responses, followed by
+// the aggregated final response, the way the Gemini model does in SSE mode.
+func Chunks(chunks ...string) Turn {
+	var t Turn
+	for _, c := range chunks {
+		t.Responses = append(t.Responses, &model.LLMResponse{
+			Content: genai.NewContentFromText(c, genai.RoleModel),
+			Partial: true,
+		})
+	}
+	t.Responses = append(t.Responses, final(genai.NewPartFromText(strings.Join(chunks, ""))))
+	return t
+}
+
+// Call returns a turn that asks for one or more function calls. Calls
+// without an ID are given a deterministic one when they are played back.
+func Call(calls ...*genai.FunctionCall) Turn {
+	parts := make([]*genai.Part, 0, len(calls))
+	for _, fc := range calls {
+		parts = append(parts, &genai.Part{FunctionCall: fc})
+	}
+	return Turn{Responses: []*model.LLMResponse{final(parts...)}}
+}
+
+// Fail returns a turn that fails with err.
+func Fail(err error) Turn {
+	return Turn{Err: err}
+}
+
+func final(parts ...*genai.Part) *model.LLMResponse {
+	return &model.LLMResponse{
+		Content:      &genai.Content{Role: string(genai.RoleModel), Parts: parts},
+		TurnComplete: true,
+		FinishReason: genai.FinishReasonStop,
+	}
+}
+
+// Model is a model.LLM that replies to each call with the next scripted turn.
+// It is safe for concurrent use; concurrent callers consume turns in the
+// order their calls arrive.
+type Model struct {
+	name string
+
+	mu       sync.Mutex
+	turns    []Turn
+	next     int
+	overruns int
+	calls    int
+	requests []*model.LLMRequest
+}
+
+// New returns a model named name that plays back turns.
+func New(name string, turns ...Turn) *Model {
+	return &Model{name: name, turns: turns}
+}
+
+// Name implements model.LLM.
+func (m *Model) Name() string {
+	return m.name
+}
+
+// GenerateContent implements model.LLM.
+func (m *Model) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		turn, err := m.take(req)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for _, resp := range turn.Responses {
+			if resp.Partial && !stream {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(resp, nil) {
+				return
+			}
+		}
+		if turn.Err != nil {
+			yield(nil, turn.Err)
+		}
+	}
+}
+
+func (m *Model) take(req *model.LLMRequest) (Turn, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, req)
+	if m.next >= len(m.turns) {
+		m.overruns++
+		return Turn{}, fmt.Errorf("%w after %d turns", ErrScriptExhausted, len(m.turns))
+	}
+	turn := m.turns[m.next]
+	m.next++
+	// The calls are given their IDs in copies, so the script stays as it was
+	// written.
+	responses := make([]*model.LLMResponse, 0, len(turn.Responses))
+	for _, resp := range turn.Responses {
+		if resp.Content != nil {
+			r := *resp
+			c := *resp.Content
+			c.Parts = make([]*genai.Part, 0, len(resp.Content.Parts))
+			for _, p := range resp.Content.Parts {
+				if p.FunctionCall != nil && p.FunctionCall.ID == "" {
+					m.calls++
+					fc := *p.FunctionCall
+					fc.ID = fmt.Sprintf("fakellm-call-%d", m.calls)
+					cp := *p
+					cp.FunctionCall = &fc
+					p = &cp
+				}
+				c.Parts = append(c.Parts, p)
+			}
+			r.Content = &c
+			resp = &r
+		}
+		responses = append(responses, resp)
+	}
+	turn.Responses = responses
+	return turn, nil
+}
+
+// Requests returns the requests the model has received so far.
+func (m *Model) Requests() []*model.LLMRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*model.LLMRequest(nil), m.requests...)
+}
+
+// Remaining reports how many scripted turns have not been played yet.
+func (m *Model) Remaining() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.turns) - m.next
+}
+
+// Overruns reports how many calls arrived after the script was exhausted.
+func (m *Model) Overruns() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.overruns
+}
+
+var _ model.LLM = (*Model)(nil)
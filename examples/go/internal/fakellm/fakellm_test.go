@@ -0,0 +1,210 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakellm_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/gemini"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/fakellm"
+)
+
+// texts returns the text of each response of m to one call, and whether
+// they were partial.
+func texts(t *testing.T, ctx context.Context, m model.LLM, stream bool) ([]string, error) {
+	t.Helper()
+	var got []string
+	for resp, err := range m.GenerateContent(ctx, &model.LLMRequest{}, stream) {
+		if err != nil {
+			return got, err
+		}
+		text := resp.Content.Parts[0].Text
+		if resp.Partial {
+			text = "partial:" + text
+		}
+		got = append(got, text)
+	}
+	return got, nil
+}
+
+func TestChunks(t *testing.T) {
+	for _, c := range []struct {
+		stream bool
+		want   []string
+	}{
+		{false, []string{"Hello, world."}},
+		{true, []string{"partial:Hello, ", "partial:world.", "Hello, world."}},
+	} {
+		m := fakellm.New("fake", fakellm.Chunks("Hello, ", "world."))
+		got, err := texts(t, t.Context(), m, c.stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(c.want, got); diff != "" {
+			t.Errorf("stream=%v: responses mismatch (-want +got):\n%s", c.stream, diff)
+		}
+	}
+}
+
+func TestCancel(t *testing.T) {
+	m := fakellm.New("fake", fakellm.Text("Hello."))
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	if _, err := texts(t, ctx, m, false); !errors.Is(err, context.Canceled) {
+		t.Errorf("GenerateContent() with a canceled context = %v, want context.Canceled", err)
+	}
+	// The turn is consumed all the same.
+	if n := m.Remaining(); n != 0 {
+		t.Errorf("Remaining() = %d, want 0", n)
+	}
+}
+
+func TestFail(t *testing.T) {
+	quota := errors.New("quota exceeded")
+	m := fakellm.New("fake", fakellm.Fail(quota))
+	if _, err := texts(t, t.Context(), m, false); !errors.Is(err, quota) {
+		t.Errorf("GenerateContent() = %v, want %v", err, quota)
+	}
+	if _, err := texts(t, t.Context(), m, false); !errors.Is(err, fakellm.ErrScriptExhausted) {
+		t.Errorf("GenerateContent() after the script = %v, want ErrScriptExhausted", err)
+	}
+	if n := m.Overruns(); n != 1 {
+		t.Errorf("Overruns() = %d, want 1", n)
+	}
+}
+
+func TestCallIDs(t *testing.T) {
+	a := &genai.FunctionCall{Name: "a"}
+	m := fakellm.New("fake",
+		fakellm.Call(a, &genai.FunctionCall{Name: "b", ID: "mine"}),
+		fakellm.Call(&genai.FunctionCall{Name: "c"}),
+	)
+	var got []string
+	for range 2 {
+		for resp, err := range m.GenerateContent(t.Context(), &model.LLMRequest{}, false) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range resp.Content.Parts {
+				got = append(got, p.FunctionCall.Name+"="+p.FunctionCall.ID)
+			}
+		}
+	}
+	want := []string{"a=fakellm-call-1", "b=mine", "c=fakellm-call-2"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("call IDs mismatch (-want +got):\n%s", diff)
+	}
+	if a.ID != "" {
+		t.Errorf("scripted call ID = %q, want it left empty", a.ID)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, c := range []struct {
+		name, script, wantErr string
+	}{
+		{"shorthands", `{"turns": [{"text": "a"}, {"chunks": ["b"]}, {"functionCalls": [{"name": "c"}]}, {"error": "d"}]}`, ""},
+		{"empty turn", `{"turns": [{}]}`, "turn 0: turn has no text"},
+		{"text and error", `{"turns": [{"text": "a"}, {"text": "b", "error": "c"}]}`, "turn 1: turn sets more than one"},
+		{"chunks and calls", `{"turns": [{"chunks": ["a"], "functionCalls": [{"name": "b"}]}]}`, "turn 0: turn sets more than one"},
+	} {
+		path := filepath.Join(t.TempDir(), "script.json")
+		if err := os.WriteFile(path, []byte(c.script), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		m, err := fakellm.Load(path)
+		if c.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: Load() = %v", c.name, err)
+			} else if n := m.Remaining(); n != 4 {
+				t.Errorf("%s: Remaining() = %d, want 4", c.name, n)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+			t.Errorf("%s: Load() = %v, want an error containing %q", c.name, err, c.wantErr)
+		}
+	}
+}
+
+// TestHandler plays a script through a Gemini model pointed at Handler.
+func TestHandler(t *testing.T) {
+	m := fakellm.New("fake",
+		fakellm.Call(&genai.FunctionCall{Name: "get_weather", Args: map[string]any{"city": "Paris"}}),
+		fakellm.Chunks("It is sunny ", "in Paris."),
+		fakellm.Fail(errors.New("quota exceeded")),
+	)
+	srv := httptest.NewServer(fakellm.Handler(m))
+	defer srv.Close()
+	g, err := gemini.NewModel(t.Context(), "gemini-2.5-flash", &genai.ClientConfig{
+		APIKey:      "fake",
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: srv.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText("Weather in Paris?", genai.RoleUser)},
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("Be brief.", genai.RoleUser),
+			Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{
+				Name:                 "get_weather",
+				ParametersJsonSchema: map[string]any{"type": "object"},
+			}}}},
+		},
+	}
+	var calls []*genai.FunctionCall
+	for resp, err := range g.GenerateContent(t.Context(), req, false) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		calls = append(calls, resp.Content.Parts[0].FunctionCall)
+	}
+	want := []*genai.FunctionCall{{ID: "fakellm-call-1", Name: "get_weather", Args: map[string]any{"city": "Paris"}}}
+	if diff := cmp.Diff(want, calls); diff != "" {
+		t.Errorf("function calls mismatch (-want +got):\n%s", diff)
+	}
+	sent := m.Requests()[0]
+	if got := sent.Config.SystemInstruction.Parts[0].Text; got != "Be brief." {
+		t.Errorf("system instruction = %q, want %q", got, "Be brief.")
+	}
+	if _, ok := sent.Tools["get_weather"]; !ok {
+		t.Errorf("tools = %v, want get_weather", sent.Tools)
+	}
+
+	got, err := texts(t, t.Context(), g, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"partial:It is sunny ", "partial:in Paris.", "It is sunny in Paris."}, got); diff != "" {
+		t.Errorf("streamed responses mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := texts(t, t.Context(), g, false); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("GenerateContent() of a failing turn = %v, want the error of the turn", err)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakellm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Script is the JSON form of a sequence of turns, for example:
+//
+//	{
+//	  "model": "fake-gemini",
+//	  "turns": [
+//	    {"functionCalls": [{"name": "get_weather", "args": {"city": "Paris"}}]},
+//	    {"chunks": ["It is sunny ", "in Paris."]},
+//	    {"error": "quota exceeded"}
+//	  ]
+//	}
+type Script struct {
+	Model string       `json:"model,omitempty"`
+	Turns []ScriptTurn `json:"turns"`
+}
+
+// ScriptTurn is the JSON form of a Turn. Exactly one of the fields must be
+// set.
+type ScriptTurn struct {
+	Text          string                `json:"text,omitempty"`
+	Chunks        []string              `json:"chunks,omitempty"`
+	FunctionCalls []*genai.FunctionCall `json:"functionCalls,omitempty"`
+	// Responses are played back verbatim, for cases the shorthands above
+	// do not cover.
+	Responses []*model.LLMResponse `json:"responses,omitempty"`
+	Error     string               `json:"error,omitempty"`
+}
+
+func (st ScriptTurn) turn() (Turn, error) {
+	set := 0
+	for _, ok := range []bool{st.Text != "", len(st.Chunks) > 0, len(st.FunctionCalls) > 0, len(st.Responses) > 0, st.Error != ""} {
+		if ok {
+			set++
+		}
+	}
+	if set > 1 {
+		return Turn{}, errors.New("turn sets more than one of text, chunks, functionCalls, responses and error")
+	}
+	switch {
+	case st.Text != "":
+		return Text(st.Text), nil
+	case len(st.Chunks) > 0:
+		return Chunks(st.Chunks...), nil
+	case len(st.FunctionCalls) > 0:
+		return Call(st.FunctionCalls...), nil
+	case len(st.Responses) > 0:
+		return Turn{Responses: st.Responses}, nil
+	case st.Error != "":
+		return Fail(errors.New(st.Error)), nil
+	}
+	return Turn{}, errors.New("turn has no text, chunks, functionCalls, responses or error")
+}
+
+// Load reads a JSON script from path and returns a model that plays it back.
+func Load(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+	var s Script
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse script %s: %w", path, err)
+	}
+	turns := make([]Turn, 0, len(s.Turns))
+	for i, st := range s.Turns {
+		t, err := st.turn()
+		if err != nil {
+			return nil, fmt.Errorf("script %s: turn %d: %w", path, i, err)
+		}
+		turns = append(turns, t)
+	}
+	name := s.Model
+	if name == "" {
+		name = "fakellm"
+	}
+	return New(name, turns...), nil
+}
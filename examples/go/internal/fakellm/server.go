@@ -0,0 +1,159 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakellm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// Handler returns an HTTP handler that answers the generateContent and
+// streamGenerateContent methods of the Gemini API with m. A program that
+// builds its models with gemini.NewModel talks to m once
+// $GOOGLE_GEMINI_BASE_URL points at the handler, without any change to its
+// code.
+func Handler(m model.LLM) http.Handler {
+	return &handler{llm: m}
+}
+
+type handler struct {
+	llm model.LLM
+}
+
+// generateRequest is the body of a generateContent call: the config of the
+// SDK, with the fields the API takes at the top level moved there.
+type generateRequest struct {
+	Contents          []*genai.Content             `json:"contents,omitempty"`
+	SystemInstruction *genai.Content               `json:"systemInstruction,omitempty"`
+	Tools             []*genai.Tool                `json:"tools,omitempty"`
+	ToolConfig        *genai.ToolConfig            `json:"toolConfig,omitempty"`
+	SafetySettings    []*genai.SafetySetting       `json:"safetySettings,omitempty"`
+	CachedContent     string                       `json:"cachedContent,omitempty"`
+	GenerationConfig  *genai.GenerateContentConfig `json:"generationConfig,omitempty"`
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The path is .../models/<model>:<method>.
+	_, method, _ := strings.Cut(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], ":")
+	stream := method == "streamGenerateContent"
+	if r.Method != http.MethodPost || (method != "generateContent" && !stream) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("fakellm: unsupported call %s %s", r.Method, r.URL.Path))
+		return
+	}
+	var body generateRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("fakellm: invalid request: %v", err))
+		return
+	}
+	req := &model.LLMRequest{Contents: body.Contents, Config: body.GenerationConfig}
+	if req.Config == nil {
+		req.Config = &genai.GenerateContentConfig{}
+	}
+	req.Config.SystemInstruction = body.SystemInstruction
+	req.Config.Tools = body.Tools
+	req.Config.ToolConfig = body.ToolConfig
+	req.Config.SafetySettings = body.SafetySettings
+	req.Config.CachedContent = body.CachedContent
+	for _, t := range body.Tools {
+		for _, decl := range t.FunctionDeclarations {
+			if req.Tools == nil {
+				req.Tools = map[string]any{}
+			}
+			req.Tools[decl.Name] = decl
+		}
+	}
+
+	if !stream {
+		var last *model.LLMResponse
+		for resp, err := range h.llm.GenerateContent(r.Context(), req, false) {
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			last = resp
+		}
+		if last == nil {
+			writeError(w, http.StatusInternalServerError, "fakellm: the model returned no response")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response(last))
+		return
+	}
+
+	// The SDK aggregates the text of the chunks itself, so the final
+	// response of a streamed turn is only sent if no chunk preceded it.
+	w.Header().Set("Content-Type", "text/event-stream")
+	partial := false
+	for resp, err := range h.llm.GenerateContent(r.Context(), req, true) {
+		if err != nil {
+			// Errors in the middle of a stream are sent as an error chunk.
+			data, _ := json.Marshal(map[string]any{"error": apiError(http.StatusBadRequest, err.Error())})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			return
+		}
+		if resp.Partial {
+			partial = true
+		} else if partial {
+			continue
+		}
+		data, err := json.Marshal(response(resp))
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+}
+
+// response converts resp to the GenerateContentResponse of the API.
+func response(resp *model.LLMResponse) *genai.GenerateContentResponse {
+	c := &genai.Candidate{
+		Content:           resp.Content,
+		FinishReason:      resp.FinishReason,
+		GroundingMetadata: resp.GroundingMetadata,
+		CitationMetadata:  resp.CitationMetadata,
+		AvgLogprobs:       resp.AvgLogprobs,
+		LogprobsResult:    resp.LogprobsResult,
+	}
+	if resp.ErrorCode != "" {
+		c.FinishReason = genai.FinishReason(resp.ErrorCode)
+		c.FinishMessage = resp.ErrorMessage
+	}
+	if resp.Partial {
+		c.FinishReason = ""
+	}
+	return &genai.GenerateContentResponse{
+		Candidates:    []*genai.Candidate{c},
+		UsageMetadata: resp.UsageMetadata,
+	}
+}
+
+func apiError(code int, message string) map[string]any {
+	return map[string]any{"code": code, "message": message, "status": http.StatusText(code)}
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]any{"error": apiError(code, message)})
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakemcp provides a Model Context Protocol server with a few fixed
+// tools, so that MCP clients can be tested without a real server.
+//
+// New returns the server, to serve in memory or over HTTP. A test binary can
+// also run it as a subprocess over stdio, by calling ServeChild from its
+// TestMain and starting the command of Command:
+//
+//	func TestMain(m *testing.M) {
+//		fakemcp.ServeChild()
+//		os.Exit(m.Run())
+//	}
+package fakemcp
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// EchoArgs are the arguments of the echo tool.
+type EchoArgs struct {
+	Text string `json:"text" jsonschema:"the text to echo"`
+}
+
+// AddArgs are the arguments of the add tool.
+type AddArgs struct {
+	A float64 `json:"a"`
+	B float64 `json:"b"`
+}
+
+// AddResult is the structured result of the add tool.
+type AddResult struct {
+	Sum float64 `json:"sum"`
+}
+
+// New returns a server with three tools:
+//
+//   - echo returns the text it is given;
+//   - add returns the sum of two numbers as structured content;
+//   - fail always fails with "it failed".
+func New() *mcp.Server {
+	s := mcp.NewServer(&mcp.Implementation{Name: "fakemcp", Version: "v1.0.0"}, nil)
+	mcp.AddTool(s, &mcp.Tool{Name: "echo", Description: "Echoes the text."},
+		func(ctx context.Context, req *mcp.CallToolRequest, args EchoArgs) (*mcp.CallToolResult, any, error) {
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: args.Text}}}, nil, nil
+		})
+	mcp.AddTool(s, &mcp.Tool{Name: "add", Description: "Adds two numbers."},
+		func(ctx context.Context, req *mcp.CallToolRequest, args AddArgs) (*mcp.CallToolResult, AddResult, error) {
+			return nil, AddResult{Sum: args.A + args.B}, nil
+		})
+	mcp.AddTool(s, &mcp.Tool{Name: "fail", Description: "Always fails."},
+		func(ctx context.Context, req *mcp.CallToolRequest, args struct{}) (*mcp.CallToolResult, any, error) {
+			return nil, nil, errors.New("it failed")
+		})
+	return s
+}
+
+// childEnv is set in the environment of the subprocesses of Command.
+const childEnv = "FAKEMCP_CHILD"
+
+// Command returns the command line and the environment that run the server
+// of New over stdio, in a new process of the current test binary.
+func Command() (args, env []string) {
+	return []string{os.Args[0]}, append(os.Environ(), childEnv+"=1")
+}
+
+// ServeChild serves the server of New over stdio and exits if the process
+// was started by Command, and returns otherwise.
+func ServeChild() {
+	if os.Getenv(childEnv) == "" {
+		return
+	}
+	if err := New().Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+		log.Fatalf("fakemcp: %v", err)
+	}
+	os.Exit(0)
+}
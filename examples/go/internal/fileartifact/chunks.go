@@ -0,0 +1,166 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fileartifact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// writeChunks stores the content r yields and sets the size, SHA-256 and
+// chunks of meta.
+func (s *Service) writeChunks(ctx context.Context, r io.Reader, meta *Metadata) error {
+	sum := sha256.New()
+	buf := make([]byte, s.cfg.ChunkSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			meta.Size += int64(n)
+			if s.cfg.MaxSize > 0 && meta.Size > s.cfg.MaxSize {
+				return fmt.Errorf("%w: over %d bytes", ErrTooLarge, s.cfg.MaxSize)
+			}
+			sum.Write(buf[:n])
+			id, err := s.putChunk(buf[:n])
+			if err != nil {
+				return err
+			}
+			meta.Chunks = append(meta.Chunks, id)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	meta.SHA256 = hex.EncodeToString(sum.Sum(nil))
+	return nil
+}
+
+// putChunk stores data under its SHA-256, unless it is stored already, and
+// returns the SHA-256.
+func (s *Service) putChunk(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	id := hex.EncodeToString(sum[:])
+	path := s.chunkPath(id)
+	now := s.now()
+	// Touching a chunk that is reused keeps Prune from removing it before
+	// the metadata that refers to it is written.
+	if err := os.Chtimes(path, now, now); err == nil {
+		return id, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	return id, writeFile(path, data)
+}
+
+// readChunks writes the content of meta to w, checking every chunk against
+// its SHA-256.
+func (s *Service) readChunks(ctx context.Context, meta *Metadata, w io.Writer) error {
+	for _, id := range meta.Chunks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(s.chunkPath(id))
+		if err != nil {
+			return err
+		}
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != id {
+			return fmt.Errorf("chunk %s is corrupt", id)
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) chunkPath(id string) string {
+	return filepath.Join(s.root, "chunks", id[:2], id)
+}
+
+// Prune removes the chunks no version refers to any more, and returns how
+// many it removed. It leaves the chunks written or reused in the last
+// minGrace, which may belong to saves still in progress.
+func (s *Service) Prune(ctx context.Context, minGrace time.Duration) (int, error) {
+	used := map[string]bool{}
+	err := filepath.WalkDir(filepath.Join(s.root, "apps"), func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".json") || strings.HasPrefix(d.Name(), ".") {
+			return ctx.Err()
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil // deleted meanwhile
+		}
+		if err != nil {
+			return err
+		}
+		var meta Metadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+		for _, id := range meta.Chunks {
+			used[id] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	cutoff := s.now().Add(-minGrace)
+	err = filepath.WalkDir(filepath.Join(s.root, "chunks"), func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if used[d.Name()] || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
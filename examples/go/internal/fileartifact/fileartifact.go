@@ -0,0 +1,489 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fileartifact provides an artifact.Service that keeps every version
+// of every artifact on disk, so artifacts survive restarts and can be
+// inspected while debugging:
+//
+//	<root>/apps/<app>/users/<user>/sessions/<id>/artifacts/<name>/
+//		1.json   the metadata of version 1: MIME type, creation time,
+//		         SHA-256 and the chunks of its content
+//		2.json, ...
+//	<root>/apps/<app>/users/<user>/artifacts/<name>/
+//		         artifacts named "user:...", shared by all sessions
+//	<root>/chunks/<ab>/<sha256>
+//		         a chunk of content, named after its SHA-256
+//
+// Path elements are escaped with url.PathEscape.
+//
+// Content is split into chunks of Config.ChunkSize bytes, which are stored
+// once however many versions share them: a version that does not change
+// the content adds nothing but its metadata. SaveStream and LoadStream move
+// content through an io.Reader and an io.Writer a chunk at a time, so large
+// artifacts never have to fit in memory. Deleting a version leaves its
+// chunks for Prune to remove.
+//
+// A version exists once its metadata file does, which is written last. A
+// save picks its version by creating an empty file named after the next
+// version exclusively, so concurrent saves, from one process or several,
+// each get a version of their own. A save that a crash cut short leaves
+// such a file without metadata, which is ignored, and whose version is not
+// reused.
+package fileartifact
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/artifactlist"
+)
+
+// userPrefix marks the names of artifacts scoped to the user rather than
+// the session.
+const userPrefix = "user:"
+
+// DefaultChunkSize is the default Config.ChunkSize.
+const DefaultChunkSize = 1 << 20
+
+// ErrTooLarge is returned when saving an artifact over Config.MaxSize.
+var ErrTooLarge = errors.New("artifact too large")
+
+// Config configures a Service.
+type Config struct {
+	// ChunkSize is the size of the chunks content is stored in. It
+	// defaults to DefaultChunkSize.
+	ChunkSize int
+	// MaxSize, if positive, is the largest artifact a save accepts.
+	MaxSize int64
+}
+
+// Metadata is the content of the metadata file of a version.
+type Metadata struct {
+	Version int64 `json:"version"`
+	// MIMEType is the MIME type of the artifact; it is "text/plain" for a
+	// text part.
+	MIMEType    string    `json:"mimeType"`
+	DisplayName string    `json:"displayName,omitempty"`
+	CreateTime  time.Time `json:"createTime"`
+	Size        int64     `json:"size"`
+	// Text is set if the artifact was saved as a text part rather than as
+	// inline data.
+	Text bool `json:"text,omitempty"`
+	// SHA256 is the hex SHA-256 of the content.
+	SHA256 string `json:"sha256"`
+	// Chunks are the hex SHA-256 of the chunks of the content, in order.
+	Chunks []string `json:"chunks"`
+}
+
+// Service is an artifact.Service that stores artifacts under a root
+// directory.
+type Service struct {
+	root string
+	cfg  Config
+	now  func() time.Time
+}
+
+// Open returns a Service that stores artifacts under root, creating the
+// directory if needed.
+func Open(root string, cfg Config) (*Service, error) {
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = DefaultChunkSize
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &Service{root: root, cfg: cfg, now: time.Now}, nil
+}
+
+// Save stores req.Part as a new version, or as req.Version if set,
+// replacing that version.
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	stream := &SaveStreamRequest{
+		AppName:   req.AppName,
+		UserID:    req.UserID,
+		SessionID: req.SessionID,
+		FileName:  req.FileName,
+		Version:   req.Version,
+	}
+	var data []byte
+	if req.Part.InlineData != nil {
+		data = req.Part.InlineData.Data
+		stream.MIMEType = req.Part.InlineData.MIMEType
+		stream.DisplayName = req.Part.InlineData.DisplayName
+	} else {
+		data = []byte(req.Part.Text)
+		stream.MIMEType = "text/plain"
+		stream.text = true
+	}
+	return s.SaveStream(ctx, stream, bytes.NewReader(data))
+}
+
+// SaveStreamRequest is a request to save content read from a stream.
+type SaveStreamRequest struct {
+	AppName, UserID, SessionID, FileName string
+	MIMEType                             string
+	DisplayName                          string
+	// If set, the artifact is saved as this version, replacing it.
+	Version int64
+
+	text bool
+}
+
+// SaveStream stores what r yields as a new version of the artifact, or as
+// req.Version if set. It fails with ErrTooLarge, saving nothing, once r
+// yields more than Config.MaxSize bytes.
+func (s *Service) SaveStream(ctx context.Context, req *SaveStreamRequest, r io.Reader) (*artifact.SaveResponse, error) {
+	var missing []string
+	for _, f := range []struct{ name, value string }{
+		{"AppName", req.AppName}, {"UserID", req.UserID}, {"SessionID", req.SessionID}, {"FileName", req.FileName}, {"MIMEType", req.MIMEType},
+	} {
+		if f.value == "" {
+			missing = append(missing, f.name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("invalid save request: missing required fields: %s", strings.Join(missing, ", "))
+	}
+
+	meta := &Metadata{
+		Version:     req.Version,
+		MIMEType:    req.MIMEType,
+		DisplayName: req.DisplayName,
+		CreateTime:  s.now(),
+		Text:        req.text,
+		Chunks:      []string{},
+	}
+	if err := s.writeChunks(ctx, r, meta); err != nil {
+		return nil, fmt.Errorf("failed to save artifact %s: %w", req.FileName, err)
+	}
+
+	dir := s.artifactDir(req.AppName, req.UserID, req.SessionID, req.FileName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if meta.Version <= 0 {
+		version, err := reserve(dir)
+		if err != nil {
+			return nil, err
+		}
+		meta.Version = version
+	}
+	// The metadata file makes the version exist, so it is written last.
+	encoded, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(dir, metadataFile(meta.Version)), append(encoded, '\n')); err != nil {
+		return nil, err
+	}
+	return &artifact.SaveResponse{Version: meta.Version}, nil
+}
+
+// reserve creates the empty file of the version after the highest one in
+// dir, complete or not, and returns that version.
+func reserve(dir string) (int64, error) {
+	version := int64(1)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range entries {
+		if v, err := strconv.ParseInt(strings.TrimSuffix(e.Name(), ".json"), 10, 64); err == nil && v >= version {
+			version = v + 1
+		}
+	}
+	for {
+		f, err := os.OpenFile(filepath.Join(dir, versionFile(version)), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			// Another save took this version first.
+			version++
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		return version, f.Close()
+	}
+}
+
+// Load returns version req.Version of the artifact, or its latest version
+// if unset.
+func (s *Service) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	meta, err := s.Stat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	b.Grow(int(meta.Size))
+	if err := s.readChunks(ctx, meta, &b); err != nil {
+		return nil, fmt.Errorf("failed to load artifact %s version %d: %w", req.FileName, meta.Version, err)
+	}
+	if meta.Text {
+		return &artifact.LoadResponse{Part: genai.NewPartFromText(b.String())}, nil
+	}
+	return &artifact.LoadResponse{Part: &genai.Part{InlineData: &genai.Blob{
+		MIMEType:    meta.MIMEType,
+		DisplayName: meta.DisplayName,
+		Data:        b.Bytes(),
+	}}}, nil
+}
+
+// LoadStream writes version req.Version of the artifact, or its latest
+// version if unset, to w, and returns its metadata.
+func (s *Service) LoadStream(ctx context.Context, req *artifact.LoadRequest, w io.Writer) (*Metadata, error) {
+	meta, err := s.Stat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.readChunks(ctx, meta, w); err != nil {
+		return nil, fmt.Errorf("failed to load artifact %s version %d: %w", req.FileName, meta.Version, err)
+	}
+	return meta, nil
+}
+
+// Stat returns the metadata of version req.Version of the artifact, or of
+// its latest version if unset.
+func (s *Service) Stat(ctx context.Context, req *artifact.LoadRequest) (*Metadata, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	dir := s.artifactDir(req.AppName, req.UserID, req.SessionID, req.FileName)
+	version := req.Version
+	if version <= 0 {
+		versions, err := versions(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) == 0 {
+			return nil, fmt.Errorf("artifact not found: %w", fs.ErrNotExist)
+		}
+		version = versions[0]
+	}
+	data, err := os.ReadFile(filepath.Join(dir, metadataFile(version)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("artifact not found: %w", fs.ErrNotExist)
+	}
+	if err != nil {
+		return nil, err
+	}
+	meta := &Metadata{}
+	if err := json.Unmarshal(data, meta); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata of artifact %s version %d: %w", req.FileName, version, err)
+	}
+	return meta, nil
+}
+
+// Delete deletes version req.Version of the artifact, or all its versions
+// if unset. Their chunks stay until Prune.
+func (s *Service) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	dir := s.artifactDir(req.AppName, req.UserID, req.SessionID, req.FileName)
+	if req.Version <= 0 {
+		return os.RemoveAll(dir)
+	}
+	// The version is gone once its metadata file is.
+	for _, name := range []string{metadataFile(req.Version), versionFile(req.Version)} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the names of the artifacts of the session and of the user,
+// sorted.
+func (s *Service) List(ctx context.Context, req *artifact.ListRequest) (*artifact.ListResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	names := []string{}
+	for _, dir := range []string{
+		filepath.Join(s.sessionDir(req.AppName, req.UserID, req.SessionID), "artifacts"),
+		filepath.Join(s.userDir(req.AppName, req.UserID), "artifacts"),
+	} {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			name, err := url.PathUnescape(e.Name())
+			if err != nil {
+				continue
+			}
+			if versions, err := versions(filepath.Join(dir, e.Name())); err == nil && len(versions) > 0 {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	return &artifact.ListResponse{FileNames: slices.Compact(names)}, nil
+}
+
+// Describe describes the artifact from the metadata of its first and latest
+// versions.
+func (s *Service) Describe(ctx context.Context, req *artifact.VersionsRequest) (*artifactlist.Info, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	versions, err := versions(s.artifactDir(req.AppName, req.UserID, req.SessionID, req.FileName))
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("artifact not found: %w", fs.ErrNotExist)
+	}
+	stat := func(version int64) (*Metadata, error) {
+		return s.Stat(ctx, &artifact.LoadRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: version})
+	}
+	latest, err := stat(versions[0])
+	if err != nil {
+		return nil, err
+	}
+	first, err := stat(versions[len(versions)-1])
+	if err != nil {
+		return nil, err
+	}
+	return &artifactlist.Info{
+		Name:       req.FileName,
+		Version:    latest.Version,
+		Versions:   len(versions),
+		MIMEType:   latest.MIMEType,
+		Size:       latest.Size,
+		CreateTime: first.CreateTime,
+		UpdateTime: latest.CreateTime,
+	}, nil
+}
+
+// Versions returns the versions of the artifact, latest first.
+func (s *Service) Versions(ctx context.Context, req *artifact.VersionsRequest) (*artifact.VersionsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	versions, err := versions(s.artifactDir(req.AppName, req.UserID, req.SessionID, req.FileName))
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("artifact not found: %w", fs.ErrNotExist)
+	}
+	return &artifact.VersionsResponse{Versions: versions}, nil
+}
+
+// versions returns the complete versions in dir, latest first.
+func versions(dir string) ([]int64, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []int64
+	for _, e := range entries {
+		base, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			continue
+		}
+		if v, err := strconv.ParseInt(base, 10, 64); err == nil && v > 0 {
+			versions = append(versions, v)
+		}
+	}
+	slices.Sort(versions)
+	slices.Reverse(versions)
+	return versions, nil
+}
+
+func (s *Service) userDir(appName, userID string) string {
+	return filepath.Join(s.root, "apps", escape(appName), "users", escape(userID))
+}
+
+func (s *Service) sessionDir(appName, userID, sessionID string) string {
+	return filepath.Join(s.userDir(appName, userID), "sessions", escape(sessionID))
+}
+
+// artifactDir returns the directory of the versions of an artifact, which
+// is under the user rather than the session for a "user:" name.
+func (s *Service) artifactDir(appName, userID, sessionID, fileName string) string {
+	dir := s.sessionDir(appName, userID, sessionID)
+	if strings.HasPrefix(fileName, userPrefix) {
+		dir = s.userDir(appName, userID)
+	}
+	return filepath.Join(dir, "artifacts", escape(fileName))
+}
+
+func versionFile(version int64) string {
+	return strconv.FormatInt(version, 10)
+}
+
+func metadataFile(version int64) string {
+	return versionFile(version) + ".json"
+}
+
+// escape turns a name into a single path element.
+func escape(name string) string {
+	e := url.PathEscape(name)
+	if strings.HasPrefix(e, ".") {
+		e = "%2E" + e[1:]
+	}
+	return e
+}
+
+// writeFile replaces the file at path with data atomically.
+func writeFile(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // fails once renamed
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+var (
+	_ artifact.Service       = (*Service)(nil)
+	_ artifactlist.Describer = (*Service)(nil)
+)
@@ -0,0 +1,324 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fileartifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/artifacttest"
+)
+
+func open(t *testing.T, root string) *Service {
+	t.Helper()
+	s, err := Open(root, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestService(t *testing.T) {
+	artifacttest.Run(t, func(t *testing.T) artifact.Service {
+		return open(t, t.TempDir())
+	})
+}
+
+func save(t *testing.T, s *Service, sessionID, name string, part *genai.Part) int64 {
+	t.Helper()
+	resp, err := s.Save(t.Context(), &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: sessionID, FileName: name, Part: part})
+	if err != nil {
+		t.Fatalf("Save(%s) failed: %v", name, err)
+	}
+	return resp.Version
+}
+
+func load(t *testing.T, s *Service, sessionID, name string, version int64) *genai.Part {
+	t.Helper()
+	resp, err := s.Load(t.Context(), &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: sessionID, FileName: name, Version: version})
+	if err != nil {
+		t.Fatalf("Load(%s, %d) failed: %v", name, version, err)
+	}
+	return resp.Part
+}
+
+func versionsOf(t *testing.T, s *Service, sessionID, name string) []int64 {
+	t.Helper()
+	resp, err := s.Versions(t.Context(), &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: sessionID, FileName: name})
+	if err != nil {
+		t.Fatalf("Versions(%s) failed: %v", name, err)
+	}
+	return resp.Versions
+}
+
+func TestSaveLoad(t *testing.T) {
+	root := t.TempDir()
+	s := open(t, root)
+	created := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return created }
+
+	if v := save(t, s, "s1", "notes.txt", genai.NewPartFromText("first")); v != 1 {
+		t.Errorf("first Save() = version %d, want 1", v)
+	}
+	png := &genai.Part{InlineData: &genai.Blob{MIMEType: "image/png", DisplayName: "chart", Data: []byte{0x89, 'P', 'N', 'G'}}}
+	if v := save(t, s, "s1", "notes.txt", png); v != 2 {
+		t.Errorf("second Save() = version %d, want 2", v)
+	}
+
+	// A new Service on the same directory sees the same artifacts.
+	s = open(t, root)
+	if got := load(t, s, "s1", "notes.txt", 1); got.Text != "first" || got.InlineData != nil {
+		t.Errorf("Load(version 1) = %+v, want the text part", got)
+	}
+	got := load(t, s, "s1", "notes.txt", 0)
+	if got.InlineData == nil || got.InlineData.MIMEType != "image/png" || got.InlineData.DisplayName != "chart" || string(got.InlineData.Data) != string(png.InlineData.Data) {
+		t.Errorf("Load(latest) = %+v, want the PNG", got)
+	}
+	meta, err := s.Stat(t.Context(), &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "s1", FileName: "notes.txt", Version: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.MIMEType != "text/plain" || !meta.CreateTime.Equal(created) || meta.Size != 5 {
+		t.Errorf("Stat(version 1) = %+v, want text/plain of 5 bytes created at %v", meta, created)
+	}
+	if got := versionsOf(t, s, "s1", "notes.txt"); !slices.Equal(got, []int64{2, 1}) {
+		t.Errorf("Versions() = %v, want [2 1]", got)
+	}
+
+	// Saving with a version replaces it.
+	if _, err := s.Save(t.Context(), &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "s1", FileName: "notes.txt", Part: genai.NewPartFromText("fixed"), Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if got := load(t, s, "s1", "notes.txt", 1); got.Text != "fixed" {
+		t.Errorf("Load(version 1) after replacing it = %q, want %q", got.Text, "fixed")
+	}
+
+	for _, req := range []*artifact.LoadRequest{
+		{AppName: "app", UserID: "user", SessionID: "s1", FileName: "notes.txt", Version: 3},
+		{AppName: "app", UserID: "user", SessionID: "s1", FileName: "missing.txt"},
+		{AppName: "app", UserID: "user", SessionID: "s2", FileName: "notes.txt"},
+	} {
+		if _, err := s.Load(t.Context(), req); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Load(%s in %s, version %d) = %v, want fs.ErrNotExist", req.FileName, req.SessionID, req.Version, err)
+		}
+	}
+}
+
+func TestConcurrentSaves(t *testing.T) {
+	root := t.TempDir()
+	const n = 20
+	var wg sync.WaitGroup
+	versions := make([]int64, n)
+	errs := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each save has a Service of its own, as separate processes would.
+			s, err := Open(root, Config{})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			resp, err := s.Save(t.Context(), &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "s1", FileName: "log.txt", Part: genai.NewPartFromText(fmt.Sprint(i))})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			versions[i] = resp.Version
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		t.Fatal(err)
+	}
+
+	s := open(t, root)
+	for i, v := range versions {
+		if got := load(t, s, "s1", "log.txt", v); got.Text != fmt.Sprint(i) {
+			t.Errorf("Load(version %d) = %q, want %q", v, got.Text, fmt.Sprint(i))
+		}
+	}
+	slices.Sort(versions)
+	if versions[0] != 1 || versions[n-1] != n || len(slices.Compact(versions)) != n {
+		t.Errorf("concurrent Save() versions = %v, want 1 to %d", versions, n)
+	}
+}
+
+func TestIncompleteVersion(t *testing.T) {
+	root := t.TempDir()
+	s := open(t, root)
+	save(t, s, "s1", "a.txt", genai.NewPartFromText("one"))
+	// A save that crashed before writing its metadata.
+	if err := os.WriteFile(filepath.Join(s.artifactDir("app", "user", "s1", "a.txt"), "2"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := load(t, s, "s1", "a.txt", 0); got.Text != "one" {
+		t.Errorf("Load(latest) = %q, want the complete version", got.Text)
+	}
+	if v := save(t, s, "s1", "a.txt", genai.NewPartFromText("three")); v != 3 {
+		t.Errorf("Save() after an incomplete version = version %d, want 3", v)
+	}
+	if got := versionsOf(t, s, "s1", "a.txt"); !slices.Equal(got, []int64{3, 1}) {
+		t.Errorf("Versions() = %v, want [3 1]", got)
+	}
+}
+
+func streamRequest(name string) *SaveStreamRequest {
+	return &SaveStreamRequest{AppName: "app", UserID: "user", SessionID: "s1", FileName: name, MIMEType: "application/pdf"}
+}
+
+func countChunks(t *testing.T, root string) int {
+	t.Helper()
+	n := 0
+	err := filepath.WalkDir(filepath.Join(root, "chunks"), func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return err
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestStream(t *testing.T) {
+	root := t.TempDir()
+	s, err := Open(root, Config{ChunkSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := "0123456789"
+	resp, err := s.SaveStream(t.Context(), streamRequest("story.pdf"), strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("SaveStream() failed: %v", err)
+	}
+	if resp.Version != 1 {
+		t.Errorf("SaveStream() = version %d, want 1", resp.Version)
+	}
+
+	var b strings.Builder
+	meta, err := s.LoadStream(t.Context(), &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "s1", FileName: "story.pdf"}, &b)
+	if err != nil {
+		t.Fatalf("LoadStream() failed: %v", err)
+	}
+	if b.String() != content {
+		t.Errorf("LoadStream() wrote %q, want %q", b.String(), content)
+	}
+	sum := sha256.Sum256([]byte(content))
+	if meta.SHA256 != hex.EncodeToString(sum[:]) || meta.Size != 10 || len(meta.Chunks) != 3 || meta.MIMEType != "application/pdf" {
+		t.Errorf("LoadStream() metadata = %+v, want 10 bytes in 3 chunks", meta)
+	}
+	if got := load(t, s, "s1", "story.pdf", 0); string(got.InlineData.Data) != content || got.InlineData.MIMEType != "application/pdf" {
+		t.Errorf("Load() = %+v, want the streamed content", got)
+	}
+}
+
+func TestDedup(t *testing.T) {
+	root := t.TempDir()
+	s, err := Open(root, Config{ChunkSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	save(t, s, "s1", "report.txt", genai.NewPartFromText("aaaabbbbcc"))
+	if n := countChunks(t, root); n != 3 {
+		t.Fatalf("stored %d chunks, want 3", n)
+	}
+	// An unchanged version, even in another session, stores no chunk.
+	save(t, s, "s1", "report.txt", genai.NewPartFromText("aaaabbbbcc"))
+	save(t, s, "s2", "copy.txt", genai.NewPartFromText("aaaabbbbcc"))
+	if n := countChunks(t, root); n != 3 {
+		t.Errorf("stored %d chunks after saving the same content again, want 3", n)
+	}
+	// A changed version stores only the chunks that changed.
+	save(t, s, "s1", "report.txt", genai.NewPartFromText("aaaabbbbdd"))
+	if n := countChunks(t, root); n != 4 {
+		t.Errorf("stored %d chunks after changing the last chunk, want 4", n)
+	}
+	if got := versionsOf(t, s, "s1", "report.txt"); !slices.Equal(got, []int64{3, 2, 1}) {
+		t.Errorf("Versions() = %v, want [3 2 1]", got)
+	}
+}
+
+func TestMaxSize(t *testing.T) {
+	s, err := Open(t.TempDir(), Config{ChunkSize: 4, MaxSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SaveStream(t.Context(), streamRequest("big.pdf"), strings.NewReader("123456789")); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("SaveStream() of 9 bytes = %v, want ErrTooLarge", err)
+	}
+	if _, err := s.Save(t.Context(), &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "s1", FileName: "big.txt", Part: genai.NewPartFromText("123456789")}); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Save() of 9 bytes = %v, want ErrTooLarge", err)
+	}
+	if _, err := s.Versions(t.Context(), &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "s1", FileName: "big.pdf"}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Versions() of a rejected artifact = %v, want fs.ErrNotExist", err)
+	}
+	if _, err := s.SaveStream(t.Context(), streamRequest("ok.pdf"), strings.NewReader("12345678")); err != nil {
+		t.Errorf("SaveStream() of 8 bytes failed: %v", err)
+	}
+}
+
+func TestCorruptChunk(t *testing.T) {
+	root := t.TempDir()
+	s := open(t, root)
+	save(t, s, "s1", "a.txt", genai.NewPartFromText("hello"))
+	sum := sha256.Sum256([]byte("hello"))
+	if err := os.WriteFile(s.chunkPath(hex.EncodeToString(sum[:])), []byte("jello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load(t.Context(), &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "s1", FileName: "a.txt"}); err == nil {
+		t.Error("Load() of a corrupt chunk succeeded, want an error")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	root := t.TempDir()
+	s, err := Open(root, Config{ChunkSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	save(t, s, "s1", "a.txt", genai.NewPartFromText("aaaabbbb"))
+	save(t, s, "s1", "b.txt", genai.NewPartFromText("aaaacccc"))
+	if err := s.Delete(t.Context(), &artifact.DeleteRequest{AppName: "app", UserID: "user", SessionID: "s1", FileName: "a.txt"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Recent chunks are left alone.
+	if n, err := s.Prune(t.Context(), time.Hour); err != nil || n != 0 {
+		t.Errorf("Prune(1h) = %d, %v, want 0 chunks removed", n, err)
+	}
+	s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if n, err := s.Prune(t.Context(), time.Hour); err != nil || n != 1 {
+		t.Errorf("Prune(1h) two hours later = %d, %v, want the one chunk only a.txt used", n, err)
+	}
+	if got := load(t, s, "s1", "b.txt", 0); got.Text != "aaaacccc" {
+		t.Errorf("Load(b.txt) after Prune() = %q, want its content", got.Text)
+	}
+}
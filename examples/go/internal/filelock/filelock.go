@@ -0,0 +1,26 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filelock locks files across processes, so that the stores that
+// keep their data in files can be shared:
+//
+//	unlock, err := filelock.Lock(filepath.Join(root, ".lock"), true)
+//	if err != nil {
+//		return err
+//	}
+//	defer unlock()
+//
+// Lock uses flock(2) on unix and LockFileEx on windows. On other platforms,
+// the lock only holds within a process.
+package filelock
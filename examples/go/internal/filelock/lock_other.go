@@ -0,0 +1,51 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !unix && !windows
+
+package filelock
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+var (
+	locksMu sync.Mutex
+	locks   = map[string]*sync.RWMutex{}
+)
+
+// Lock locks path within this process only: on platforms without
+// flock(2) or LockFileEx, what it guards must not be shared between
+// processes.
+func Lock(path string, exclusive bool) (unlock func(), err error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	locksMu.Lock()
+	mu, ok := locks[abs]
+	if !ok {
+		mu = &sync.RWMutex{}
+		locks[abs] = mu
+	}
+	locksMu.Unlock()
+
+	if exclusive {
+		mu.Lock()
+		return mu.Unlock, nil
+	}
+	mu.RLock()
+	return mu.RUnlock, nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesession
+
+import (
+	"log"
+	"os"
+
+	"google.golang.org/adk/session"
+)
+
+// EnvDir names the environment variable holding the directory the examples
+// store their sessions in.
+const EnvDir = "ADK_SESSION_DIR"
+
+// FromEnv returns a session service constructor that stores sessions under
+// $ADK_SESSION_DIR, or fallback when the variable is unset:
+//
+//	var newSessionService = filesession.FromEnv(session.InMemoryService)
+//
+// The constructor exits the program if the directory cannot be created.
+func FromEnv(fallback func() session.Service) func() session.Service {
+	return func() session.Service {
+		dir := os.Getenv(EnvDir)
+		if dir == "" {
+			return fallback()
+		}
+		s, err := Open(dir)
+		if err != nil {
+			log.Fatalf("Failed to open session directory %s: %v", dir, err)
+		}
+		return s
+	}
+}
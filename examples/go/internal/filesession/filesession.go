@@ -0,0 +1,595 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filesession provides a session.Service that keeps every session
+// in a directory of plain files, so conversations can be read and grepped
+// while debugging:
+//
+//	<root>/apps/<app>/state.json                            app: state
+//	<root>/apps/<app>/users/<user>/state.json               user: state
+//	<root>/apps/<app>/users/<user>/sessions/<id>/
+//		events.jsonl   one JSON event per line, in append order
+//		state.json     snapshot of the session's own state
+//		metadata.json  IDs, creation and last update time
+//
+// Path elements are escaped with url.PathEscape.
+//
+// events.jsonl is the record of a session: Get rebuilds the session state by
+// replaying the state deltas of its events over the state the session was
+// created with, and takes the last update time from the last event.
+// state.json and the LastUpdateTime in metadata.json are updated after
+// every append for people to read. An append only reads the tail of
+// events.jsonl: a torn last line, which a crash may leave, is ignored when
+// loading and dropped by the next append.
+//
+// The app: and user: state is shared by sessions, and only kept in the
+// state.json files of the app and the user. An append writes their deltas
+// before the event, so that a crash in between leaves them applied rather
+// than lost; retrying the append sets the same keys again.
+//
+// Every call holds a lock on <root>/.lock, so several processes may share a
+// store, except on platforms that are neither unix nor windows, where the
+// lock only holds within a process. It behaves like session.InMemoryService
+// otherwise; like sqlitesession, it returns state values as encoding/json
+// decodes them.
+package filesession
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/adk/session"
+
+	"github.com/google/adk-docs/examples/go/internal/filelock"
+	"github.com/google/adk-docs/examples/go/internal/localsession"
+)
+
+const (
+	eventsFile   = "events.jsonl"
+	stateFile    = "state.json"
+	metadataFile = "metadata.json"
+)
+
+// metadata is the content of metadata.json.
+type metadata struct {
+	AppName        string    `json:"appName"`
+	UserID         string    `json:"userId"`
+	ID             string    `json:"id"`
+	CreateTime     time.Time `json:"createTime"`
+	LastUpdateTime time.Time `json:"lastUpdateTime"`
+	// InitialState is the session-scoped state the session was created
+	// with, which the deltas of its events are replayed over.
+	InitialState map[string]any `json:"initialState,omitempty"`
+	// Events is the number of events in events.jsonl as of the last append,
+	// and EventsSize the length of the lines holding them. A log of another
+	// length, left by a crash or an older version of this package, has its
+	// events counted again.
+	Events     int   `json:"events"`
+	EventsSize int64 `json:"eventsSize"`
+}
+
+// Service is a session.Service that stores sessions under a root directory.
+type Service struct {
+	root string
+}
+
+// Open returns a Service that stores sessions under root, creating the
+// directory if needed.
+func Open(root string) (*Service, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &Service{root: root}, nil
+}
+
+func (s *Service) Create(ctx context.Context, req *session.CreateRequest) (*session.CreateResponse, error) {
+	if req.AppName == "" || req.UserID == "" {
+		return nil, fmt.Errorf("app_name and user_id are required, got app_name: %q, user_id: %q", req.AppName, req.UserID)
+	}
+	id := req.SessionID
+	if id == "" {
+		id = uuid.NewString()
+	}
+
+	unlock, err := s.lock(true)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	dir := s.sessionDir(req.AppName, req.UserID, id)
+	if _, err := os.Stat(filepath.Join(dir, metadataFile)); err == nil {
+		return nil, fmt.Errorf("session %s already exists", id)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	app, user, state := localsession.SplitState(req.State)
+	if err := os.WriteFile(filepath.Join(dir, eventsFile), nil, 0o644); err != nil {
+		return nil, err
+	}
+	if err := writeJSON(filepath.Join(dir, stateFile), state); err != nil {
+		return nil, err
+	}
+	app, err = updateState(filepath.Join(s.appDir(req.AppName), stateFile), app)
+	if err != nil {
+		return nil, err
+	}
+	user, err = updateState(filepath.Join(s.userDir(req.AppName, req.UserID), stateFile), user)
+	if err != nil {
+		return nil, err
+	}
+	// The metadata file makes the session exist, so it is written last.
+	now := time.Now()
+	meta := &metadata{
+		AppName:        req.AppName,
+		UserID:         req.UserID,
+		ID:             id,
+		CreateTime:     now,
+		LastUpdateTime: now,
+		InitialState:   state,
+	}
+	if err := writeJSON(filepath.Join(dir, metadataFile), meta); err != nil {
+		return nil, err
+	}
+
+	merged := localsession.MergeState(app, user, state)
+	return &session.CreateResponse{Session: localsession.New(req.AppName, req.UserID, id, merged, nil, now)}, nil
+}
+
+func (s *Service) Get(ctx context.Context, req *session.GetRequest) (*session.GetResponse, error) {
+	appName, userID, id := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || id == "" {
+		return nil, fmt.Errorf("app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", appName, userID, id)
+	}
+
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	l, err := s.load(appName, userID, id)
+	if errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("session %s not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	events := localsession.FilterEvents(l.events, req)
+	return &session.GetResponse{Session: localsession.New(appName, userID, id, l.state, events, l.updatedAt)}, nil
+}
+
+// List returns the sessions of an app, or of one of its users if
+// req.UserID is set, without their events.
+func (s *Service) List(ctx context.Context, req *session.ListRequest) (*session.ListResponse, error) {
+	if req.AppName == "" {
+		return nil, fmt.Errorf("app_name is required, got app_name: %q", req.AppName)
+	}
+
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var userDirs []string
+	if req.UserID != "" {
+		userDirs = []string{s.userDir(req.AppName, req.UserID)}
+	} else {
+		entries, err := readDir(filepath.Join(s.appDir(req.AppName), "users"))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			userDirs = append(userDirs, filepath.Join(s.appDir(req.AppName), "users", e.Name()))
+		}
+	}
+
+	sessions := []session.Session{}
+	for _, userDir := range userDirs {
+		entries, err := readDir(filepath.Join(userDir, "sessions"))
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			var meta metadata
+			if err := readJSON(filepath.Join(userDir, "sessions", e.Name(), metadataFile), &meta); errors.Is(err, fs.ErrNotExist) {
+				continue // not created, or not completely
+			} else if err != nil {
+				return nil, err
+			}
+			l, err := s.load(meta.AppName, meta.UserID, meta.ID)
+			if err != nil {
+				return nil, err
+			}
+			sessions = append(sessions, localsession.New(meta.AppName, meta.UserID, meta.ID, l.state, nil, l.updatedAt))
+		}
+	}
+	return &session.ListResponse{Sessions: sessions}, nil
+}
+
+// Delete removes the directory of a session. Deleting a session that does
+// not exist is not an error.
+func (s *Service) Delete(ctx context.Context, req *session.DeleteRequest) error {
+	appName, userID, id := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || id == "" {
+		return fmt.Errorf("app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", appName, userID, id)
+	}
+
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	dir := s.sessionDir(appName, userID, id)
+	// Without its metadata file the session no longer exists, even if a
+	// crash stops RemoveAll halfway.
+	if err := os.Remove(filepath.Join(dir, metadataFile)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// AppendEvent appends e to the event log of curSession, which must have been
+// returned by s, then updates the state files and curSession.
+func (s *Service) AppendEvent(ctx context.Context, curSession session.Session, e *session.Event) error {
+	_, _, err := s.appendEvent(curSession, -1, e)
+	return err
+}
+
+// AppendEventIf appends e to curSession as AppendEvent does, but only if the
+// stored session holds exactly version events. It returns the number of
+// events the stored session held, and whether e was appended. The check and
+// the append happen under one lock, so sessionversion can use it to check
+// the appends of every process sharing the store.
+func (s *Service) AppendEventIf(ctx context.Context, curSession session.Session, version int, e *session.Event) (stored int, appended bool, err error) {
+	return s.appendEvent(curSession, version, e)
+}
+
+// appendEvent appends e if the session holds version events, or in any case
+// if version is negative.
+func (s *Service) appendEvent(curSession session.Session, version int, e *session.Event) (stored int, appended bool, err error) {
+	if curSession == nil {
+		return 0, false, fmt.Errorf("session is nil")
+	}
+	if e == nil {
+		return 0, false, fmt.Errorf("event is nil")
+	}
+	if e.Partial {
+		return 0, false, nil
+	}
+	sess, ok := curSession.(*localsession.Session)
+	if !ok {
+		return 0, false, fmt.Errorf("unexpected session type %T", curSession)
+	}
+
+	localsession.TrimTempDelta(e)
+	line, err := json.Marshal(e)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	unlock, err := s.lock(true)
+	if err != nil {
+		return 0, false, err
+	}
+	defer unlock()
+
+	appName, userID, id := sess.AppName(), sess.UserID(), sess.ID()
+	dir := s.sessionDir(appName, userID, id)
+	var meta metadata
+	if err := readJSON(filepath.Join(dir, metadataFile), &meta); errors.Is(err, fs.ErrNotExist) {
+		return 0, false, fmt.Errorf("session not found, cannot apply event")
+	} else if err != nil {
+		return 0, false, err
+	}
+
+	log, err := openLog(filepath.Join(dir, eventsFile), &meta)
+	if err != nil {
+		return 0, false, err
+	}
+	defer log.close()
+	if version >= 0 && log.stored != version {
+		return log.stored, false, nil
+	}
+
+	app, user, state := localsession.SplitState(e.Actions.StateDelta)
+	if _, err := updateState(filepath.Join(s.appDir(appName), stateFile), app); err != nil {
+		return 0, false, err
+	}
+	if _, err := updateState(filepath.Join(s.userDir(appName, userID), stateFile), user); err != nil {
+		return 0, false, err
+	}
+	if err := log.append(line, &meta); err != nil {
+		return 0, false, err
+	}
+
+	// The event is on disk, and appended: the session files are only
+	// brought up to date with it for people to read, and a failure to write
+	// them is not an error. load does not read them, and the next append
+	// counts the events again if the metadata is stale.
+	updateState(filepath.Join(dir, stateFile), state)
+	meta.LastUpdateTime = e.Timestamp
+	writeJSON(filepath.Join(dir, metadataFile), &meta)
+
+	sess.Append(e)
+	return log.stored, true, nil
+}
+
+var errNotFound = errors.New("session not found")
+
+// loaded is a session as rebuilt from its files.
+type loaded struct {
+	state     map[string]any // merged with the app and user state
+	events    []*session.Event
+	updatedAt time.Time
+}
+
+// load rebuilds a session from its event log.
+func (s *Service) load(appName, userID, id string) (*loaded, error) {
+	dir := s.sessionDir(appName, userID, id)
+	var meta metadata
+	if err := readJSON(filepath.Join(dir, metadataFile), &meta); errors.Is(err, fs.ErrNotExist) {
+		return nil, errNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, eventsFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	events, _, err := parseEvents(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(dir, eventsFile), err)
+	}
+
+	app, err := readState(filepath.Join(s.appDir(appName), stateFile))
+	if err != nil {
+		return nil, err
+	}
+	user, err := readState(filepath.Join(s.userDir(appName, userID), stateFile))
+	if err != nil {
+		return nil, err
+	}
+	l := &loaded{
+		state:     localsession.MergeState(app, user, localsession.Replay(meta.InitialState, events)),
+		events:    events,
+		updatedAt: meta.CreateTime,
+	}
+	if len(events) > 0 {
+		l.updatedAt = events[len(events)-1].Timestamp
+	}
+	return l, nil
+}
+
+func (s *Service) lock(exclusive bool) (unlock func(), err error) {
+	return filelock.Lock(filepath.Join(s.root, ".lock"), exclusive)
+}
+
+func (s *Service) appDir(appName string) string {
+	return filepath.Join(s.root, "apps", escape(appName))
+}
+
+func (s *Service) userDir(appName, userID string) string {
+	return filepath.Join(s.appDir(appName), "users", escape(userID))
+}
+
+func (s *Service) sessionDir(appName, userID, id string) string {
+	return filepath.Join(s.userDir(appName, userID), "sessions", escape(id))
+}
+
+// escape turns an ID into a single path element.
+func escape(id string) string {
+	e := url.PathEscape(id)
+	if strings.HasPrefix(e, ".") {
+		e = "%2E" + e[1:]
+	}
+	return e
+}
+
+// parseEvents decodes an event log. It returns the length of the complete
+// lines it decoded; a torn last line is left out.
+func parseEvents(data []byte) (events []*session.Event, n int, err error) {
+	for n < len(data) {
+		i := bytes.IndexByte(data[n:], '\n')
+		if i < 0 {
+			break
+		}
+		line := data[n : n+i]
+		if len(bytes.TrimSpace(line)) > 0 {
+			e := &session.Event{}
+			if err := json.Unmarshal(line, e); err != nil {
+				return nil, 0, fmt.Errorf("line %d: %w", len(events)+1, err)
+			}
+			events = append(events, e)
+		}
+		n += i + 1
+	}
+	return events, n, nil
+}
+
+// eventLog is an event log open for an append.
+type eventLog struct {
+	f      *os.File
+	n      int64 // length of its complete lines
+	stored int   // number of events in them
+}
+
+// openLog opens the event log at path for an append, and counts its events.
+// Only the tail of the log is read, to find where a torn line an earlier
+// append may have left starts, unless meta does not match the log and its
+// events have to be counted again.
+func openLog(path string, meta *metadata) (*eventLog, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	l := &eventLog{f: f, stored: meta.Events}
+	if l.n, err = completeLength(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if l.n != meta.EventsSize {
+		data := make([]byte, l.n)
+		if _, err := f.ReadAt(data, 0); err != nil {
+			f.Close()
+			return nil, err
+		}
+		events, _, err := parseEvents(data)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		l.stored = len(events)
+	}
+	return l, nil
+}
+
+// append appends line to the log and syncs it, dropping a torn last line,
+// and updates the count in meta.
+func (l *eventLog) append(line []byte, meta *metadata) error {
+	if err := l.f.Truncate(l.n); err != nil {
+		return err
+	}
+	if _, err := l.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := l.f.Sync(); err != nil {
+		return err
+	}
+	meta.Events, meta.EventsSize = l.stored+1, l.n+int64(len(line))+1
+	return nil
+}
+
+func (l *eventLog) close() error {
+	return l.f.Close()
+}
+
+// completeLength returns the length of the complete lines of f, reading it
+// backwards from its end up to the last newline.
+func completeLength(f *os.File) (int64, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	end := fi.Size()
+	buf := make([]byte, 4096)
+	for end > 0 {
+		chunk := buf[:min(int64(len(buf)), end)]
+		if _, err := f.ReadAt(chunk, end-int64(len(chunk))); err != nil {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(chunk, '\n'); i >= 0 {
+			return end - int64(len(chunk)) + int64(i) + 1, nil
+		}
+		end -= int64(len(chunk))
+	}
+	return 0, nil
+}
+
+// updateState applies delta to the state file at path and returns the
+// resulting state.
+func updateState(path string, delta map[string]any) (map[string]any, error) {
+	state, err := readState(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(delta) == 0 {
+		return state, nil
+	}
+	for k, v := range delta {
+		state[k] = v
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return state, writeJSON(path, state)
+}
+
+// readState reads a state file; a missing file is an empty state.
+func readState(path string) (map[string]any, error) {
+	state := map[string]any{}
+	if err := readJSON(path, &state); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return state, nil
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// writeJSON replaces the file at path with the indented encoding of v. The
+// file is written next to path and renamed over it, so readers see either
+// the old or the new content.
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // fails once renamed
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// readDir lists the subdirectories of dir; a missing dir has none.
+func readDir(dir string) ([]fs.DirEntry, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	var dirs []fs.DirEntry
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, e)
+		}
+	}
+	return dirs, err
+}
+
+var _ session.Service = (*Service)(nil)
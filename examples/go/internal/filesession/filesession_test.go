@@ -0,0 +1,273 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesession
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/sessiontest"
+)
+
+func open(t *testing.T, root string) *Service {
+	t.Helper()
+	s, err := Open(root)
+	if err != nil {
+		t.Fatalf("Open(%q) failed: %v", root, err)
+	}
+	return s
+}
+
+func TestService(t *testing.T) {
+	sessiontest.Run(t, func(t *testing.T) session.Service {
+		return open(t, t.TempDir())
+	})
+}
+
+// newSession creates app/user/s1 and appends events that count up the
+// session key "n" and the user key "user:n".
+func newSession(t *testing.T, s *Service, events int) session.Session {
+	t.Helper()
+	resp, err := s.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1",
+		State: map[string]any{"initial": "yes"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range events {
+		e := session.NewEvent("inv")
+		e.Author = "agent"
+		e.Content = genai.NewContentFromText(fmt.Sprintf("turn %d", i), genai.RoleModel)
+		e.Actions.StateDelta["n"] = fmt.Sprint(i)
+		e.Actions.StateDelta["user:n"] = fmt.Sprint(i)
+		e.Actions.StateDelta["temp:scratch"] = "x"
+		if err := s.AppendEvent(t.Context(), resp.Session, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.Session
+}
+
+func get(t *testing.T, s *Service) session.Session {
+	t.Helper()
+	resp, err := s.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	return resp.Session
+}
+
+func checkKey(t *testing.T, sess session.Session, key string, want any) {
+	t.Helper()
+	if got, err := sess.State().Get(key); err != nil || got != want {
+		t.Errorf("State().Get(%q) = %v, %v, want %v", key, got, err, want)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	root := t.TempDir()
+	newSession(t, open(t, root), 2)
+
+	dir := filepath.Join(root, "apps", "app", "users", "user", "sessions", "s1")
+	data, err := os.ReadFile(filepath.Join(dir, eventsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("%s has %d lines, want 2", eventsFile, lines)
+	}
+	if strings.Contains(string(data), "temp:scratch") {
+		t.Errorf("%s contains temp: state", eventsFile)
+	}
+	for path, want := range map[string]string{
+		filepath.Join(dir, stateFile):                                  `"n": "1"`,
+		filepath.Join(dir, metadataFile):                               `"lastUpdateTime"`,
+		filepath.Join(root, "apps", "app", "users", "user", stateFile): `"n": "1"`,
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s = %s, want it to contain %s", path, data, want)
+		}
+	}
+}
+
+func TestEscapedIDs(t *testing.T) {
+	s := open(t, t.TempDir())
+	for _, id := range []string{"a/b", "..", ".hidden", "with space"} {
+		if _, err := s.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "../user", SessionID: id}); err != nil {
+			t.Fatalf("Create(%q) failed: %v", id, err)
+		}
+	}
+	resp, err := s.List(t.Context(), &session.ListRequest{AppName: "app", UserID: "../user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Sessions) != 4 {
+		t.Errorf("List() returned %d sessions, want 4", len(resp.Sessions))
+	}
+}
+
+func TestReplayRebuildsState(t *testing.T) {
+	root := t.TempDir()
+	s := open(t, root)
+	newSession(t, s, 3)
+
+	// The snapshots are only for reading: a stale or garbled one does not
+	// change what Get returns.
+	dir := filepath.Join(root, "apps", "app", "users", "user", "sessions", "s1")
+	if err := os.WriteFile(filepath.Join(dir, stateFile), []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sess := get(t, open(t, root))
+	checkKey(t, sess, "n", "2")
+	checkKey(t, sess, "initial", "yes")
+	checkKey(t, sess, "user:n", "2")
+	if _, err := sess.State().Get("temp:scratch"); err == nil {
+		t.Error("temp: state was persisted")
+	}
+	if got, want := sess.LastUpdateTime(), sess.Events().At(2).Timestamp; !got.Equal(want) {
+		t.Errorf("LastUpdateTime() = %v, want the last event's timestamp %v", got, want)
+	}
+}
+
+func TestTornAppend(t *testing.T) {
+	root := t.TempDir()
+	s := open(t, root)
+	newSession(t, s, 2)
+
+	// Simulate a crash in the middle of writing a third event.
+	path := filepath.Join(root, "apps", "app", "users", "user", "sessions", "s1", eventsFile)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"ID":"torn","Actions":{"StateDel`)
+	f.Close()
+
+	sess := get(t, s)
+	if n := sess.Events().Len(); n != 2 {
+		t.Fatalf("session with a torn line has %d events, want 2", n)
+	}
+	e := session.NewEvent("inv")
+	e.Actions.StateDelta["n"] = "after"
+	if err := s.AppendEvent(t.Context(), sess, e); err != nil {
+		t.Fatalf("AppendEvent() after a torn line failed: %v", err)
+	}
+	sess = get(t, s)
+	if n := sess.Events().Len(); n != 3 {
+		t.Errorf("session has %d events, want 3", n)
+	}
+	checkKey(t, sess, "n", "after")
+}
+
+// block makes path a directory, so that writing a file there fails.
+func block(t *testing.T, path string) {
+	t.Helper()
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(path, "blocked"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAppendCommit(t *testing.T) {
+	root := t.TempDir()
+	s := open(t, root)
+	sess := newSession(t, s, 1)
+	dir := filepath.Join(root, "apps", "app", "users", "user", "sessions", "s1")
+
+	// Once the event is in the log, it is appended, even if the session
+	// state file, which is only for reading, cannot be updated.
+	block(t, filepath.Join(dir, stateFile))
+	e := session.NewEvent("inv")
+	e.Actions.StateDelta["n"] = "late"
+	e.Actions.StateDelta["user:n"] = "late"
+	if err := s.AppendEvent(t.Context(), sess, e); err != nil {
+		t.Fatalf("AppendEvent() with a stale session state file = %v, want the event appended", err)
+	}
+	got := get(t, s)
+	if n := got.Events().Len(); n != 2 {
+		t.Errorf("session has %d events, want 2", n)
+	}
+	checkKey(t, got, "n", "late")
+	checkKey(t, got, "user:n", "late")
+
+	// The user state is written before the event, so an append that cannot
+	// write it fails without appending.
+	block(t, filepath.Join(root, "apps", "app", "users", "user", stateFile))
+	e = session.NewEvent("inv")
+	e.Actions.StateDelta["user:n"] = "lost"
+	if err := s.AppendEvent(t.Context(), sess, e); err == nil {
+		t.Fatal("AppendEvent() without a user state file succeeded, want an error")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, eventsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("%s has %d lines after the failed append, want 2", eventsFile, lines)
+	}
+}
+
+func TestSharedRoot(t *testing.T) {
+	// Services on the same root exclude each other through the lock file,
+	// as services in different processes do.
+	root := t.TempDir()
+	newSession(t, open(t, root), 0)
+
+	const writers, perWriter = 4, 10
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := open(t, root)
+			resp, err := s.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+			if err != nil {
+				t.Errorf("Get() failed: %v", err)
+				return
+			}
+			sess := resp.Session
+			for i := range perWriter {
+				e := session.NewEvent("inv")
+				e.Actions.StateDelta[fmt.Sprintf("w%d", w)] = fmt.Sprint(i)
+				if err := s.AppendEvent(t.Context(), sess, e); err != nil {
+					t.Errorf("AppendEvent() failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	sess := get(t, open(t, root))
+	if n := sess.Events().Len(); n != writers*perWriter {
+		t.Errorf("session has %d events, want %d", n, writers*perWriter)
+	}
+	for w := range writers {
+		checkKey(t, sess, fmt.Sprintf("w%d", w), fmt.Sprint(perWriter-1))
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package golden compares the events a snippet program appends to its
+// sessions against checked-in golden files.
+//
+// A snippet program builds its models with gemini.NewModel, and its session
+// service with a package variable the tests can replace:
+//
+//	var newSessionService = session.InMemoryService
+//
+// CheckMain serves a fakellm script as the Gemini API, points
+// newSessionService at a recording service, runs main and checks what was
+// recorded:
+//
+//	func TestExample(t *testing.T) {
+//		golden.CheckMain(t, "example", &newSessionService, main)
+//	}
+//
+// Tests that check more use UseScript, which returns the scripted model
+// with the requests it received, Main and Check on their own.
+//
+// Run the tests with -update to rewrite the golden files after an
+// intended change.
+package golden
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/cassette"
+	"github.com/google/adk-docs/examples/go/internal/fakellm"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files instead of comparing against them")
+
+// script is the fakellm script of a snippet test, relative to its package.
+const script = "testdata/fakellm_script.json"
+
+// UseScript makes every model a program builds with gemini.NewModel play
+// back the script at path for the duration of the test, and returns the
+// scripted model. The test fails if the program does not make exactly one
+// model call per scripted turn.
+func UseScript(t testing.TB, path string) *fakellm.Model {
+	t.Helper()
+	m, err := fakellm.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load script: %v", err)
+	}
+	ServeModel(t, m)
+	t.Cleanup(func() {
+		if n := m.Remaining(); n > 0 {
+			t.Errorf("%s: %d scripted turns were never played", path, n)
+		}
+		if n := m.Overruns(); n > 0 {
+			t.Errorf("%s: %d model calls arrived after the script was exhausted", path, n)
+		}
+	})
+	return m
+}
+
+// UseCassette makes every model a program builds with gemini.NewModel
+// replay the cassette at path, for the duration of the test. The test fails
+// if the program sends a request the cassette does not hold, or fewer
+// requests than were recorded. With -update, the cassette is recorded again,
+// from the fakellm script at script.
+func UseCassette(t testing.TB, path, script string) {
+	t.Helper()
+	if *update {
+		live, err := fakellm.Load(script)
+		if err != nil {
+			t.Fatalf("failed to load script: %v", err)
+		}
+		c, err := cassette.Open(path, cassette.ModeRecord)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			if err := c.Close(); err != nil {
+				t.Error(err)
+			}
+		})
+		ServeModel(t, c.Wrap(live))
+		return
+	}
+	c, err := cassette.Open(path, cassette.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ServeModel(t, c.Wrap(nil))
+	t.Cleanup(func() {
+		if n := c.Unused(); n > 0 {
+			t.Errorf("%s: %d recorded interactions were never replayed", path, n)
+		}
+	})
+}
+
+// ServeModel points the Gemini models a program builds with
+// gemini.NewModel at m, for the duration of the test, by serving m as the
+// Gemini API on a local address.
+func ServeModel(t testing.TB, m model.LLM) {
+	t.Helper()
+	srv := httptest.NewServer(fakellm.Handler(m))
+	t.Cleanup(srv.Close)
+	t.Setenv("GOOGLE_GEMINI_BASE_URL", srv.URL)
+	t.Setenv("GOOGLE_GENAI_USE_VERTEXAI", "false")
+	t.Setenv("GOOGLE_API_KEY", "fake")
+	t.Setenv("GEMINI_API_KEY", "")
+}
+
+// Recorder remembers every event appended through the session services it
+// wraps, across all of them, in append order. The zero value is ready to use.
+type Recorder struct {
+	mu     sync.Mutex
+	events []*session.Event
+}
+
+// Main runs main, a program's main function, with *newService, its session
+// service constructor, pointed at a new Recorder, and returns the events
+// main appended.
+func Main(t testing.TB, newService *func() session.Service, main func()) []*session.Event {
+	t.Helper()
+	rec := &Recorder{}
+	orig := *newService
+	*newService = rec.InMemoryService
+	defer func() { *newService = orig }()
+	main()
+	return rec.Events()
+}
+
+// CheckMain runs main with Main, its models playing back the script at
+// testdata/fakellm_script.json, and checks
+// the events against testdata/<name>.golden.json.
+func CheckMain(t testing.TB, name string, newService *func() session.Service, main func()) {
+	t.Helper()
+	UseScript(t, script)
+	Check(t, name, Main(t, newService, main))
+}
+
+// Wrap returns a session.Service that stores sessions in svc and records
+// the events appended to them.
+func (r *Recorder) Wrap(svc session.Service) session.Service {
+	return &recordingService{Service: svc, rec: r}
+}
+
+// InMemoryService returns a recording session.InMemoryService.
+func (r *Recorder) InMemoryService() session.Service {
+	return r.Wrap(session.InMemoryService())
+}
+
+// Events returns the events recorded so far.
+func (r *Recorder) Events() []*session.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*session.Event(nil), r.events...)
+}
+
+type recordingService struct {
+	session.Service
+	rec *Recorder
+}
+
+func (s *recordingService) AppendEvent(ctx context.Context, sess session.Session, e *session.Event) error {
+	if err := s.Service.AppendEvent(ctx, sess, e); err != nil {
+		return err
+	}
+	if e.Partial {
+		return nil
+	}
+	s.rec.mu.Lock()
+	defer s.rec.mu.Unlock()
+	s.rec.events = append(s.rec.events, e)
+	return nil
+}
+
+// Run sends prompt to a session through r and drains the events, failing
+// the test on the first error. It is for tests that drive a program's agent
+// directly instead of through one of its run helpers.
+func Run(t testing.TB, r *runner.Runner, userID, sessionID, prompt string) {
+	t.Helper()
+	msg := genai.NewContentFromText(prompt, genai.RoleUser)
+	for _, err := range r.Run(t.Context(), userID, sessionID, msg, agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run(%q) failed: %v", prompt, err)
+		}
+	}
+}
+
+// Event is the part of a session.Event that is stable from one run to the
+// next. Function call IDs are replaced by "call-1", "call-2", ... in order of
+// first appearance, so calls and their responses stay paired. The IDs of
+// calls already seen are replaced in the state delta too, where callbacks
+// keep track of calls.
+type Event struct {
+	Author             string           `json:"author"`
+	Branch             string           `json:"branch,omitempty"`
+	Role               string           `json:"role,omitempty"`
+	Parts              []*genai.Part    `json:"parts,omitempty"`
+	StateDelta         map[string]any   `json:"stateDelta,omitempty"`
+	ArtifactDelta      map[string]int64 `json:"artifactDelta,omitempty"`
+	TransferToAgent    string           `json:"transferToAgent,omitempty"`
+	Escalate           bool             `json:"escalate,omitempty"`
+	SkipSummarization  bool             `json:"skipSummarization,omitempty"`
+	LongRunningToolIDs []string         `json:"longRunningToolIds,omitempty"`
+	ErrorCode          string           `json:"errorCode,omitempty"`
+	ErrorMessage       string           `json:"errorMessage,omitempty"`
+}
+
+// Normalize converts events to their stable form.
+func Normalize(events []*session.Event) []*Event {
+	ids := map[string]string{}
+	stable := func(id string) string {
+		if id == "" {
+			return ""
+		}
+		if s, ok := ids[id]; ok {
+			return s
+		}
+		s := fmt.Sprintf("call-%d", len(ids)+1)
+		ids[id] = s
+		return s
+	}
+
+	out := make([]*Event, 0, len(events))
+	for _, e := range events {
+		ne := &Event{
+			Author:            e.Author,
+			Branch:            e.Branch,
+			StateDelta:        e.Actions.StateDelta,
+			ArtifactDelta:     e.Actions.ArtifactDelta,
+			TransferToAgent:   e.Actions.TransferToAgent,
+			Escalate:          e.Actions.Escalate,
+			SkipSummarization: e.Actions.SkipSummarization,
+			ErrorCode:         e.ErrorCode,
+			ErrorMessage:      e.ErrorMessage,
+		}
+		if len(ne.StateDelta) == 0 {
+			ne.StateDelta = nil
+		}
+		if len(ne.ArtifactDelta) == 0 {
+			ne.ArtifactDelta = nil
+		}
+		if c := e.Content; c != nil {
+			ne.Role = c.Role
+			for _, p := range c.Parts {
+				if p == nil {
+					continue
+				}
+				np := *p
+				if fc := p.FunctionCall; fc != nil {
+					ncall := *fc
+					ncall.ID = stable(fc.ID)
+					np.FunctionCall = &ncall
+				}
+				if fr := p.FunctionResponse; fr != nil {
+					nresp := *fr
+					nresp.ID = stable(fr.ID)
+					np.FunctionResponse = &nresp
+				}
+				ne.Parts = append(ne.Parts, &np)
+			}
+		}
+		for _, id := range e.LongRunningToolIDs {
+			ne.LongRunningToolIDs = append(ne.LongRunningToolIDs, stable(id))
+		}
+		if ne.StateDelta != nil {
+			ne.StateDelta = replaceIDs(ne.StateDelta, ids).(map[string]any)
+		}
+		out = append(out, ne)
+	}
+	return out
+}
+
+// replaceIDs returns a copy of v, a value decoded from JSON, with the IDs of
+// ids replaced in its strings and keys.
+func replaceIDs(v any, ids map[string]string) any {
+	var pairs []string
+	for _, id := range slices.SortedFunc(maps.Keys(ids), func(a, b string) int { return len(b) - len(a) }) {
+		pairs = append(pairs, id, ids[id])
+	}
+	r := strings.NewReplacer(pairs...)
+	var walk func(v any) any
+	walk = func(v any) any {
+		switch v := v.(type) {
+		case string:
+			return r.Replace(v)
+		case map[string]any:
+			m := make(map[string]any, len(v))
+			for k, e := range v {
+				m[r.Replace(k)] = walk(e)
+			}
+			return m
+		case []any:
+			s := make([]any, len(v))
+			for i, e := range v {
+				s[i] = walk(e)
+			}
+			return s
+		}
+		return v
+	}
+	return walk(v)
+}
+
+// Check compares the stable form of events with testdata/<name>.golden.json
+// and reports any difference, or rewrites the file when -update is set.
+func Check(t testing.TB, name string, events []*session.Event) {
+	t.Helper()
+	CheckValue(t, name, Normalize(events))
+}
+
+// CheckValue compares the indented JSON encoding of v with
+// testdata/<name>.golden.json. It is for programs whose observable output is
+// not an event stream, like the session management snippets.
+func CheckValue(t testing.TB, name string, v any) {
+	t.Helper()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("failed to encode %s: %v", name, err)
+	}
+	got := buf.String()
+
+	path := filepath.Join("testdata", name+".golden.json")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+	}
+	if diff := cmp.Diff(lines(string(want)), lines(got)); diff != "" {
+		t.Errorf("%s mismatch (-want +got):\n%s", path, diff)
+	}
+}
+
+func lines(s string) []string {
+	return strings.Split(strings.TrimRight(s, "\n"), "\n")
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package llmrequest edits a model.LLMRequest from the ProcessRequest method
+// of a tool or a before-model callback, the way the ADK's own tools do.
+package llmrequest
+
+import (
+	"fmt"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// Tool is a tool the model calls through a function declaration.
+type Tool interface {
+	tool.Tool
+	Declaration() *genai.FunctionDeclaration
+}
+
+// AddTool adds t and its declaration to req, as the function tools of the
+// ADK do, so that the flow can find t when the model calls it. It fails if
+// req already has a tool of the same name.
+func AddTool(req *model.LLMRequest, t Tool) error {
+	if req.Tools == nil {
+		req.Tools = map[string]any{}
+	}
+	if _, ok := req.Tools[t.Name()]; ok {
+		return fmt.Errorf("duplicate tool: %q", t.Name())
+	}
+	req.Tools[t.Name()] = t
+	if req.Config == nil {
+		req.Config = &genai.GenerateContentConfig{}
+	}
+	for _, gt := range req.Config.Tools {
+		if gt != nil && gt.FunctionDeclarations != nil {
+			gt.FunctionDeclarations = append(gt.FunctionDeclarations, t.Declaration())
+			return nil
+		}
+	}
+	req.Config.Tools = append(req.Config.Tools, &genai.Tool{FunctionDeclarations: []*genai.FunctionDeclaration{t.Declaration()}})
+	return nil
+}
+
+// AppendInstructions adds instructions to the system instruction of req.
+func AppendInstructions(req *model.LLMRequest, instructions string) {
+	if req.Config == nil {
+		req.Config = &genai.GenerateContentConfig{}
+	}
+	if req.Config.SystemInstruction == nil {
+		req.Config.SystemInstruction = genai.NewContentFromText(instructions, genai.RoleUser)
+		return
+	}
+	req.Config.SystemInstruction.Parts = append(req.Config.SystemInstruction.Parts, genai.NewPartFromText(instructions))
+}
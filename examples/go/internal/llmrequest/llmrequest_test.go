@@ -0,0 +1,77 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llmrequest_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/llmrequest"
+)
+
+type fakeTool struct{ name string }
+
+func (t fakeTool) Name() string        { return t.name }
+func (t fakeTool) Description() string { return "Does " + t.name + "." }
+func (t fakeTool) IsLongRunning() bool { return false }
+func (t fakeTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{Name: t.name, Description: t.Description()}
+}
+
+func TestAddTool(t *testing.T) {
+	req := &model.LLMRequest{}
+	for _, name := range []string{"a", "b"} {
+		if err := llmrequest.AddTool(req, fakeTool{name}); err != nil {
+			t.Fatalf("AddTool(%s) failed: %v", name, err)
+		}
+	}
+	// A tool without function declarations, like google_search, is left
+	// as it is.
+	req.Config.Tools = append([]*genai.Tool{{GoogleSearch: &genai.GoogleSearch{}}}, req.Config.Tools...)
+	if err := llmrequest.AddTool(req, fakeTool{"c"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*genai.Tool{
+		{GoogleSearch: &genai.GoogleSearch{}},
+		{FunctionDeclarations: []*genai.FunctionDeclaration{
+			{Name: "a", Description: "Does a."},
+			{Name: "b", Description: "Does b."},
+			{Name: "c", Description: "Does c."},
+		}},
+	}
+	if diff := cmp.Diff(want, req.Config.Tools); diff != "" {
+		t.Errorf("tools mismatch (-want +got):\n%s", diff)
+	}
+	if _, ok := req.Tools["c"].(fakeTool); !ok {
+		t.Errorf("req.Tools[c] = %v, want the tool", req.Tools["c"])
+	}
+	if err := llmrequest.AddTool(req, fakeTool{"a"}); err == nil {
+		t.Error("AddTool() of a duplicate name succeeded, want an error")
+	}
+}
+
+func TestAppendInstructions(t *testing.T) {
+	req := &model.LLMRequest{}
+	llmrequest.AppendInstructions(req, "Be brief.")
+	llmrequest.AppendInstructions(req, "Be kind.")
+	want := genai.NewContentFromParts([]*genai.Part{genai.NewPartFromText("Be brief."), genai.NewPartFromText("Be kind.")}, genai.RoleUser)
+	if diff := cmp.Diff(want, req.Config.SystemInstruction); diff != "" {
+		t.Errorf("system instruction mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package localsession provides the session.Session value and the state
+// scoping rules shared by the session services in this module, so that they
+// behave like session.InMemoryService.
+//
+// State keys prefixed with session.KeyPrefixApp are shared by every session
+// of an app, keys prefixed with session.KeyPrefixUser by every session of a
+// user, and keys prefixed with session.KeyPrefixTemp are never persisted.
+package localsession
+
+import (
+	"iter"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/session"
+)
+
+// Session is a session.Session held in memory. It is safe for concurrent
+// use.
+type Session struct {
+	appName string
+	userID  string
+	id      string
+
+	mu        sync.RWMutex
+	state     map[string]any
+	events    []*session.Event
+	updatedAt time.Time
+}
+
+// New returns a session that takes ownership of state and events.
+func New(appName, userID, id string, state map[string]any, events []*session.Event, updatedAt time.Time) *Session {
+	if state == nil {
+		state = map[string]any{}
+	}
+	return &Session{
+		appName:   appName,
+		userID:    userID,
+		id:        id,
+		state:     state,
+		events:    events,
+		updatedAt: updatedAt,
+	}
+}
+
+func (s *Session) ID() string      { return s.id }
+func (s *Session) AppName() string { return s.appName }
+func (s *Session) UserID() string  { return s.userID }
+
+func (s *Session) State() session.State {
+	return &state{mu: &s.mu, m: s.state}
+}
+
+func (s *Session) Events() session.Events {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return events(s.events)
+}
+
+func (s *Session) LastUpdateTime() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.updatedAt
+}
+
+// Append adds e to the session the way session.InMemoryService does: it
+// drops the temporary keys from e's state delta, applies the rest to the
+// session state and makes e's timestamp the last update time. Partial
+// events are ignored.
+func (s *Session) Append(e *session.Event) {
+	if e.Partial {
+		return
+	}
+	TrimTempDelta(e)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	maps.Copy(s.state, e.Actions.StateDelta)
+	s.events = append(s.events, e)
+	s.updatedAt = e.Timestamp
+}
+
+// TrimTempDelta replaces e's state delta with a copy without the temporary
+// keys. The original map is left alone, as callers may still hold it.
+func TrimTempDelta(e *session.Event) {
+	if len(e.Actions.StateDelta) == 0 {
+		return
+	}
+	trimmed := make(map[string]any, len(e.Actions.StateDelta))
+	for k, v := range e.Actions.StateDelta {
+		if !strings.HasPrefix(k, session.KeyPrefixTemp) {
+			trimmed[k] = v
+		}
+	}
+	e.Actions.StateDelta = trimmed
+}
+
+// SplitState splits a state or state delta by scope. The app and user maps
+// are keyed without their prefix; temporary keys are dropped.
+func SplitState(delta map[string]any) (app, user, sess map[string]any) {
+	app, user, sess = map[string]any{}, map[string]any{}, map[string]any{}
+	for k, v := range delta {
+		if key, ok := strings.CutPrefix(k, session.KeyPrefixApp); ok {
+			app[key] = v
+		} else if key, ok := strings.CutPrefix(k, session.KeyPrefixUser); ok {
+			user[key] = v
+		} else if !strings.HasPrefix(k, session.KeyPrefixTemp) {
+			sess[k] = v
+		}
+	}
+	return app, user, sess
+}
+
+// MergeState is the inverse of SplitState: it returns the state a session
+// sees, with the app and user keys prefixed again.
+func MergeState(app, user, sess map[string]any) map[string]any {
+	merged := make(map[string]any, len(app)+len(user)+len(sess))
+	maps.Copy(merged, sess)
+	for k, v := range app {
+		merged[session.KeyPrefixApp+k] = v
+	}
+	for k, v := range user {
+		merged[session.KeyPrefixUser+k] = v
+	}
+	return merged
+}
+
+// Replay returns the session-scoped state that results from applying the
+// state deltas of events, in order, to initial. App, user and temporary keys
+// are skipped: they are not part of one session's own state.
+func Replay(initial map[string]any, events []*session.Event) map[string]any {
+	_, _, state := SplitState(initial)
+	for _, e := range events {
+		if e.Partial {
+			continue
+		}
+		_, _, delta := SplitState(e.Actions.StateDelta)
+		maps.Copy(state, delta)
+	}
+	return state
+}
+
+// FilterEvents applies the NumRecentEvents and After options of req to
+// events, which must be in append order, as session.InMemoryService does.
+func FilterEvents(events []*session.Event, req *session.GetRequest) []*session.Event {
+	if req.NumRecentEvents > 0 {
+		events = events[max(len(events)-req.NumRecentEvents, 0):]
+	}
+	if !req.After.IsZero() {
+		i := sort.Search(len(events), func(i int) bool {
+			return !events[i].Timestamp.Before(req.After)
+		})
+		events = events[i:]
+	}
+	return events
+}
+
+// Events returns a session.Events over events.
+func Events(e []*session.Event) session.Events {
+	return events(e)
+}
+
+type events []*session.Event
+
+func (e events) All() iter.Seq[*session.Event] {
+	return func(yield func(*session.Event) bool) {
+		for _, ev := range e {
+			if !yield(ev) {
+				return
+			}
+		}
+	}
+}
+
+func (e events) Len() int { return len(e) }
+
+func (e events) At(i int) *session.Event {
+	if i >= 0 && i < len(e) {
+		return e[i]
+	}
+	return nil
+}
+
+type state struct {
+	mu *sync.RWMutex
+	m  map[string]any
+}
+
+func (s *state) Get(key string) (any, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.m[key]
+	if !ok {
+		return nil, session.ErrStateKeyNotExist
+	}
+	return v, nil
+}
+
+func (s *state) Set(key string, value any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[key] = value
+	return nil
+}
+
+func (s *state) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		s.mu.RLock()
+		snapshot := maps.Clone(s.m)
+		s.mu.RUnlock()
+		for k, v := range snapshot {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
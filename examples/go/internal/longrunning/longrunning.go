@@ -0,0 +1,244 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package longrunning tracks the calls of long-running tools until they
+// complete, after the run that made them has ended.
+//
+// A Tracker records an Operation for each call of the tools it wraps,
+// keyed by the function call ID, along with the session of the call:
+//
+//	tracker, err := longrunning.New(longrunning.Config{SessionService: sessionService, Store: store})
+//	ticketTool, err := tracker.Track(createTicketTool)
+//	a, err := llmagent.New(llmagent.Config{
+//		Name:                 "ticket_agent",
+//		Model:                model,
+//		Tools:                []tool.Tool{ticketTool},
+//		BeforeModelCallbacks: []llmagent.BeforeModelCallback{tracker.BeforeModel},
+//	})
+//
+// The code doing the work later reports on the call by its ID, even after a
+// restart when the store and the session service are persistent. Progress
+// and Complete append a function response for the call to its session, as
+// the user:
+//
+//	err := tracker.Progress(ctx, callID, map[string]any{"status": "in review"})
+//	err = tracker.Complete(ctx, callID, map[string]any{"status": "approved", "ticket_id": ticketID})
+//
+// The model sees the latest response of the call in place of the first
+// one on the next turn. To have the agent answer right away, run it with no
+// new message:
+//
+//	op, err := tracker.Operation(ctx, callID)
+//	err = tracker.Complete(ctx, callID, result)
+//	for ev, err := range r.Run(ctx, op.UserID, op.SessionID, nil, agent.RunConfig{}) {
+//
+// The operation is only recorded once the tool has returned, so Progress
+// and Complete return ErrNotFound for a call whose tool is still running;
+// report on it after that.
+//
+// Until a call completes, BeforeModel lists it in the system instruction,
+// so that the model knows what it is still waiting for.
+package longrunning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/llmrequest"
+)
+
+// ErrNotFound is returned for a call that is not tracked, or no longer.
+var ErrNotFound = errors.New("operation not found")
+
+// Operation is a call of a long-running tool that has not completed.
+type Operation struct {
+	// CallID is the function call ID of the call.
+	CallID string `json:"callId"`
+	// Tool is the name of the tool called, and Args its arguments.
+	Tool string         `json:"tool"`
+	Args map[string]any `json:"args,omitempty"`
+	// Result is the response of the tool when it was called, such as the
+	// ID of a ticket.
+	Result map[string]any `json:"result,omitempty"`
+	// Progress is the latest progress reported, if any.
+	Progress map[string]any `json:"progress,omitempty"`
+	// AppName, UserID and SessionID identify the session of the call.
+	AppName   string `json:"appName"`
+	UserID    string `json:"userId"`
+	SessionID string `json:"sessionId"`
+	// Started is when the tool was called, and Updated when the operation
+	// last changed.
+	Started time.Time `json:"started"`
+	Updated time.Time `json:"updated"`
+}
+
+// Store keeps the operations of a Tracker.
+type Store interface {
+	// Put stores op, replacing the operation with the same call ID.
+	Put(ctx context.Context, op Operation) error
+	// Get returns the operation with the given call ID, or ErrNotFound.
+	Get(ctx context.Context, callID string) (Operation, error)
+	// Delete removes the operation with the given call ID, or returns
+	// ErrNotFound. Of several calls removing the same operation, even from
+	// several processes, only one succeeds.
+	Delete(ctx context.Context, callID string) error
+	// List returns the operations of a session, oldest first.
+	List(ctx context.Context, appName, userID, sessionID string) ([]Operation, error)
+}
+
+// Config configures a Tracker.
+type Config struct {
+	// SessionService keeps the sessions of the calls. It is required.
+	SessionService session.Service
+	// Store keeps the operations. It defaults to a MemoryStore; use a
+	// FileStore to keep them across restarts, or share them between
+	// processes.
+	Store Store
+}
+
+// Tracker tracks the calls of long-running tools.
+type Tracker struct {
+	cfg Config
+
+	mu sync.Mutex // serializes the reports on the calls
+}
+
+// New returns a Tracker.
+func New(cfg Config) (*Tracker, error) {
+	if cfg.SessionService == nil {
+		return nil, errors.New("a session service must be set")
+	}
+	if cfg.Store == nil {
+		cfg.Store = &MemoryStore{}
+	}
+	return &Tracker{cfg: cfg}, nil
+}
+
+// Operation returns the operation of the call with the given ID, or
+// ErrNotFound.
+func (t *Tracker) Operation(ctx context.Context, callID string) (Operation, error) {
+	return t.cfg.Store.Get(ctx, callID)
+}
+
+// Pending returns the operations of a session, oldest first.
+func (t *Tracker) Pending(ctx context.Context, appName, userID, sessionID string) ([]Operation, error) {
+	return t.cfg.Store.List(ctx, appName, userID, sessionID)
+}
+
+// Progress reports progress on the call with the given ID: it appends a
+// response to its session telling that the call continues, and keeps
+// progress in the operation.
+func (t *Tracker) Progress(ctx context.Context, callID string, progress map[string]any) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	op, err := t.cfg.Store.Get(ctx, callID)
+	if err != nil {
+		return err
+	}
+	if err := t.respond(ctx, op, progress, true); err != nil {
+		return err
+	}
+	op.Progress = maps.Clone(progress)
+	op.Updated = time.Now()
+	return t.cfg.Store.Put(ctx, op)
+}
+
+// Complete reports the result of the call with the given ID: it forgets the
+// operation, and appends the final response to its session. Only one of
+// several Complete calls for the same call succeeds, even from several
+// processes sharing a FileStore; the others return ErrNotFound. If the
+// response cannot be appended, the operation is stored again, so that the
+// call can be completed later, but if the process stops in between, the
+// call is forgotten without a final response.
+func (t *Tracker) Complete(ctx context.Context, callID string, result map[string]any) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	op, err := t.cfg.Store.Get(ctx, callID)
+	if err != nil {
+		return err
+	}
+	// Deleting first claims the call, so that it gets one final response.
+	if err := t.cfg.Store.Delete(ctx, callID); err != nil {
+		return err
+	}
+	if err := t.respond(ctx, op, result, false); err != nil {
+		if perr := t.cfg.Store.Put(ctx, op); perr != nil {
+			return errors.Join(err, fmt.Errorf("failed to restore operation: %w", perr))
+		}
+		return err
+	}
+	return nil
+}
+
+// respond appends a response of the call of op to its session.
+func (t *Tracker) respond(ctx context.Context, op Operation, response map[string]any, willContinue bool) error {
+	resp, err := t.cfg.SessionService.Get(ctx, &session.GetRequest{AppName: op.AppName, UserID: op.UserID, SessionID: op.SessionID})
+	if err != nil {
+		return fmt.Errorf("failed to get session of call %s: %w", op.CallID, err)
+	}
+	if response == nil {
+		response = map[string]any{}
+	}
+	ev := session.NewEvent("e-" + uuid.NewString())
+	ev.Author = "user"
+	ev.Content = genai.NewContentFromParts([]*genai.Part{{FunctionResponse: &genai.FunctionResponse{
+		ID:           op.CallID,
+		Name:         op.Tool,
+		Response:     response,
+		WillContinue: &willContinue,
+	}}}, genai.RoleUser)
+	if err := t.cfg.SessionService.AppendEvent(ctx, resp.Session, ev); err != nil {
+		return fmt.Errorf("failed to append response of call %s: %w", op.CallID, err)
+	}
+	return nil
+}
+
+// BeforeModel is a BeforeModelCallback that tells the model about the calls
+// of the session that have not completed.
+func (t *Tracker) BeforeModel(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+	ops, err := t.cfg.Store.List(ctx, ctx.AppName(), ctx.UserID(), ctx.SessionID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list operations: %w", err)
+	}
+	if len(ops) == 0 {
+		return nil, nil
+	}
+	var b strings.Builder
+	b.WriteString("These long-running operations have not completed yet. Do not start them again; tell the user they are in progress if asked:\n")
+	for _, op := range ops {
+		fmt.Fprintf(&b, "- %s (call %s), started %s", op.Tool, op.CallID, op.Started.UTC().Format(time.RFC3339))
+		if len(op.Args) > 0 {
+			fmt.Fprintf(&b, ", arguments: %s", encode(op.Args))
+		}
+		if len(op.Progress) > 0 {
+			fmt.Fprintf(&b, ", latest progress: %s", encode(op.Progress))
+		} else if len(op.Result) > 0 {
+			fmt.Fprintf(&b, ", initial response: %s", encode(op.Result))
+		}
+		b.WriteString("\n")
+	}
+	llmrequest.AppendInstructions(req, b.String())
+	return nil, nil
+}
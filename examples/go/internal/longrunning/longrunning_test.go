@@ -0,0 +1,477 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package longrunning_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/fakellm"
+	"github.com/google/adk-docs/examples/go/internal/longrunning"
+)
+
+type ticketArgs struct {
+	Urgency string `json:"urgency"`
+}
+
+func ticketTool(t *testing.T) tool.Tool {
+	t.Helper()
+	tl, err := functiontool.New(functiontool.Config{Name: "create_ticket", Description: "Creates a support ticket."},
+		func(ctx tool.Context, args ticketArgs) map[string]any {
+			return map[string]any{"status": "started", "ticket_id": "TICKET-" + ctx.FunctionCallID()}
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tl
+}
+
+// newTracker returns a tracker of the operations in store, or in memory
+// if store is nil.
+func newTracker(t *testing.T, sessions session.Service, store longrunning.Store) *longrunning.Tracker {
+	t.Helper()
+	tracker, err := longrunning.New(longrunning.Config{SessionService: sessions, Store: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tracker
+}
+
+// ticketAgent returns a runner of an agent with the ticket tool, tracked by
+// tracker, playing back turns, and its model.
+func ticketAgent(t *testing.T, tracker *longrunning.Tracker, sessions session.Service, turns ...fakellm.Turn) (*runner.Runner, *fakellm.Model) {
+	t.Helper()
+	llm := fakellm.New("fake", turns...)
+	tracked, err := tracker.Track(ticketTool(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := llmagent.New(llmagent.Config{
+		Name:                 "ticket_agent",
+		Model:                llm,
+		Tools:                []tool.Tool{tracked},
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{tracker.BeforeModel},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, llm
+}
+
+// newSession creates a session in sessions and returns its ID.
+func newSession(t *testing.T, sessions session.Service) string {
+	t.Helper()
+	resp, err := sessions.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Session.ID()
+}
+
+// run runs r in the session with msg, which may be nil.
+func run(t *testing.T, r *runner.Runner, sessionID string, msg *genai.Content) {
+	t.Helper()
+	for _, err := range r.Run(t.Context(), "user", sessionID, msg, agent.RunConfig{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// systemInstruction returns the text of the system instruction of req.
+func systemInstruction(req *model.LLMRequest) string {
+	if req.Config == nil || req.Config.SystemInstruction == nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range req.Config.SystemInstruction.Parts {
+		texts = append(texts, p.Text)
+	}
+	return strings.Join(texts, "\n")
+}
+
+// responses returns the function responses sent to the model in req.
+func responses(req *model.LLMRequest) []map[string]any {
+	var rs []map[string]any
+	for _, c := range req.Contents {
+		for _, p := range c.Parts {
+			if p.FunctionResponse != nil {
+				rs = append(rs, p.FunctionResponse.Response)
+			}
+		}
+	}
+	return rs
+}
+
+func TestComplete(t *testing.T) {
+	sessions := session.InMemoryService()
+	tracker := newTracker(t, sessions, nil)
+	r, llm := ticketAgent(t, tracker, sessions,
+		fakellm.Call(&genai.FunctionCall{Name: "create_ticket", Args: map[string]any{"urgency": "high"}}),
+		fakellm.Text("Your ticket is being created."),
+		fakellm.Text("It is still in review."),
+		fakellm.Text("Your ticket was approved."),
+	)
+	id := newSession(t, sessions)
+	ctx := t.Context()
+	run(t, r, id, genai.NewContentFromText("Create a high urgency ticket.", genai.RoleUser))
+
+	ops, err := tracker.Pending(ctx, "app", "user", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 1 {
+		t.Fatalf("Pending() = %v, want one operation", ops)
+	}
+	op := ops[0]
+	want := longrunning.Operation{
+		CallID:    "fakellm-call-1",
+		Tool:      "create_ticket",
+		Args:      map[string]any{"urgency": "high"},
+		Result:    map[string]any{"status": "started", "ticket_id": "TICKET-fakellm-call-1"},
+		AppName:   "app",
+		UserID:    "user",
+		SessionID: id,
+		Started:   op.Started,
+		Updated:   op.Updated,
+	}
+	if diff := cmp.Diff(want, op); diff != "" {
+		t.Errorf("operation mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := tracker.Progress(ctx, op.CallID, map[string]any{"status": "in review"}); err != nil {
+		t.Fatal(err)
+	}
+	run(t, r, id, genai.NewContentFromText("How is my ticket?", genai.RoleUser))
+	req := llm.Requests()[2]
+	if got := systemInstruction(req); !strings.Contains(got, "create_ticket (call fakellm-call-1)") || !strings.Contains(got, `latest progress: {"status":"in review"}`) {
+		t.Errorf("system instruction = %q, want the pending call and its progress", got)
+	}
+	if diff := cmp.Diff([]map[string]any{{"status": "in review"}}, responses(req)); diff != "" {
+		t.Errorf("function responses mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := tracker.Complete(ctx, op.CallID, map[string]any{"status": "approved", "ticket_id": "TICKET-1"}); err != nil {
+		t.Fatal(err)
+	}
+	run(t, r, id, nil)
+	req = llm.Requests()[3]
+	if got := systemInstruction(req); strings.Contains(got, "create_ticket") {
+		t.Errorf("system instruction = %q, want no pending call", got)
+	}
+	if diff := cmp.Diff([]map[string]any{{"status": "approved", "ticket_id": "TICKET-1"}}, responses(req)); diff != "" {
+		t.Errorf("function responses mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := tracker.Operation(ctx, op.CallID); !errors.Is(err, longrunning.ErrNotFound) {
+		t.Errorf("Operation() after Complete() = %v, want ErrNotFound", err)
+	}
+	if err := tracker.Complete(ctx, op.CallID, nil); !errors.Is(err, longrunning.ErrNotFound) {
+		t.Errorf("Complete() twice = %v, want ErrNotFound", err)
+	}
+	if llm.Remaining() != 0 {
+		t.Errorf("%d turns left", llm.Remaining())
+	}
+
+	resp, err := sessions.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var willContinue []bool
+	for ev := range resp.Session.Events().All() {
+		if ev.Author != "user" || ev.Content == nil {
+			continue
+		}
+		for _, p := range ev.Content.Parts {
+			if fr := p.FunctionResponse; fr != nil && fr.ID == op.CallID && fr.WillContinue != nil {
+				willContinue = append(willContinue, *fr.WillContinue)
+			}
+		}
+	}
+	if diff := cmp.Diff([]bool{true, false}, willContinue); diff != "" {
+		t.Errorf("WillContinue of the responses mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDeclaration(t *testing.T) {
+	sessions := session.InMemoryService()
+	r, llm := ticketAgent(t, newTracker(t, sessions, nil), sessions, fakellm.Text("Hello."))
+	run(t, r, newSession(t, sessions), genai.NewContentFromText("Hi.", genai.RoleUser))
+	decls := llm.Requests()[0].Config.Tools[0].FunctionDeclarations
+	if len(decls) != 1 || !strings.HasPrefix(decls[0].Description, "Creates a support ticket.\n\nNOTE: This is a long-running operation.") {
+		t.Errorf("declarations = %+v, want the tool noted as long-running", decls)
+	}
+	if got := systemInstruction(llm.Requests()[0]); strings.Contains(got, "long-running operations") {
+		t.Errorf("system instruction = %q, want no pending call", got)
+	}
+}
+
+func TestFailedCall(t *testing.T) {
+	sessions := session.InMemoryService()
+	tracker := newTracker(t, sessions, nil)
+	// The arguments do not match those of the tool, so the call fails.
+	r, _ := ticketAgent(t, tracker, sessions,
+		fakellm.Call(&genai.FunctionCall{Name: "create_ticket", Args: map[string]any{"urgency": 1}}),
+		fakellm.Text("Sorry."),
+	)
+	for range r.Run(t.Context(), "user", newSession(t, sessions), genai.NewContentFromText("Create a ticket.", genai.RoleUser), agent.RunConfig{}) {
+	}
+	if _, err := tracker.Operation(t.Context(), "fakellm-call-1"); !errors.Is(err, longrunning.ErrNotFound) {
+		t.Errorf("Operation() of a failed call = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "operations.jsonl")
+	store, err := longrunning.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := session.InMemoryService()
+	r, _ := ticketAgent(t, newTracker(t, sessions, store), sessions,
+		fakellm.Call(&genai.FunctionCall{Name: "create_ticket", Args: map[string]any{"urgency": "low"}}),
+		fakellm.Text("Your ticket is being created."),
+	)
+	id := newSession(t, sessions)
+	ctx := t.Context()
+	run(t, r, id, genai.NewContentFromText("Create a low urgency ticket.", genai.RoleUser))
+
+	// A process that restarts finds the operation, and completes it.
+	store, err = longrunning.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker := newTracker(t, sessions, store)
+	op, err := tracker.Operation(ctx, "fakellm-call-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op.SessionID != id || op.Result["ticket_id"] != "TICKET-fakellm-call-1" {
+		t.Errorf("Operation() = %+v, want the call of session %s", op, id)
+	}
+	if err := tracker.Complete(ctx, op.CallID, map[string]any{"status": "approved"}); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = longrunning.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops, err := store.List(ctx, "app", "user", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 0 {
+		t.Errorf("List() after Complete() = %v, want none", ops)
+	}
+}
+
+// hookedAppends is a session service that calls before ahead of appending
+// an event.
+type hookedAppends struct {
+	session.Service
+	before func() error
+}
+
+func (s hookedAppends) AppendEvent(ctx context.Context, sess session.Session, e *session.Event) error {
+	if err := s.before(); err != nil {
+		return err
+	}
+	return s.Service.AppendEvent(ctx, sess, e)
+}
+
+// TestCompleteOnce completes a call from two trackers sharing a FileStore,
+// as two processes would, the second while the first appends the response,
+// after a first attempt that fails.
+func TestCompleteOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "operations.jsonl")
+	open := func() *longrunning.FileStore {
+		store, err := longrunning.OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}
+	sessions := session.InMemoryService()
+	r, _ := ticketAgent(t, newTracker(t, sessions, open()), sessions,
+		fakellm.Call(&genai.FunctionCall{Name: "create_ticket", Args: map[string]any{"urgency": "low"}}),
+		fakellm.Text("Your ticket is being created."),
+	)
+	id := newSession(t, sessions)
+	ctx := t.Context()
+	run(t, r, id, genai.NewContentFromText("Create a low urgency ticket.", genai.RoleUser))
+	result := map[string]any{"status": "approved"}
+
+	failing := newTracker(t, hookedAppends{sessions, func() error { return errors.New("session store unavailable") }}, open())
+	if err := failing.Complete(ctx, "fakellm-call-1", result); err == nil {
+		t.Fatal("Complete() failing to append succeeded, want an error")
+	}
+	other := newTracker(t, sessions, open())
+	if _, err := other.Operation(ctx, "fakellm-call-1"); err != nil {
+		t.Fatalf("Operation() after a failed Complete() = %v, want it kept", err)
+	}
+
+	var otherErr error
+	first := newTracker(t, hookedAppends{sessions, func() error {
+		otherErr = other.Complete(ctx, "fakellm-call-1", result)
+		return nil
+	}}, open())
+	if err := first.Complete(ctx, "fakellm-call-1", result); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(otherErr, longrunning.ErrNotFound) {
+		t.Errorf("Complete() during another Complete() = %v, want ErrNotFound", otherErr)
+	}
+
+	resp, err := sessions.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	final := 0
+	for e := range resp.Session.Events().All() {
+		if e.Content == nil {
+			continue
+		}
+		for _, p := range e.Content.Parts {
+			if fr := p.FunctionResponse; fr != nil && fr.WillContinue != nil && !*fr.WillContinue {
+				final++
+			}
+		}
+	}
+	if final != 1 {
+		t.Errorf("session has %d final responses, want 1", final)
+	}
+}
+
+func TestStores(t *testing.T) {
+	for name, open := range map[string]func(t *testing.T) longrunning.Store{
+		"memory": func(t *testing.T) longrunning.Store { return &longrunning.MemoryStore{} },
+		"file": func(t *testing.T) longrunning.Store {
+			store, err := longrunning.OpenFile(filepath.Join(t.TempDir(), "operations.jsonl"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return store
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := t.Context()
+			store := open(t)
+			now := time.Now()
+			ops := []longrunning.Operation{
+				{CallID: "b", Tool: "create_ticket", AppName: "app", UserID: "user", SessionID: "s1", Started: now},
+				{CallID: "a", Tool: "create_ticket", AppName: "app", UserID: "user", SessionID: "s1", Started: now.Add(time.Second)},
+				{CallID: "c", Tool: "create_ticket", AppName: "app", UserID: "user", SessionID: "s2", Started: now},
+			}
+			for _, op := range ops {
+				if err := store.Put(ctx, op); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := store.Put(ctx, longrunning.Operation{}); err == nil {
+				t.Error("Put() of an operation without a call ID succeeded, want an error")
+			}
+			got, err := store.List(ctx, "app", "user", "s1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(ops[:2], got, cmpopts.EquateApproxTime(0)); diff != "" {
+				t.Errorf("List() mismatch (-want +got):\n%s", diff)
+			}
+			if err := store.Delete(ctx, "b"); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Delete(ctx, "b"); !errors.Is(err, longrunning.ErrNotFound) {
+				t.Errorf("Delete() of a missing operation = %v, want ErrNotFound", err)
+			}
+			if _, err := store.Get(ctx, "b"); !errors.Is(err, longrunning.ErrNotFound) {
+				t.Errorf("Get() after Delete() = %v, want ErrNotFound", err)
+			}
+			if op, err := store.Get(ctx, "c"); err != nil || op.SessionID != "s2" {
+				t.Errorf("Get(c) = %+v, %v, want the operation of s2", op, err)
+			}
+		})
+	}
+}
+
+// TestFileStoreShared opens the same file twice, as two processes would:
+// each store sees the changes of the other, and none is lost.
+func TestFileStoreShared(t *testing.T) {
+	ctx := t.Context()
+	path := filepath.Join(t.TempDir(), "operations.jsonl")
+	var stores []*longrunning.FileStore
+	for range 2 {
+		store, err := longrunning.OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stores = append(stores, store)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			op := longrunning.Operation{CallID: fmt.Sprintf("call-%02d", i), AppName: "app", UserID: "user", SessionID: "s1"}
+			if err := stores[i%2].Put(ctx, op); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	for i, store := range stores {
+		ops, err := store.List(ctx, "app", "user", "s1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ops) != 20 {
+			t.Errorf("store %d lists %d operations, want 20", i, len(ops))
+		}
+	}
+
+	if err := stores[0].Delete(ctx, "call-01"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stores[1].Get(ctx, "call-01"); !errors.Is(err, longrunning.ErrNotFound) {
+		t.Errorf("Get() of an operation the other store deleted = %v, want ErrNotFound", err)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	if _, err := longrunning.New(longrunning.Config{}); err == nil {
+		t.Error("New() without a session service succeeded, want an error")
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package longrunning
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/google/adk-docs/examples/go/internal/filelock"
+)
+
+// MemoryStore keeps the operations in memory, for one process. It is the
+// default store of a Tracker. The zero value is an empty store.
+type MemoryStore struct {
+	mu  sync.RWMutex
+	ops map[string]Operation // by call ID
+}
+
+func (s *MemoryStore) Put(ctx context.Context, op Operation) error {
+	if op.CallID == "" {
+		return errors.New("operation has no call ID")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ops == nil {
+		s.ops = map[string]Operation{}
+	}
+	s.ops[op.CallID] = op
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, callID string) (Operation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	op, ok := s.ops[callID]
+	if !ok {
+		return Operation{}, fmt.Errorf("call %s: %w", callID, ErrNotFound)
+	}
+	return op, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, callID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.ops[callID]; !ok {
+		return fmt.Errorf("call %s: %w", callID, ErrNotFound)
+	}
+	delete(s.ops, callID)
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, appName, userID, sessionID string) ([]Operation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return list(s.ops, appName, userID, sessionID), nil
+}
+
+// FileStore keeps the operations in a JSON Lines file, one operation per
+// line. Every call reads the file under a lock on the file at its path
+// with ".lock" appended, and every change writes it anew, so several
+// processes may share the file, except on platforms where filelock only
+// holds within a process. Operations are few and short-lived, so the file
+// stays small.
+type FileStore struct {
+	path string
+}
+
+// OpenFile returns the store of the operations in the file at path,
+// checking that the file, if it exists, can be read. The file is created
+// on the first change.
+func OpenFile(path string) (*FileStore, error) {
+	s := &FileStore{path: path}
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if _, err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) Put(ctx context.Context, op Operation) error {
+	if op.CallID == "" {
+		return errors.New("operation has no call ID")
+	}
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	ops, err := s.load()
+	if err != nil {
+		return err
+	}
+	ops[op.CallID] = op
+	return s.save(ops)
+}
+
+func (s *FileStore) Get(ctx context.Context, callID string) (Operation, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return Operation{}, err
+	}
+	defer unlock()
+	ops, err := s.load()
+	if err != nil {
+		return Operation{}, err
+	}
+	op, ok := ops[callID]
+	if !ok {
+		return Operation{}, fmt.Errorf("call %s: %w", callID, ErrNotFound)
+	}
+	return op, nil
+}
+
+func (s *FileStore) Delete(ctx context.Context, callID string) error {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	ops, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := ops[callID]; !ok {
+		return fmt.Errorf("call %s: %w", callID, ErrNotFound)
+	}
+	delete(ops, callID)
+	return s.save(ops)
+}
+
+func (s *FileStore) List(ctx context.Context, appName, userID, sessionID string) ([]Operation, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	ops, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return list(ops, appName, userID, sessionID), nil
+}
+
+func (s *FileStore) lock(exclusive bool) (unlock func(), err error) {
+	return filelock.Lock(s.path+".lock", exclusive)
+}
+
+// load reads the operations in the file, by call ID. A missing file has
+// none.
+func (s *FileStore) load() (map[string]Operation, error) {
+	ops := map[string]Operation{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ops, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, 64<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var op Operation
+		if err := json.Unmarshal(sc.Bytes(), &op); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", s.path, line, err)
+		}
+		ops[op.CallID] = op
+	}
+	return ops, sc.Err()
+}
+
+// save writes ops to a temporary file and renames it over the file, so
+// that a crash leaves either the old or the new operations.
+func (s *FileStore) save(ops map[string]Operation) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, op := range sorted(ops) {
+		if err := enc.Encode(op); err != nil {
+			return err
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// list returns the operations of a session, oldest first.
+func list(ops map[string]Operation, appName, userID, sessionID string) []Operation {
+	var out []Operation
+	for _, op := range sorted(ops) {
+		if op.AppName == appName && op.UserID == userID && op.SessionID == sessionID {
+			out = append(out, op)
+		}
+	}
+	return out
+}
+
+// sorted returns the operations, oldest first.
+func sorted(ops map[string]Operation) []Operation {
+	s := slices.Collect(maps.Values(ops))
+	slices.SortFunc(s, func(a, b Operation) int {
+		return cmp.Or(a.Started.Compare(b.Started), cmp.Compare(a.CallID, b.CallID))
+	})
+	return s
+}
+
+var (
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*FileStore)(nil)
+)
@@ -0,0 +1,113 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package longrunning
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/llmrequest"
+)
+
+// longRunningNote is the note that functiontool adds to the description of
+// its long-running tools.
+const longRunningNote = "NOTE: This is a long-running operation. Do not call this tool again if it has already returned some intermediate or pending status."
+
+// functionTool is a tool the agent calls with its Run method.
+type functionTool interface {
+	tool.Tool
+	Declaration() *genai.FunctionDeclaration
+	Run(ctx tool.Context, args any) (map[string]any, error)
+}
+
+// Track returns t, such as a tool of functiontool, as a long-running tool
+// whose calls the tracker records. The response of t starts the operation,
+// which is recorded once t returns: the work t hands off must not be
+// reported on before. A call that fails is not recorded.
+func (tr *Tracker) Track(t tool.Tool) (tool.Tool, error) {
+	ft, ok := t.(functionTool)
+	if !ok {
+		return nil, fmt.Errorf("tool %q is not a function tool", t.Name())
+	}
+	return &trackedTool{functionTool: ft, tracker: tr}, nil
+}
+
+type trackedTool struct {
+	functionTool
+	tracker *Tracker
+}
+
+func (t *trackedTool) IsLongRunning() bool { return true }
+
+// Declaration returns the declaration of the tool, with the note of
+// functiontool for long-running tools.
+func (t *trackedTool) Declaration() *genai.FunctionDeclaration {
+	decl := t.functionTool.Declaration()
+	if t.functionTool.IsLongRunning() || decl == nil {
+		return decl
+	}
+	d := *decl
+	if d.Description != "" {
+		d.Description += "\n\n" + longRunningNote
+	} else {
+		d.Description = longRunningNote
+	}
+	return &d
+}
+
+// ProcessRequest adds the tool to req.
+func (t *trackedTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	return llmrequest.AddTool(req, t)
+}
+
+// Run runs the tool and records the operation it started.
+func (t *trackedTool) Run(ctx tool.Context, args any) (map[string]any, error) {
+	result, err := t.functionTool.Run(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := args.(map[string]any)
+	now := time.Now()
+	op := Operation{
+		CallID:    ctx.FunctionCallID(),
+		Tool:      t.Name(),
+		Args:      maps.Clone(m),
+		Result:    maps.Clone(result),
+		AppName:   ctx.AppName(),
+		UserID:    ctx.UserID(),
+		SessionID: ctx.SessionID(),
+		Started:   now,
+		Updated:   now,
+	}
+	if err := t.tracker.cfg.Store.Put(ctx, op); err != nil {
+		return nil, fmt.Errorf("failed to record operation: %w", err)
+	}
+	return result, nil
+}
+
+// encode returns v as JSON, for the model.
+func encode(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,199 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcpserve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/google/uuid"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// toolContext is the tool.Context of a tool called over MCP, in the session
+// of the client. The changes of the tool are recorded in its actions, and
+// appended to the session by commit.
+type toolContext struct {
+	context.Context
+	s            *server
+	session      session.Session
+	invocationID string
+	callID       string
+	actions      *session.EventActions
+}
+
+var _ tool.Context = (*toolContext)(nil)
+
+func newToolContext(ctx context.Context, s *server, sess session.Session) *toolContext {
+	return &toolContext{
+		Context:      ctx,
+		s:            s,
+		session:      sess,
+		invocationID: "e-" + uuid.NewString(),
+		callID:       uuid.NewString(),
+		actions:      &session.EventActions{StateDelta: map[string]any{}},
+	}
+}
+
+// commit appends an event with the changes of the tool to the session, if
+// it made any.
+func (c *toolContext) commit() error {
+	if len(c.actions.StateDelta) == 0 && len(c.actions.ArtifactDelta) == 0 {
+		return nil
+	}
+	ev := session.NewEvent(c.invocationID)
+	ev.Author = c.s.cfg.Name
+	ev.Actions = *c.actions
+	if err := c.s.cfg.SessionService.AppendEvent(c, c.session, ev); err != nil {
+		return fmt.Errorf("failed to record the changes of the tool: %w", err)
+	}
+	return nil
+}
+
+func (c *toolContext) UserContent() *genai.Content { return nil }
+func (c *toolContext) InvocationID() string        { return c.invocationID }
+func (c *toolContext) AgentName() string           { return c.s.cfg.Name }
+func (c *toolContext) UserID() string              { return c.session.UserID() }
+func (c *toolContext) AppName() string             { return c.session.AppName() }
+func (c *toolContext) SessionID() string           { return c.session.ID() }
+func (c *toolContext) Branch() string              { return "" }
+func (c *toolContext) FunctionCallID() string      { return c.callID }
+
+func (c *toolContext) Actions() *session.EventActions { return c.actions }
+
+func (c *toolContext) ReadonlyState() session.ReadonlyState { return c.State() }
+
+func (c *toolContext) State() session.State {
+	return &state{base: c.session.State(), delta: c.actions.StateDelta}
+}
+
+func (c *toolContext) Artifacts() agent.Artifacts {
+	return &artifacts{c: c}
+}
+
+func (c *toolContext) SearchMemory(ctx context.Context, query string) (*memory.SearchResponse, error) {
+	if c.s.cfg.MemoryService == nil {
+		return nil, errors.New("memory service is not set")
+	}
+	return c.s.cfg.MemoryService.Search(ctx, &memory.SearchRequest{
+		Query:   query,
+		UserID:  c.session.UserID(),
+		AppName: c.session.AppName(),
+	})
+}
+
+// state is the state of the session as the tool changes it.
+type state struct {
+	base  session.State
+	delta map[string]any
+}
+
+func (s *state) Get(key string) (any, error) {
+	if v, ok := s.delta[key]; ok {
+		return v, nil
+	}
+	return s.base.Get(key)
+}
+
+func (s *state) Set(key string, value any) error {
+	s.delta[key] = value
+	return nil
+}
+
+func (s *state) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for k, v := range s.base.All() {
+			if _, ok := s.delta[k]; ok {
+				continue
+			}
+			if !yield(k, v) {
+				return
+			}
+		}
+		for k, v := range s.delta {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// artifacts are the artifacts of the session. Saves are recorded in the
+// actions of the tool.
+type artifacts struct {
+	c *toolContext
+}
+
+func (a *artifacts) service() (artifact.Service, error) {
+	if a.c.s.cfg.ArtifactService == nil {
+		return nil, errors.New("artifact service is not set")
+	}
+	return a.c.s.cfg.ArtifactService, nil
+}
+
+func (a *artifacts) Save(ctx context.Context, name string, data *genai.Part) (*artifact.SaveResponse, error) {
+	svc, err := a.service()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := svc.Save(ctx, &artifact.SaveRequest{
+		AppName:   a.c.AppName(),
+		UserID:    a.c.UserID(),
+		SessionID: a.c.SessionID(),
+		FileName:  name,
+		Part:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if a.c.actions.ArtifactDelta == nil {
+		a.c.actions.ArtifactDelta = map[string]int64{}
+	}
+	a.c.actions.ArtifactDelta[name] = resp.Version
+	return resp, nil
+}
+
+func (a *artifacts) List(ctx context.Context) (*artifact.ListResponse, error) {
+	svc, err := a.service()
+	if err != nil {
+		return nil, err
+	}
+	return svc.List(ctx, &artifact.ListRequest{AppName: a.c.AppName(), UserID: a.c.UserID(), SessionID: a.c.SessionID()})
+}
+
+func (a *artifacts) Load(ctx context.Context, name string) (*artifact.LoadResponse, error) {
+	return a.LoadVersion(ctx, name, 0)
+}
+
+func (a *artifacts) LoadVersion(ctx context.Context, name string, version int) (*artifact.LoadResponse, error) {
+	svc, err := a.service()
+	if err != nil {
+		return nil, err
+	}
+	return svc.Load(ctx, &artifact.LoadRequest{
+		AppName:   a.c.AppName(),
+		UserID:    a.c.UserID(),
+		SessionID: a.c.SessionID(),
+		FileName:  name,
+		Version:   int64(version),
+	})
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcpserve
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/adk/cmd/launcher"
+	"google.golang.org/adk/cmd/launcher/adk"
+	"google.golang.org/adk/cmd/launcher/web"
+	"google.golang.org/adk/tool"
+)
+
+// Path is the path of the streamable HTTP endpoint of the web sub-launcher.
+const Path = "/mcp"
+
+// mcpLauncher is the common part of the stdio and the web sub-launchers.
+type mcpLauncher struct {
+	flags      *flag.FlagSet // flags are used to parse command-line arguments
+	tools      []tool.Tool
+	serveAgent bool
+}
+
+func newMCPLauncher(tools []tool.Tool) *mcpLauncher {
+	l := &mcpLauncher{tools: tools}
+	l.flags = flag.NewFlagSet("mcp", flag.ContinueOnError)
+	l.flags.BoolVar(&l.serveAgent, "mcp_agent", true, "Serve the root agent as an MCP tool, next to the tools of the launcher.")
+	return l
+}
+
+// Keyword returns the command-line keyword of the MCP launchers.
+func (l *mcpLauncher) Keyword() string {
+	return "mcp"
+}
+
+// Parse parses the MCP flags and returns the remaining arguments.
+func (l *mcpLauncher) Parse(args []string) ([]string, error) {
+	if err := l.flags.Parse(args); err != nil {
+		return nil, fmt.Errorf("failed to parse mcp flags: %v", err)
+	}
+	return l.flags.Args(), nil
+}
+
+// CommandLineSyntax returns the command-line syntax of the MCP launchers.
+func (l *mcpLauncher) CommandLineSyntax() string {
+	var b strings.Builder
+	o := l.flags.Output()
+	l.flags.SetOutput(&b)
+	l.flags.PrintDefaults()
+	l.flags.SetOutput(o)
+	return b.String()
+}
+
+// server returns the server of the tools and, unless disabled, of the root
+// agent of adkConfig.
+func (l *mcpLauncher) server(adkConfig *adk.Config) (*mcp.Server, error) {
+	cfg := Config{
+		Tools:           l.tools,
+		SessionService:  adkConfig.SessionService,
+		ArtifactService: adkConfig.ArtifactService,
+		MemoryService:   adkConfig.MemoryService,
+	}
+	if l.serveAgent && adkConfig.AgentLoader != nil {
+		cfg.Agent = adkConfig.AgentLoader.RootAgent()
+	}
+	return NewServer(cfg)
+}
+
+type stdioLauncher struct {
+	*mcpLauncher
+}
+
+// NewLauncher returns a sub-launcher of the universal launcher that serves
+// the root agent and tools over stdin and stdout, for clients that start
+// the server as a subprocess.
+func NewLauncher(tools ...tool.Tool) launcher.SubLauncher {
+	return &stdioLauncher{newMCPLauncher(tools)}
+}
+
+// SimpleDescription implements launcher.SubLauncher.
+func (l *stdioLauncher) SimpleDescription() string {
+	return "serves the agent and its tools as an MCP server over stdin and stdout"
+}
+
+// Run implements launcher.SubLauncher. It serves until the client
+// disconnects or ctx is done.
+func (l *stdioLauncher) Run(ctx context.Context, config *adk.Config) error {
+	s, err := l.server(config)
+	if err != nil {
+		return err
+	}
+	return s.Run(ctx, &mcp.StdioTransport{})
+}
+
+type webLauncher struct {
+	*mcpLauncher
+}
+
+// NewWebLauncher returns a sub-launcher of the web launcher that serves the
+// root agent and tools over streamable HTTP at Path.
+func NewWebLauncher(tools ...tool.Tool) web.Sublauncher {
+	return &webLauncher{newMCPLauncher(tools)}
+}
+
+// SimpleDescription implements web.Sublauncher.
+func (l *webLauncher) SimpleDescription() string {
+	return fmt.Sprintf("starts an MCP server which handles streamable HTTP requests on %s path", Path)
+}
+
+// SetupSubrouters implements web.Sublauncher. It adds the MCP endpoint to
+// the main router.
+func (l *webLauncher) SetupSubrouters(router *mux.Router, adkConfig *adk.Config) error {
+	s, err := l.server(adkConfig)
+	if err != nil {
+		return err
+	}
+	router.Handle(Path, mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return s }, nil))
+	return nil
+}
+
+// UserMessage implements web.Sublauncher.
+func (l *webLauncher) UserMessage(webURL string, printer func(v ...any)) {
+	printer(fmt.Sprintf("       mcp:  you can access the MCP server using streamable HTTP: %s%s", webURL, Path))
+}
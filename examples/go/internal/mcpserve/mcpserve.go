@@ -0,0 +1,341 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mcpserve serves an agent and function tools as a Model Context
+// Protocol server, so that IDE assistants and other agent frameworks can
+// call them.
+//
+// NewServer returns the server, to run over any MCP transport. NewLauncher
+// and NewWebLauncher plug it into the launchers of the ADK, serving the root
+// agent of the launcher and the given tools over stdio, or over streamable
+// HTTP next to the other web sub-launchers:
+//
+//	l := universal.NewLauncher(
+//		mcpserve.NewLauncher(capitalTool),
+//		web.NewLauncher(api.NewLauncher(), mcpserve.NewWebLauncher(capitalTool)),
+//	)
+//	err := l.Execute(ctx, config, os.Args[1:])
+//
+// The agent is served as a tool named after it, which takes a request in
+// natural language and returns the final response of the agent. Each client
+// talks to the agent in a session of its own, so that the agent remembers
+// the earlier requests of the client.
+//
+// The tools keep their names, descriptions and parameters. They run in the
+// session of the client too: their changes to the state and the artifacts
+// they save are recorded there, as if the agent had called them.
+package mcpserve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// Config configures a server. At least one of Agent and Tools must be set.
+type Config struct {
+	// Name and Version identify the server to its clients. Name is also the
+	// app name of the sessions; it defaults to the name of Agent, or to
+	// "adk".
+	Name    string
+	Version string
+	// Agent, if set, is served as a tool named after it.
+	Agent agent.Agent
+	// Tools are served as tools of their own. Each must be a function tool,
+	// with a declaration and a Run method, such as those of functiontool.
+	Tools []tool.Tool
+	// UserID is the user of the sessions of the clients. It defaults to
+	// "mcp".
+	UserID string
+	// SessionService keeps the sessions. It defaults to an in-memory
+	// service. ArtifactService and MemoryService, if set, are those of the
+	// agent and the tools.
+	SessionService  session.Service
+	ArtifactService artifact.Service
+	MemoryService   memory.Service
+}
+
+// functionTool is a tool that can be called outside of an agent.
+type functionTool interface {
+	tool.Tool
+	Declaration() *genai.FunctionDeclaration
+	Run(ctx tool.Context, args any) (map[string]any, error)
+}
+
+type server struct {
+	cfg    Config
+	runner *runner.Runner
+
+	mu       sync.Mutex
+	sessions map[*mcp.ServerSession]string // IDs of the ADK sessions of the clients
+}
+
+// NewServer returns a server with the agent and the tools of cfg.
+func NewServer(cfg Config) (*mcp.Server, error) {
+	if cfg.Agent == nil && len(cfg.Tools) == 0 {
+		return nil, errors.New("an agent or tools must be set")
+	}
+	if cfg.Name == "" {
+		cfg.Name = "adk"
+		if cfg.Agent != nil {
+			cfg.Name = cfg.Agent.Name()
+		}
+	}
+	if cfg.Version == "" {
+		cfg.Version = "v1.0.0"
+	}
+	if cfg.UserID == "" {
+		cfg.UserID = "mcp"
+	}
+	if cfg.SessionService == nil {
+		cfg.SessionService = session.InMemoryService()
+	}
+	s := &server{cfg: cfg, sessions: map[*mcp.ServerSession]string{}}
+
+	ms := mcp.NewServer(&mcp.Implementation{Name: cfg.Name, Version: cfg.Version}, nil)
+	seen := map[string]bool{}
+	if cfg.Agent != nil {
+		r, err := runner.New(runner.Config{
+			AppName:         cfg.Name,
+			Agent:           cfg.Agent,
+			SessionService:  cfg.SessionService,
+			ArtifactService: cfg.ArtifactService,
+			MemoryService:   cfg.MemoryService,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create runner: %w", err)
+		}
+		s.runner = r
+		ms.AddTool(&mcp.Tool{
+			Name:        cfg.Agent.Name(),
+			Description: cfg.Agent.Description(),
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"request": map[string]any{"type": "string", "description": "The request to the agent, in natural language."},
+				},
+				"required": []string{"request"},
+			},
+		}, s.runAgent)
+		seen[cfg.Agent.Name()] = true
+	}
+	for _, t := range cfg.Tools {
+		ft, ok := t.(functionTool)
+		if !ok {
+			return nil, fmt.Errorf("tool %q is not a function tool", t.Name())
+		}
+		if seen[t.Name()] {
+			return nil, fmt.Errorf("duplicate tool: %q", t.Name())
+		}
+		seen[t.Name()] = true
+		ms.AddTool(&mcp.Tool{
+			Name:        t.Name(),
+			Description: t.Description(),
+			InputSchema: inputSchema(ft.Declaration()),
+		}, func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return s.runTool(ctx, req, ft)
+		})
+	}
+	return ms, nil
+}
+
+// session returns the ID of the ADK session of the client of req, creating
+// it on first use. The server forgets it once the client disconnects; the
+// session itself stays in the session service.
+func (s *server) session(ctx context.Context, req *mcp.CallToolRequest) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id, ok := s.sessions[req.Session]; ok {
+		return id, nil
+	}
+	resp, err := s.cfg.SessionService.Create(ctx, &session.CreateRequest{AppName: s.cfg.Name, UserID: s.cfg.UserID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+	s.sessions[req.Session] = resp.Session.ID()
+	if ss := req.Session; ss != nil {
+		go func() {
+			ss.Wait()
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.sessions, ss)
+		}()
+	}
+	return resp.Session.ID(), nil
+}
+
+// runAgent sends the request of req to the agent, and returns its final
+// response.
+func (s *server) runAgent(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var args struct {
+		Request string `json:"request"`
+	}
+	if err := unmarshalArgs(req, &args); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Request) == "" {
+		return errorResult(errors.New("request is empty")), nil
+	}
+	sessionID, err := s.session(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var reply []string
+	for ev, err := range s.runner.Run(ctx, s.cfg.UserID, sessionID, genai.NewContentFromText(args.Request, genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			return errorResult(fmt.Errorf("agent failed: %w", err)), nil
+		}
+		if ev.Partial || !ev.IsFinalResponse() || ev.Content == nil {
+			continue
+		}
+		for _, p := range ev.Content.Parts {
+			if p.Text != "" && !p.Thought {
+				reply = append(reply, p.Text)
+			}
+		}
+	}
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: strings.Join(reply, "\n")}}}, nil
+}
+
+// runTool runs t with the arguments of req, and records its changes in the
+// session of the client.
+func (s *server) runTool(ctx context.Context, req *mcp.CallToolRequest, t functionTool) (*mcp.CallToolResult, error) {
+	var args map[string]any
+	if err := unmarshalArgs(req, &args); err != nil {
+		return nil, err
+	}
+	if args == nil {
+		args = map[string]any{}
+	}
+	sessionID, err := s.session(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.cfg.SessionService.Get(ctx, &session.GetRequest{AppName: s.cfg.Name, UserID: s.cfg.UserID, SessionID: sessionID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	tc := newToolContext(ctx, s, resp.Session)
+	result, runErr := t.Run(tc, args)
+	if err := tc.commit(); err != nil {
+		return nil, err
+	}
+	if runErr != nil {
+		return errorResult(runErr), nil
+	}
+	text, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		StructuredContent: result,
+	}, nil
+}
+
+// unmarshalArgs decodes the arguments of req into v.
+func unmarshalArgs(req *mcp.CallToolRequest, v any) error {
+	if len(req.Params.Arguments) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(req.Params.Arguments, v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// errorResult reports err to the model of the client, rather than as a
+// protocol error.
+func errorResult(err error) *mcp.CallToolResult {
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: err.Error()}}, IsError: true}
+}
+
+// inputSchema returns the JSON schema of the parameters of decl. MCP wants
+// an object, even for a function without parameters.
+func inputSchema(decl *genai.FunctionDeclaration) any {
+	if decl != nil && decl.ParametersJsonSchema != nil {
+		return decl.ParametersJsonSchema
+	}
+	if decl != nil && decl.Parameters != nil {
+		return jsonSchema(decl.Parameters)
+	}
+	return map[string]any{"type": "object"}
+}
+
+// jsonSchema converts s, in the subset of OpenAPI of genai, to JSON schema.
+func jsonSchema(s *genai.Schema) map[string]any {
+	m := map[string]any{}
+	if s.Type != "" && s.Type != genai.TypeUnspecified {
+		typ := strings.ToLower(string(s.Type))
+		if s.Nullable != nil && *s.Nullable {
+			m["type"] = []string{typ, "null"}
+		} else {
+			m["type"] = typ
+		}
+	}
+	if s.Description != "" {
+		m["description"] = s.Description
+	}
+	if s.Format != "" {
+		m["format"] = s.Format
+	}
+	if len(s.Enum) > 0 {
+		m["enum"] = s.Enum
+	}
+	if s.Items != nil {
+		m["items"] = jsonSchema(s.Items)
+	}
+	if len(s.Properties) > 0 {
+		props := map[string]any{}
+		for name, p := range s.Properties {
+			props[name] = jsonSchema(p)
+		}
+		m["properties"] = props
+	}
+	if len(s.Required) > 0 {
+		m["required"] = s.Required
+	}
+	if len(s.AnyOf) > 0 {
+		var anyOf []any
+		for _, a := range s.AnyOf {
+			anyOf = append(anyOf, jsonSchema(a))
+		}
+		m["anyOf"] = anyOf
+	}
+	if s.Minimum != nil {
+		m["minimum"] = *s.Minimum
+	}
+	if s.Maximum != nil {
+		m["maximum"] = *s.Maximum
+	}
+	if s.MinItems != nil {
+		m["minItems"] = *s.MinItems
+	}
+	if s.MaxItems != nil {
+		m["maxItems"] = *s.MaxItems
+	}
+	return m
+}
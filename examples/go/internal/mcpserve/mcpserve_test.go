@@ -0,0 +1,329 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcpserve_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/gorilla/mux"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/cmd/launcher/adk"
+	"google.golang.org/adk/server/restapi/services"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/fakellm"
+	"github.com/google/adk-docs/examples/go/internal/mcpserve"
+)
+
+type capitalArgs struct {
+	Country string `json:"country" jsonschema:"The country for which to find the capital city."`
+}
+
+type capitalResult struct {
+	Result       string `json:"result,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// capitalTool returns the capital of a few countries, and remembers the last
+// country it was asked about in the state, and as an artifact.
+func capitalTool(t *testing.T) tool.Tool {
+	t.Helper()
+	capitals := map[string]string{"france": "Paris", "japan": "Tokyo"}
+	tl, err := functiontool.New(functiontool.Config{
+		Name:        "get_capital_city",
+		Description: "Retrieves the capital city for a given country.",
+	}, func(ctx tool.Context, args capitalArgs) capitalResult {
+		capital, ok := capitals[strings.ToLower(args.Country)]
+		if !ok {
+			return capitalResult{ErrorMessage: "unknown country " + args.Country}
+		}
+		if err := ctx.State().Set("last_country", args.Country); err != nil {
+			return capitalResult{ErrorMessage: err.Error()}
+		}
+		if _, err := ctx.Artifacts().Save(ctx, "last.txt", genai.NewPartFromText(args.Country)); err != nil {
+			return capitalResult{ErrorMessage: err.Error()}
+		}
+		return capitalResult{Result: capital}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tl
+}
+
+// failTool is a function tool that always fails.
+type failTool struct{}
+
+func (failTool) Name() string        { return "fail" }
+func (failTool) Description() string { return "Always fails." }
+func (failTool) IsLongRunning() bool { return false }
+
+func (failTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{Name: "fail", Description: "Always fails."}
+}
+
+func (failTool) Run(ctx tool.Context, args any) (map[string]any, error) {
+	return nil, errors.New("it failed")
+}
+
+func newAgent(t *testing.T, llm *fakellm.Model) agent.Agent {
+	t.Helper()
+	a, err := llmagent.New(llmagent.Config{
+		Name:        "capital_agent",
+		Description: "Agent to find the capital city of a country.",
+		Model:       llm,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// connect connects a client to s in memory.
+func connect(t *testing.T, s *mcp.Server) *mcp.ClientSession {
+	t.Helper()
+	ct, st := mcp.NewInMemoryTransports()
+	if _, err := s.Connect(t.Context(), st, nil); err != nil {
+		t.Fatal(err)
+	}
+	return connectTransport(t, ct)
+}
+
+func connectTransport(t *testing.T, transport mcp.Transport) *mcp.ClientSession {
+	t.Helper()
+	c := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "v1.0.0"}, nil)
+	cs, err := c.Connect(t.Context(), transport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs
+}
+
+func toolNames(t *testing.T, cs *mcp.ClientSession) []string {
+	t.Helper()
+	var names []string
+	for tl, err := range cs.Tools(t.Context(), nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, tl.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func callTool(t *testing.T, cs *mcp.ClientSession, name string, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	res, err := cs.CallTool(t.Context(), &mcp.CallToolParams{Name: name, Arguments: args})
+	if err != nil {
+		t.Fatalf("CallTool(%s) failed: %v", name, err)
+	}
+	return res
+}
+
+func text(res *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range res.Content {
+		if tc, ok := c.(*mcp.TextContent); ok {
+			parts = append(parts, tc.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func TestTools(t *testing.T) {
+	sessions := session.InMemoryService()
+	artifacts := artifact.InMemoryService()
+	s, err := mcpserve.NewServer(mcpserve.Config{
+		Name:            "capitals",
+		Tools:           []tool.Tool{capitalTool(t), failTool{}},
+		SessionService:  sessions,
+		ArtifactService: artifacts,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := connect(t, s)
+
+	var decl *mcp.Tool
+	for tl, err := range cs.Tools(t.Context(), nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tl.Name == "get_capital_city" {
+			decl = tl
+		}
+	}
+	if decl == nil {
+		t.Fatal("get_capital_city is not listed")
+	}
+	schema, _ := decl.InputSchema.(map[string]any)
+	if props, _ := schema["properties"].(map[string]any); decl.Description != "Retrieves the capital city for a given country." || props["country"] == nil {
+		t.Errorf("get_capital_city = %q, %v, want its description and parameters", decl.Description, schema)
+	}
+
+	res := callTool(t, cs, "get_capital_city", map[string]any{"country": "Japan"})
+	if diff := cmp.Diff(map[string]any{"result": "Tokyo"}, res.StructuredContent); res.IsError || diff != "" {
+		t.Errorf("get_capital_city result (-want +got):\n%s", diff)
+	}
+	if got := text(res); got != `{"result":"Tokyo"}` {
+		t.Errorf("get_capital_city text = %q, want the result as JSON", got)
+	}
+	if res := callTool(t, cs, "get_capital_city", map[string]any{"country": "Atlantis"}); res.IsError || !strings.Contains(text(res), "unknown country Atlantis") {
+		t.Errorf("get_capital_city(Atlantis) = %v, %q, want the error message of the result", res.IsError, text(res))
+	}
+	if res := callTool(t, cs, "fail", nil); !res.IsError || text(res) != "it failed" {
+		t.Errorf("fail = %v, %q, want the error of the tool", res.IsError, text(res))
+	}
+
+	// The changes of the tool are recorded in the session of the client.
+	list, err := sessions.List(t.Context(), &session.ListRequest{AppName: "capitals", UserID: "mcp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Sessions) != 1 {
+		t.Fatalf("got %d sessions, want 1", len(list.Sessions))
+	}
+	got, err := sessions.Get(t.Context(), &session.GetRequest{AppName: "capitals", UserID: "mcp", SessionID: list.Sessions[0].ID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := got.Session.State().Get("last_country"); err != nil || v != "Japan" {
+		t.Errorf("last_country = %v, %v, want Japan", v, err)
+	}
+	if n := got.Session.Events().Len(); n != 1 {
+		t.Errorf("session has %d events, want 1 for the call that changed it", n)
+	}
+	loaded, err := artifacts.Load(t.Context(), &artifact.LoadRequest{AppName: "capitals", UserID: "mcp", SessionID: got.Session.ID(), FileName: "last.txt"})
+	if err != nil || loaded.Part.Text != "Japan" {
+		t.Errorf("last.txt = %v, %v, want Japan", loaded, err)
+	}
+}
+
+func TestAgent(t *testing.T) {
+	llm := fakellm.New("fake", fakellm.Text("Tokyo."), fakellm.Text("Paris."), fakellm.Text("Ottawa."))
+	s, err := mcpserve.NewServer(mcpserve.Config{Agent: newAgent(t, llm), Tools: []tool.Tool{capitalTool(t)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := connect(t, s), connect(t, s)
+	if got := toolNames(t, first); !slices.Equal(got, []string{"capital_agent", "get_capital_city"}) {
+		t.Fatalf("tools = %q, want the agent and its tool", got)
+	}
+
+	for _, c := range []struct {
+		cs      *mcp.ClientSession
+		request string
+		want    string
+	}{
+		{first, "What is the capital of Japan?", "Tokyo."},
+		{first, "And of France?", "Paris."},
+		{second, "What is the capital of Canada?", "Ottawa."},
+	} {
+		if res := callTool(t, c.cs, "capital_agent", map[string]any{"request": c.request}); res.IsError || text(res) != c.want {
+			t.Errorf("capital_agent(%q) = %v, %q, want %q", c.request, res.IsError, text(res), c.want)
+		}
+	}
+
+	// Each client has a session of its own.
+	reqs := llm.Requests()
+	if n := len(reqs[1].Contents); n != 3 {
+		t.Errorf("second request of the first client has %d contents, want 3", n)
+	}
+	if n := len(reqs[2].Contents); n != 1 {
+		t.Errorf("first request of the second client has %d contents, want 1", n)
+	}
+
+	if res := callTool(t, first, "capital_agent", map[string]any{"request": " "}); !res.IsError {
+		t.Errorf("capital_agent with an empty request = %q, want an error", text(res))
+	}
+}
+
+func TestWebLauncher(t *testing.T) {
+	for _, c := range []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"capital_agent", "get_capital_city"}},
+		{[]string{"-mcp_agent=false"}, []string{"get_capital_city"}},
+	} {
+		l := mcpserve.NewWebLauncher(capitalTool(t))
+		if rest, err := l.Parse(c.args); err != nil || len(rest) != 0 {
+			t.Fatalf("Parse(%q) = %q, %v", c.args, rest, err)
+		}
+		router := mux.NewRouter()
+		err := l.SetupSubrouters(router, &adk.Config{
+			AgentLoader:     services.NewSingleAgentLoader(newAgent(t, fakellm.New("fake"))),
+			SessionService:  session.InMemoryService(),
+			ArtifactService: artifact.InMemoryService(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		httpServer := httptest.NewServer(router)
+		cs := connectTransport(t, &mcp.StreamableClientTransport{Endpoint: httpServer.URL + mcpserve.Path})
+		if got := toolNames(t, cs); !slices.Equal(got, c.want) {
+			t.Errorf("with %q, tools = %q, want %q", c.args, got, c.want)
+		}
+		if res := callTool(t, cs, "get_capital_city", map[string]any{"country": "France"}); text(res) != `{"result":"Paris"}` {
+			t.Errorf("with %q, get_capital_city(France) = %q, want Paris", c.args, text(res))
+		}
+		cs.Close()
+		httpServer.Close()
+	}
+}
+
+func TestStdioLauncher(t *testing.T) {
+	l := mcpserve.NewLauncher()
+	if l.Keyword() != "mcp" {
+		t.Errorf("Keyword() = %q, want mcp", l.Keyword())
+	}
+	if _, err := l.Parse([]string{"-no_such_flag"}); err == nil {
+		t.Error("Parse() of an unknown flag succeeded, want an error")
+	}
+	if !strings.Contains(l.CommandLineSyntax(), "-mcp_agent") {
+		t.Errorf("CommandLineSyntax() = %q, want the flags", l.CommandLineSyntax())
+	}
+	// Without an agent or tools, there is nothing to serve.
+	if err := l.Run(context.Background(), &adk.Config{}); err == nil {
+		t.Error("Run() without an agent or tools succeeded, want an error")
+	}
+}
+
+func TestConfig(t *testing.T) {
+	notFunction := struct{ tool.Tool }{capitalTool(t)}
+	for name, cfg := range map[string]mcpserve.Config{
+		"empty":        {},
+		"not function": {Tools: []tool.Tool{notFunction}},
+		"duplicate":    {Agent: newAgent(t, fakellm.New("fake")), Tools: []tool.Tool{capitalTool(t), capitalTool(t)}},
+	} {
+		if _, err := mcpserve.NewServer(cfg); err == nil {
+			t.Errorf("NewServer() with %s config succeeded, want an error", name)
+		}
+	}
+}
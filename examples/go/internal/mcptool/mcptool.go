@@ -0,0 +1,357 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mcptool exposes the tools of a Model Context Protocol server to an
+// agent, as a tool.Toolset.
+//
+// New connects to a server run as a subprocess over stdio, or reached at a
+// URL over streamable HTTP or the older HTTP with server-sent events:
+//
+//	files, err := mcptool.New(mcptool.Config{
+//		Command: []string{"npx", "-y", "@modelcontextprotocol/server-filesystem", dir},
+//		Filter:  tool.StringPredicate([]string{"read_file", "list_directory"}),
+//	})
+//	...
+//	defer files.Close()
+//	a, err := llmagent.New(llmagent.Config{
+//		Name:     "file_agent",
+//		Model:    model,
+//		Toolsets: []tool.Toolset{files},
+//	})
+//
+// The toolset connects on first use, and lists the tools of the server once,
+// and again whenever the server says its list changed. Each tool keeps the
+// name, the description and the input schema the server gives it.
+//
+// When the server goes away, such as when its process exits, the next use
+// connects again, starting a new process if needed. Listing the tools is
+// tried again on the new connection; a tool call that failed as the
+// connection closed is not, as the server may have run it.
+//
+// The ADK has an MCP toolset too, google.golang.org/adk/tool/mcptoolset, but
+// as of ADK v0.1.0 it cannot be extended to do this. It keeps the first
+// session it opens for good and does not expose it, so it can neither notice
+// that the session closed nor close it to stop the server process. It takes
+// a single mcp.Transport, while a CommandTransport starts its process only
+// once, so connecting again takes a new toolset, whose tools the model may
+// be calling already. And it connects with the context of the request that
+// first lists the tools, so a streamable HTTP session stops receiving the
+// messages of the server, such as that its tools changed, when that request
+// ends. Once mcptoolset can reconnect, this package should give way to it.
+package mcptool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/llmrequest"
+)
+
+// Config configures a Toolset. Exactly one of Command, URL and Transport
+// must be set.
+type Config struct {
+	// Name is the name of the toolset. It defaults to "mcp".
+	Name string
+	// Command runs the server, which speaks over its stdin and stdout. Env
+	// and Dir are the environment and the working directory of the process;
+	// by default, those of this process.
+	Command []string
+	Env     []string
+	Dir     string
+	// URL is the endpoint of a server speaking streamable HTTP or, if SSE
+	// is set, HTTP with server-sent events.
+	URL string
+	SSE bool
+	// HTTPClient sends the requests to URL. It defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+	// Transport, if set, returns a new transport for each connection, for
+	// servers reached another way, such as in memory.
+	Transport func() mcp.Transport
+	// Filter, if set, selects the tools of the toolset, as in
+	// tool.StringPredicate.
+	Filter tool.Predicate
+}
+
+// Toolset is the toolset of an MCP server. It is safe for concurrent use.
+type Toolset struct {
+	cfg    Config
+	client *mcp.Client
+
+	connMu sync.Mutex // held while connecting
+
+	mu      sync.Mutex
+	session *mcp.ClientSession // nil when not connected
+	tools   []tool.Tool        // tools of session, or nil if not listed yet
+	changes int                // number of list changes of session
+}
+
+// New returns the toolset of the server of cfg. It does not connect yet.
+func New(cfg Config) (*Toolset, error) {
+	set := 0
+	for _, ok := range []bool{len(cfg.Command) > 0, cfg.URL != "", cfg.Transport != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("exactly one of Command, URL and Transport must be set")
+	}
+	if cfg.Name == "" {
+		cfg.Name = "mcp"
+	}
+	ts := &Toolset{cfg: cfg}
+	ts.client = mcp.NewClient(&mcp.Implementation{Name: "adk-mcptool", Version: "v1.0.0"}, &mcp.ClientOptions{
+		ToolListChangedHandler: func(ctx context.Context, req *mcp.ToolListChangedRequest) {
+			ts.mu.Lock()
+			defer ts.mu.Unlock()
+			if req.Session == ts.session {
+				ts.tools = nil
+				ts.changes++
+			}
+		},
+	})
+	return ts, nil
+}
+
+// Name returns the name of the toolset.
+func (ts *Toolset) Name() string {
+	return ts.cfg.Name
+}
+
+// Tools returns the tools of the server that Config.Filter selects,
+// connecting to the server if needed.
+func (ts *Toolset) Tools(ctx agent.ReadonlyContext) ([]tool.Tool, error) {
+	all, err := ts.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var tools []tool.Tool
+	for _, t := range all {
+		if ts.cfg.Filter == nil || ts.cfg.Filter(ctx, t) {
+			tools = append(tools, t)
+		}
+	}
+	return tools, nil
+}
+
+// Close closes the connection to the server, if any, and stops its process.
+// The toolset connects again if it is used after.
+func (ts *Toolset) Close() error {
+	ts.mu.Lock()
+	cs := ts.session
+	ts.session, ts.tools = nil, nil
+	ts.mu.Unlock()
+	if cs == nil {
+		return nil
+	}
+	return cs.Close()
+}
+
+// list returns all the tools of the server, listing them if the list is not
+// known.
+func (ts *Toolset) list(ctx context.Context) ([]tool.Tool, error) {
+	var tools []tool.Tool
+	err := ts.do(ctx, true, func(cs *mcp.ClientSession) error {
+		ts.mu.Lock()
+		tools = ts.tools
+		changes := ts.changes
+		ts.mu.Unlock()
+		if tools != nil {
+			return nil
+		}
+
+		tools = []tool.Tool{}
+		for t, err := range cs.Tools(ctx, nil) {
+			if err != nil {
+				return err
+			}
+			tools = append(tools, &mcpTool{ts: ts, tool: t})
+		}
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
+		// A list that changed meanwhile may be out of date already.
+		if ts.session == cs && ts.changes == changes {
+			ts.tools = tools
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list MCP tools: %w", err)
+	}
+	return tools, nil
+}
+
+// do runs f with the session, connecting first if needed. If f fails
+// because the connection closed, the session is dropped and, if retry is
+// set, f runs once more on a new one.
+func (ts *Toolset) do(ctx context.Context, retry bool, f func(cs *mcp.ClientSession) error) error {
+	for attempt := 0; ; attempt++ {
+		cs, err := ts.connect(ctx)
+		if err != nil {
+			return err
+		}
+		err = f(cs)
+		if errors.Is(err, mcp.ErrConnectionClosed) {
+			ts.drop(cs)
+			if retry && attempt == 0 {
+				continue
+			}
+		}
+		return err
+	}
+}
+
+// connect returns the session, connecting to the server if there is none.
+func (ts *Toolset) connect(ctx context.Context) (*mcp.ClientSession, error) {
+	ts.connMu.Lock()
+	defer ts.connMu.Unlock()
+	ts.mu.Lock()
+	cs := ts.session
+	ts.mu.Unlock()
+	if cs != nil {
+		return cs, nil
+	}
+	// The session outlives the call that happens to connect.
+	cs, err := ts.client.Connect(context.WithoutCancel(ctx), ts.transport(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MCP server: %w", err)
+	}
+	ts.mu.Lock()
+	ts.session, ts.tools, ts.changes = cs, nil, 0
+	ts.mu.Unlock()
+	go func() {
+		cs.Wait()
+		ts.drop(cs)
+	}()
+	return cs, nil
+}
+
+// drop closes cs and forgets it if it is the session, so that the next use
+// connects again.
+func (ts *Toolset) drop(cs *mcp.ClientSession) {
+	ts.mu.Lock()
+	if ts.session == cs {
+		ts.session, ts.tools = nil, nil
+	}
+	ts.mu.Unlock()
+	cs.Close()
+}
+
+// transport returns a new transport to the server.
+func (ts *Toolset) transport() mcp.Transport {
+	switch cfg := &ts.cfg; {
+	case cfg.Transport != nil:
+		return cfg.Transport()
+	case len(cfg.Command) > 0:
+		cmd := exec.Command(cfg.Command[0], cfg.Command[1:]...)
+		cmd.Env, cmd.Dir = cfg.Env, cfg.Dir
+		return &mcp.CommandTransport{Command: cmd}
+	case cfg.SSE:
+		return &mcp.SSEClientTransport{Endpoint: cfg.URL, HTTPClient: cfg.HTTPClient}
+	default:
+		return &mcp.StreamableClientTransport{Endpoint: cfg.URL, HTTPClient: cfg.HTTPClient}
+	}
+}
+
+type mcpTool struct {
+	ts   *Toolset
+	tool *mcp.Tool
+}
+
+func (t *mcpTool) Name() string {
+	return t.tool.Name
+}
+
+func (t *mcpTool) Description() string {
+	return t.tool.Description
+}
+
+func (t *mcpTool) IsLongRunning() bool {
+	return false
+}
+
+func (t *mcpTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:                 t.tool.Name,
+		Description:          t.tool.Description,
+		ParametersJsonSchema: t.tool.InputSchema,
+	}
+}
+
+// ProcessRequest adds the tool to req.
+func (t *mcpTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	return llmrequest.AddTool(req, t)
+}
+
+// Run calls the tool on the server. It returns its structured content, or
+// else its text, under "output", and fails if the tool does.
+func (t *mcpTool) Run(ctx tool.Context, args any) (map[string]any, error) {
+	var res *mcp.CallToolResult
+	err := t.ts.do(ctx, false, func(cs *mcp.ClientSession) error {
+		var err error
+		res, err = cs.CallTool(ctx, &mcp.CallToolParams{Name: t.tool.Name, Arguments: args})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call MCP tool %q: %w", t.tool.Name, err)
+	}
+	text := contentText(res.Content)
+	if res.IsError {
+		if text == "" {
+			text = "no details"
+		}
+		return nil, fmt.Errorf("MCP tool %q failed: %s", t.tool.Name, text)
+	}
+	if res.StructuredContent != nil {
+		return map[string]any{"output": res.StructuredContent}, nil
+	}
+	return map[string]any{"output": text}, nil
+}
+
+// contentText returns the text of content. Other content is named by its
+// type.
+func contentText(content []mcp.Content) string {
+	var parts []string
+	for _, c := range content {
+		switch c := c.(type) {
+		case *mcp.TextContent:
+			parts = append(parts, c.Text)
+		case *mcp.ImageContent:
+			parts = append(parts, "["+c.MIMEType+" image]")
+		case *mcp.AudioContent:
+			parts = append(parts, "["+c.MIMEType+" audio]")
+		case *mcp.ResourceLink:
+			parts = append(parts, "[resource "+c.URI+"]")
+		case *mcp.EmbeddedResource:
+			if c.Resource != nil && c.Resource.Text != "" {
+				parts = append(parts, c.Resource.Text)
+			} else if c.Resource != nil {
+				parts = append(parts, "[resource "+c.Resource.URI+"]")
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
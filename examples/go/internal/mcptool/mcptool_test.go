@@ -0,0 +1,264 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcptool_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/fakellm"
+	"github.com/google/adk-docs/examples/go/internal/fakemcp"
+	"github.com/google/adk-docs/examples/go/internal/mcptool"
+)
+
+func TestMain(m *testing.M) {
+	fakemcp.ServeChild()
+	os.Exit(m.Run())
+}
+
+// memoryServer serves a server in memory, and keeps its sessions.
+type memoryServer struct {
+	server *mcp.Server
+
+	mu       sync.Mutex
+	sessions []*mcp.ServerSession
+}
+
+func (s *memoryServer) transport(t *testing.T) func() mcp.Transport {
+	return func() mcp.Transport {
+		client, server := mcp.NewInMemoryTransports()
+		ss, err := s.server.Connect(context.Background(), server, nil)
+		if err != nil {
+			t.Errorf("server failed to connect: %v", err)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.sessions = append(s.sessions, ss)
+		return client
+	}
+}
+
+func (s *memoryServer) connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+// readonlyContext is the agent.ReadonlyContext of a test, for the calls of
+// Tools outside of an agent.
+type readonlyContext struct {
+	agent.ReadonlyContext
+	ctx context.Context
+}
+
+func (c readonlyContext) Deadline() (time.Time, bool) { return c.ctx.Deadline() }
+func (c readonlyContext) Done() <-chan struct{}       { return c.ctx.Done() }
+func (c readonlyContext) Err() error                  { return c.ctx.Err() }
+func (c readonlyContext) Value(key any) any           { return c.ctx.Value(key) }
+
+func newToolset(t *testing.T, cfg mcptool.Config) *mcptool.Toolset {
+	t.Helper()
+	ts, err := mcptool.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ts.Close() })
+	return ts
+}
+
+func toolNames(t *testing.T, ts *mcptool.Toolset) []string {
+	t.Helper()
+	tools, err := ts.Tools(readonlyContext{ctx: t.Context()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tl := range tools {
+		names = append(names, tl.Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+// call has an agent with ts call a function with args, and returns the
+// response of the tool.
+func call(t *testing.T, ts *mcptool.Toolset, name string, args map[string]any) map[string]any {
+	t.Helper()
+	llm := fakellm.New("fake", fakellm.Call(&genai.FunctionCall{Name: name, Args: args}), fakellm.Text("Done."))
+	a, err := llmagent.New(llmagent.Config{Name: "assistant", Model: llm, Toolsets: []tool.Toolset{ts}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := session.InMemoryService()
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range r.Run(t.Context(), "user", "s1", genai.NewContentFromText("Go ahead.", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() failed: %v", err)
+		}
+	}
+	reqs := llm.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("model was called %d times, want 2", len(reqs))
+	}
+	for _, p := range reqs[1].Contents[len(reqs[1].Contents)-1].Parts {
+		if p.FunctionResponse != nil && p.FunctionResponse.Name == name {
+			return p.FunctionResponse.Response
+		}
+	}
+	t.Fatalf("second request has no response of %s", name)
+	return nil
+}
+
+func TestTransports(t *testing.T) {
+	server := fakemcp.New()
+	httpServer := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	defer httpServer.Close()
+	sseServer := httptest.NewServer(mcp.NewSSEHandler(func(*http.Request) *mcp.Server { return server }, nil))
+	defer sseServer.Close()
+	command, env := fakemcp.Command()
+
+	for name, cfg := range map[string]mcptool.Config{
+		"memory":     {Transport: (&memoryServer{server: server}).transport(t)},
+		"stdio":      {Command: command, Env: env},
+		"streamable": {URL: httpServer.URL},
+		"sse":        {URL: sseServer.URL, SSE: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ts := newToolset(t, cfg)
+			if got := toolNames(t, ts); !slices.Equal(got, []string{"add", "echo", "fail"}) {
+				t.Fatalf("tools = %q, want those of the server", got)
+			}
+			tools, _ := ts.Tools(readonlyContext{ctx: t.Context()})
+			decl := tools[slices.IndexFunc(tools, func(tl tool.Tool) bool { return tl.Name() == "echo" })].(interface {
+				Declaration() *genai.FunctionDeclaration
+			}).Declaration()
+			schema, _ := decl.ParametersJsonSchema.(map[string]any)
+			if props, _ := schema["properties"].(map[string]any); decl.Description != "Echoes the text." || props["text"] == nil {
+				t.Errorf("echo declaration = %q, %v, want the description and the input schema of the server", decl.Description, schema)
+			}
+
+			if diff := cmp.Diff(map[string]any{"output": "hi"}, call(t, ts, "echo", map[string]any{"text": "hi"})); diff != "" {
+				t.Errorf("echo response (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(map[string]any{"output": map[string]any{"sum": 3.0}}, call(t, ts, "add", map[string]any{"a": 1, "b": 2})); diff != "" {
+				t.Errorf("add response (-want +got):\n%s", diff)
+			}
+			if err := fmt.Sprint(call(t, ts, "fail", nil)["error"]); !strings.Contains(err, "it failed") {
+				t.Errorf("fail response = %q, want the error of the tool", err)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	s := &memoryServer{server: fakemcp.New()}
+	ts := newToolset(t, mcptool.Config{Transport: s.transport(t), Filter: tool.StringPredicate([]string{"echo"})})
+	if got := toolNames(t, ts); !slices.Equal(got, []string{"echo"}) {
+		t.Errorf("tools = %q, want echo", got)
+	}
+}
+
+// eventually fails t unless cond becomes true within a few seconds.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestListChanged(t *testing.T) {
+	s := &memoryServer{server: fakemcp.New()}
+	ts := newToolset(t, mcptool.Config{Transport: s.transport(t)})
+	if got := toolNames(t, ts); len(got) != 3 {
+		t.Fatalf("tools = %q, want 3", got)
+	}
+
+	s.server.AddTool(&mcp.Tool{Name: "ping", InputSchema: map[string]any{"type": "object"}},
+		func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "pong"}}}, nil
+		})
+	eventually(t, "ping to be listed", func() bool { return slices.Contains(toolNames(t, ts), "ping") })
+	if diff := cmp.Diff(map[string]any{"output": "pong"}, call(t, ts, "ping", nil)); diff != "" {
+		t.Errorf("ping response (-want +got):\n%s", diff)
+	}
+
+	s.server.RemoveTools("fail")
+	eventually(t, "fail to be removed", func() bool { return !slices.Contains(toolNames(t, ts), "fail") })
+	if n := s.connections(); n != 1 {
+		t.Errorf("connected %d times, want 1", n)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	s := &memoryServer{server: fakemcp.New()}
+	ts := newToolset(t, mcptool.Config{Transport: s.transport(t)})
+	if got := toolNames(t, ts); len(got) != 3 {
+		t.Fatalf("tools = %q, want 3", got)
+	}
+
+	// Once the toolset sees the server go away, it connects again.
+	s.mu.Lock()
+	s.sessions[0].Close()
+	s.mu.Unlock()
+	eventually(t, "a new connection", func() bool { return len(toolNames(t, ts)) == 3 && s.connections() == 2 })
+	if diff := cmp.Diff(map[string]any{"output": "again"}, call(t, ts, "echo", map[string]any{"text": "again"})); diff != "" {
+		t.Errorf("echo response after the server closed (-want +got):\n%s", diff)
+	}
+
+	// A closed toolset connects again when used.
+	if err := ts.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := toolNames(t, ts); len(got) != 3 {
+		t.Errorf("tools after Close() = %q, want 3", got)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	for _, cfg := range []mcptool.Config{
+		{},
+		{Command: []string{"server"}, URL: "http://localhost"},
+	} {
+		if _, err := mcptool.New(cfg); err == nil {
+			t.Errorf("New(%+v) succeeded, want an error", cfg)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package memorychunk splits the events of a session into the text passages
+// the memory services of this module index.
+package memorychunk
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// Chunk is a passage of the text of one event.
+type Chunk struct {
+	SessionID string    `json:"sessionId"`
+	EventID   string    `json:"eventId"`
+	Author    string    `json:"author"`
+	Role      string    `json:"role"`
+	Timestamp time.Time `json:"timestamp"`
+	Text      string    `json:"text"`
+}
+
+// Entry returns the chunk as a memory entry.
+func (c *Chunk) Entry() memory.Entry {
+	return memory.Entry{
+		Content:   genai.NewContentFromText(c.Text, genai.Role(c.Role)),
+		Author:    c.Author,
+		Timestamp: c.Timestamp,
+	}
+}
+
+// Options configures Split.
+type Options struct {
+	// MaxWords is the most words a chunk holds. Longer texts are split in
+	// several chunks. It defaults to 200.
+	MaxWords int
+	// Overlap is the number of words a chunk repeats from the end of the
+	// one before, so that a sentence cut in two is still found whole. It
+	// must be less than MaxWords.
+	Overlap int
+}
+
+// Split returns the chunks of the text parts of the events of sess, in
+// order. Events without text, such as function calls, have none.
+func Split(sess session.Session, opts Options) []Chunk {
+	if opts.MaxWords <= 0 {
+		opts.MaxWords = 200
+	}
+	step := max(opts.MaxWords-opts.Overlap, 1)
+
+	var chunks []Chunk
+	for e := range sess.Events().All() {
+		if e.Content == nil {
+			continue
+		}
+		var texts []string
+		for _, p := range e.Content.Parts {
+			if p.Text != "" && !p.Thought {
+				texts = append(texts, p.Text)
+			}
+		}
+		words := strings.Fields(strings.Join(texts, "\n"))
+		for start := 0; start < len(words); start += step {
+			end := min(start+opts.MaxWords, len(words))
+			chunks = append(chunks, Chunk{
+				SessionID: sess.ID(),
+				EventID:   e.ID,
+				Author:    e.Author,
+				Role:      e.Content.Role,
+				Timestamp: e.Timestamp,
+				Text:      strings.Join(words[start:end], " "),
+			})
+			if end == len(words) {
+				break
+			}
+		}
+	}
+	return chunks
+}
+
+// Words returns the lowercase words of text, split at anything that is not
+// a letter or a digit.
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorychunk
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/localsession"
+)
+
+func TestSplit(t *testing.T) {
+	ts := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	text := &session.Event{ID: "e1", Author: "user", Timestamp: ts}
+	text.Content = &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+		genai.NewPartFromText("one two three"),
+		{Text: "thinking", Thought: true},
+		genai.NewPartFromText("four\nfive six  seven"),
+	}}
+	call := &session.Event{ID: "e2", Author: "agent"}
+	call.Content = genai.NewContentFromFunctionCall("f", nil, genai.RoleModel)
+	short := &session.Event{ID: "e3", Author: "agent"}
+	short.Content = genai.NewContentFromText("done", genai.RoleModel)
+	sess := localsession.New("app", "user", "s1", nil, []*session.Event{text, call, short}, ts)
+
+	chunks := Split(sess, Options{MaxWords: 3, Overlap: 1})
+	var texts []string
+	for _, c := range chunks {
+		texts = append(texts, c.Text)
+	}
+	want := []string{"one two three", "three four five", "five six seven", "done"}
+	if !slices.Equal(texts, want) {
+		t.Errorf("Split() texts = %q, want %q", texts, want)
+	}
+	if c := chunks[0]; c.SessionID != "s1" || c.EventID != "e1" || c.Author != "user" || c.Role != genai.RoleUser || !c.Timestamp.Equal(ts) {
+		t.Errorf("Split() first chunk = %+v, want the fields of event e1", c)
+	}
+	if e := chunks[3].Entry(); e.Author != "agent" || e.Content.Parts[0].Text != "done" || e.Content.Role != genai.RoleModel {
+		t.Errorf("Entry() = %+v, want the last chunk", e)
+	}
+}
+
+func TestWords(t *testing.T) {
+	if got, want := Words("What's my FAVORITE project? (Gemini-2)"), []string{"what", "s", "my", "favorite", "project", "gemini", "2"}; !slices.Equal(got, want) {
+		t.Errorf("Words() = %q, want %q", got, want)
+	}
+}
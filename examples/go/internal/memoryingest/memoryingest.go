@@ -0,0 +1,185 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package memoryingest adds sessions to a memory.Service as they go, so that
+// an app does not have to call AddSession itself.
+//
+// Wrap the session service the runner uses:
+//
+//	sessionService := memoryingest.Wrap(session.InMemoryService(), memoryService, memoryingest.Policy{
+//		EveryTurns: 1,
+//		OnDelete:   true,
+//	})
+//	r, err := runner.New(runner.Config{
+//		AppName:        appName,
+//		Agent:          a,
+//		SessionService: sessionService,
+//		MemoryService:  memoryService,
+//	})
+//
+// By default the memory gets the session as it is. An AgentExtractor has an
+// LLM distill it into a list of facts first, which keeps the memory small
+// and to the point.
+package memoryingest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/compaction"
+	"github.com/google/adk-docs/examples/go/internal/localsession"
+)
+
+// Policy says when a session is added to memory. The zero Policy never adds
+// one.
+type Policy struct {
+	// EveryTurns adds the session after every EveryTurns final responses
+	// of its agents: 1 adds it after every final response.
+	EveryTurns int
+	// OnDelete adds the session just before it is deleted.
+	OnDelete bool
+	// Extractor turns the session into what is added to memory. It
+	// defaults to adding the session as it is.
+	Extractor Extractor
+}
+
+// Extractor returns what of a session to add to memory, as a session of the
+// same app, user and ID.
+type Extractor interface {
+	Extract(ctx context.Context, sess session.Session) (session.Session, error)
+}
+
+// ExtractorFunc adapts a function to an Extractor.
+type ExtractorFunc func(ctx context.Context, sess session.Session) (session.Session, error)
+
+func (f ExtractorFunc) Extract(ctx context.Context, sess session.Session) (session.Session, error) {
+	return f(ctx, sess)
+}
+
+// Service is a session.Service that adds sessions to a memory.Service
+// according to a Policy.
+type Service struct {
+	session.Service
+	memory memory.Service
+	policy Policy
+}
+
+// Wrap returns svc adding its sessions to mem according to policy.
+func Wrap(svc session.Service, mem memory.Service, policy Policy) *Service {
+	if policy.Extractor == nil {
+		policy.Extractor = ExtractorFunc(func(ctx context.Context, sess session.Session) (session.Session, error) {
+			return sess, nil
+		})
+	}
+	return &Service{Service: svc, memory: mem, policy: policy}
+}
+
+// AppendEvent appends e, then adds the session to memory if e is an agent's
+// final response that completes EveryTurns turns. A failure to add it is
+// logged; e is appended all the same.
+func (s *Service) AppendEvent(ctx context.Context, sess session.Session, e *session.Event) error {
+	if err := s.Service.AppendEvent(ctx, sess, e); err != nil {
+		return err
+	}
+	if s.policy.EveryTurns <= 0 || e.Partial || e.Author == "user" || !e.IsFinalResponse() {
+		return nil
+	}
+	if turns(sess)%s.policy.EveryTurns != 0 {
+		return nil
+	}
+	if err := s.Ingest(ctx, sess); err != nil {
+		log.Printf("Failed to add session %s to memory: %v", sess.ID(), err)
+	}
+	return nil
+}
+
+// Delete adds the session to memory if the policy says so, then deletes it.
+// If adding it fails, the session is not deleted.
+func (s *Service) Delete(ctx context.Context, req *session.DeleteRequest) error {
+	if s.policy.OnDelete {
+		resp, err := s.Service.Get(ctx, &session.GetRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID})
+		if err == nil {
+			if err := s.Ingest(ctx, resp.Session); err != nil {
+				return fmt.Errorf("failed to add session %s to memory before deleting it: %w", req.SessionID, err)
+			}
+		}
+	}
+	return s.Service.Delete(ctx, req)
+}
+
+// Ingest adds sess to memory through the policy's Extractor, whatever the
+// policy says about when.
+func (s *Service) Ingest(ctx context.Context, sess session.Session) error {
+	extracted, err := s.policy.Extractor.Extract(ctx, sess)
+	if err != nil {
+		return fmt.Errorf("failed to extract memories: %w", err)
+	}
+	return s.memory.AddSession(ctx, extracted)
+}
+
+// turns counts the final responses of the agents of sess.
+func turns(sess session.Session) int {
+	n := 0
+	for e := range sess.Events().All() {
+		if e.Author != "user" && e.IsFinalResponse() {
+			n++
+		}
+	}
+	return n
+}
+
+// FactAuthor is the author of the facts an AgentExtractor extracts.
+const FactAuthor = "memory"
+
+// AgentExtractor returns an Extractor that sends the transcript of a session
+// to a, typically an llmagent instructed to list the facts about the user
+// worth remembering, one per line. Each line of its reply becomes an event
+// of the extracted session, authored by FactAuthor. A reply of "NONE" or
+// with no lines extracts nothing.
+func AgentExtractor(a agent.Agent) Extractor {
+	summarizer := compaction.AgentSummarizer(a)
+	return ExtractorFunc(func(ctx context.Context, sess session.Session) (session.Session, error) {
+		var events []*session.Event
+		for e := range sess.Events().All() {
+			events = append(events, e)
+		}
+		var facts []*session.Event
+		if compaction.Transcript(events) != "" {
+			reply, err := summarizer.Summarize(ctx, events)
+			if err != nil {
+				return nil, err
+			}
+			for line := range strings.Lines(reply) {
+				fact := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*•"))
+				if fact == "" || strings.EqualFold(fact, "none") {
+					continue
+				}
+				e := session.NewEvent("")
+				e.ID = fmt.Sprintf("%s-fact-%d", sess.ID(), len(facts))
+				e.Timestamp = sess.LastUpdateTime()
+				e.Author = FactAuthor
+				e.Content = genai.NewContentFromText(fact, genai.RoleModel)
+				facts = append(facts, e)
+			}
+		}
+		return localsession.New(sess.AppName(), sess.UserID(), sess.ID(), nil, facts, sess.LastUpdateTime()), nil
+	})
+}
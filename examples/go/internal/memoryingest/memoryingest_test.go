@@ -0,0 +1,153 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memoryingest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/fakellm"
+)
+
+func newRunner(t *testing.T, svc session.Service, replies ...string) *runner.Runner {
+	t.Helper()
+	var turns []fakellm.Turn
+	for _, r := range replies {
+		turns = append(turns, fakellm.Text(r))
+	}
+	a, err := llmagent.New(llmagent.Config{Name: "assistant", Model: fakellm.New("fake", turns...)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: svc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func run(t *testing.T, r *runner.Runner, prompt string) {
+	t.Helper()
+	for _, err := range r.Run(t.Context(), "user", "s1", genai.NewContentFromText(prompt, genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run(%q) failed: %v", prompt, err)
+		}
+	}
+}
+
+func recall(t *testing.T, mem memory.Service, query string) []string {
+	t.Helper()
+	resp, err := mem.Search(t.Context(), &memory.SearchRequest{AppName: "app", UserID: "user", Query: query})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, m := range resp.Memories {
+		texts = append(texts, m.Content.Parts[0].Text)
+	}
+	slices.Sort(texts)
+	return texts
+}
+
+func TestEveryTurns(t *testing.T) {
+	mem := memory.InMemoryService()
+	svc := Wrap(session.InMemoryService(), mem, Policy{EveryTurns: 2})
+	r := newRunner(t, svc, "Noted.", "Sure.", "Bye.")
+
+	run(t, r, "My favorite project is Alpha.")
+	if got := recall(t, mem, "favorite project"); len(got) != 0 {
+		t.Errorf("memory after one turn = %q, want nothing yet", got)
+	}
+	run(t, r, "Remember that please.")
+	if got, want := recall(t, mem, "favorite project"), []string{"My favorite project is Alpha."}; !slices.Equal(got, want) {
+		t.Errorf("memory after two turns = %q, want %q", got, want)
+	}
+	run(t, r, "Thanks.")
+	if got := recall(t, mem, "Thanks"); len(got) != 0 {
+		t.Errorf("memory after three turns = %q, want the third turn not added yet", got)
+	}
+}
+
+func TestOnDelete(t *testing.T) {
+	mem := memory.InMemoryService()
+	svc := Wrap(session.InMemoryService(), mem, Policy{OnDelete: true})
+	r := newRunner(t, svc, "Noted.")
+	run(t, r, "My favorite project is Alpha.")
+	if got := recall(t, mem, "favorite project"); len(got) != 0 {
+		t.Errorf("memory before delete = %q, want nothing", got)
+	}
+	if err := svc.Delete(t.Context(), &session.DeleteRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if got := recall(t, mem, "favorite project"); len(got) != 1 {
+		t.Errorf("memory after delete = %q, want the session", got)
+	}
+}
+
+func TestFailedIngestKeepsSession(t *testing.T) {
+	inner := session.InMemoryService()
+	svc := Wrap(inner, memory.InMemoryService(), Policy{OnDelete: true, Extractor: ExtractorFunc(func(ctx context.Context, sess session.Session) (session.Session, error) {
+		return nil, errors.New("extractor down")
+	})})
+	newRunner(t, svc)
+	if err := svc.Delete(t.Context(), &session.DeleteRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err == nil {
+		t.Error("Delete() with a failing extractor succeeded, want an error")
+	}
+	if _, err := inner.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err != nil {
+		t.Errorf("session was deleted although adding it to memory failed: %v", err)
+	}
+}
+
+func TestAgentExtractor(t *testing.T) {
+	llm := fakellm.New("fake", fakellm.Text("- The user's favorite project is Alpha.\n\n- The user lives in Paris.\n"))
+	extractor, err := llmagent.New(llmagent.Config{
+		Name:        "extractor",
+		Model:       llm,
+		Instruction: "List the facts about the user worth remembering, one per line, or NONE.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem := memory.InMemoryService()
+	svc := Wrap(session.InMemoryService(), mem, Policy{EveryTurns: 1, Extractor: AgentExtractor(extractor)})
+	r := newRunner(t, svc, "Noted.")
+	run(t, r, "My favorite project is Alpha, and I live in Paris.")
+
+	got := recall(t, mem, "the")
+	want := []string{"The user lives in Paris.", "The user's favorite project is Alpha."}
+	if !slices.Equal(got, want) {
+		t.Errorf("memory = %q, want %q", got, want)
+	}
+	reqs := llm.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("extractor was called %d times, want 1", len(reqs))
+	}
+	prompt := reqs[0].Contents[len(reqs[0].Contents)-1].Parts[0].Text
+	if want := "[user]: My favorite project is Alpha, and I live in Paris.\n[assistant]: Noted.\n"; prompt != want {
+		t.Errorf("extractor prompt = %q, want %q", prompt, want)
+	}
+}
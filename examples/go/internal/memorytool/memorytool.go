@@ -0,0 +1,350 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package memorytool provides tools that give an agent the memory.Service of
+// its runner, so that an app does not have to write its own tool around
+// tool.Context.SearchMemory.
+//
+// NewLoad returns the load_memory tool, which the model calls when it thinks
+// the memory may help:
+//
+//	a, err := llmagent.New(llmagent.Config{
+//		Name:  "assistant",
+//		Model: model,
+//		Tools: []tool.Tool{memorytool.NewLoad(memorytool.Config{})},
+//	})
+//
+// NewPreload returns the preload_memory tool, which the model never calls:
+// before every model call, it searches the memory for the message of the
+// user and adds what it finds to the system instruction.
+//
+// Both leave out memories whose text is already in the history of the
+// request, so that the model does not read the same thing twice.
+package memorytool
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/llmrequest"
+)
+
+// Config configures the memory tools.
+type Config struct {
+	// Limit is the most memories a search returns. It defaults to 5.
+	Limit int
+	// Format renders a memory for the model. It defaults to FormatEntry.
+	Format func(memory.Entry) string
+	// KeepDuplicates keeps the memories whose text is already in the
+	// history of the request.
+	KeepDuplicates bool
+}
+
+func (c Config) withDefaults() Config {
+	if c.Limit <= 0 {
+		c.Limit = 5
+	}
+	if c.Format == nil {
+		c.Format = FormatEntry
+	}
+	return c
+}
+
+// FormatEntry renders a memory as its time, author and text, as in
+//
+//	[2025-06-01 12:00] user: My favorite project is Alpha.
+func FormatEntry(m memory.Entry) string {
+	var b strings.Builder
+	if !m.Timestamp.IsZero() {
+		b.WriteString("[" + m.Timestamp.Format("2006-01-02 15:04") + "] ")
+	}
+	if m.Author != "" {
+		b.WriteString(m.Author + ": ")
+	}
+	b.WriteString(text(m.Content))
+	return b.String()
+}
+
+// search returns the memories of the user matching query, without those in
+// seen, at most cfg.Limit of them.
+func search(ctx tool.Context, cfg Config, query string, seen *history) ([]memory.Entry, error) {
+	resp, err := ctx.SearchMemory(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search memory: %w", err)
+	}
+	var found []memory.Entry
+	for _, m := range resp.Memories {
+		if len(found) == cfg.Limit {
+			break
+		}
+		t := normalize(text(m.Content))
+		if t == "" {
+			continue
+		}
+		if !cfg.KeepDuplicates {
+			if seen.contains(t) {
+				continue
+			}
+			seen.add(t)
+		}
+		found = append(found, m)
+	}
+	return found, nil
+}
+
+type loadTool struct {
+	cfg Config
+
+	mu      sync.Mutex
+	seen    map[string]*history // history of the last request, by invocation ID
+	recents []string            // invocation IDs in seen, oldest first
+}
+
+// maxInvocations is the most invocations whose history a load_memory tool
+// remembers at once.
+const maxInvocations = 64
+
+// NewLoad returns the load_memory tool, which searches the memory for a query
+// of the model.
+func NewLoad(cfg Config) tool.Tool {
+	return &loadTool{cfg: cfg.withDefaults(), seen: map[string]*history{}}
+}
+
+func (t *loadTool) Name() string {
+	return "load_memory"
+}
+
+func (t *loadTool) Description() string {
+	return "Loads the memory of past conversations with the user that matches the query."
+}
+
+func (t *loadTool) IsLongRunning() bool {
+	return false
+}
+
+func (t *loadTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        t.Name(),
+		Description: t.Description(),
+		Parameters: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"query": {
+					Type:        genai.TypeString,
+					Description: "The words to look for in the memory.",
+				},
+			},
+			Required: []string{"query"},
+		},
+	}
+}
+
+// Run returns the memories matching the query as a list of strings under
+// "memories".
+func (t *loadTool) Run(ctx tool.Context, args any) (map[string]any, error) {
+	m, ok := args.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected args type, got: %T", args)
+	}
+	query, _ := m["query"].(string)
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("missing query")
+	}
+	found, err := search(ctx, t.cfg, query, t.history(ctx.InvocationID()))
+	if err != nil {
+		return nil, err
+	}
+	memories := make([]string, 0, len(found))
+	for _, e := range found {
+		memories = append(memories, t.cfg.Format(e))
+	}
+	return map[string]any{"memories": memories}, nil
+}
+
+// ProcessRequest adds the tool to req and tells the model about it. It also
+// remembers the history of req, which the model's next call of the tool must
+// not repeat.
+func (t *loadTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	if err := llmrequest.AddTool(req, t); err != nil {
+		return err
+	}
+	llmrequest.AppendInstructions(req, "You have memory of past conversations with the user. When a question"+
+		" may be answered by something the user said before, call the `load_memory`"+
+		" function with a query to look it up.")
+	if !t.cfg.KeepDuplicates {
+		t.remember(ctx.InvocationID(), newHistory(req.Contents))
+	}
+	return nil
+}
+
+func (t *loadTool) remember(invocationID string, h *history) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.seen[invocationID]; !ok {
+		t.recents = append(t.recents, invocationID)
+		if len(t.recents) > maxInvocations {
+			delete(t.seen, t.recents[0])
+			t.recents = t.recents[1:]
+		}
+	}
+	t.seen[invocationID] = h
+}
+
+// history returns the history of the last request of the invocation, or an
+// empty one if there is none.
+func (t *loadTool) history(invocationID string) *history {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if h, ok := t.seen[invocationID]; ok {
+		return h.clone()
+	}
+	return newHistory(nil)
+}
+
+type preloadTool struct {
+	cfg Config
+}
+
+// NewPreload returns the preload_memory tool, which adds the memories
+// matching the message of the user to the system instruction of every
+// request.
+func NewPreload(cfg Config) tool.Tool {
+	return &preloadTool{cfg: cfg.withDefaults()}
+}
+
+func (t *preloadTool) Name() string {
+	return "preload_memory"
+}
+
+func (t *preloadTool) Description() string {
+	return "Preloads the memory of past conversations with the user that matches the message of the user."
+}
+
+func (t *preloadTool) IsLongRunning() bool {
+	return false
+}
+
+// ProcessRequest adds the memories matching the message of the user to the
+// system instruction of req.
+func (t *preloadTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	query := text(ctx.UserContent())
+	if strings.TrimSpace(query) == "" {
+		return nil
+	}
+	found, err := search(ctx, t.cfg, query, newHistory(req.Contents))
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("The following is from your memory of past conversations with the user." +
+		" Use it if it helps to answer:\n")
+	for _, e := range found {
+		b.WriteString("\n" + t.cfg.Format(e))
+	}
+	llmrequest.AppendInstructions(req, b.String())
+	return nil
+}
+
+// history holds the normalized texts of the contents of a request.
+type history struct {
+	texts []string
+}
+
+// newHistory returns the history of contents. Besides their text, it holds
+// the strings in their function responses, such as earlier memories of
+// load_memory.
+func newHistory(contents []*genai.Content) *history {
+	h := &history{}
+	for _, c := range contents {
+		if t := normalize(text(c)); t != "" {
+			h.texts = append(h.texts, t)
+		}
+		if c == nil {
+			continue
+		}
+		for _, p := range c.Parts {
+			if p != nil && p.FunctionResponse != nil {
+				h.addStrings(p.FunctionResponse.Response)
+			}
+		}
+	}
+	return h
+}
+
+func (h *history) addStrings(v any) {
+	switch v := v.(type) {
+	case string:
+		if t := normalize(v); t != "" {
+			h.texts = append(h.texts, t)
+		}
+	case []string:
+		for _, s := range v {
+			h.addStrings(s)
+		}
+	case []any:
+		for _, e := range v {
+			h.addStrings(e)
+		}
+	case map[string]any:
+		for _, e := range v {
+			h.addStrings(e)
+		}
+	}
+}
+
+// contains reports whether t is part of a text of h.
+func (h *history) contains(t string) bool {
+	for _, s := range h.texts {
+		if strings.Contains(s, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *history) add(t string) {
+	h.texts = append(h.texts, t)
+}
+
+func (h *history) clone() *history {
+	return &history{texts: append([]string(nil), h.texts...)}
+}
+
+// text returns the text of the non-thought parts of c.
+func text(c *genai.Content) string {
+	if c == nil {
+		return ""
+	}
+	var parts []string
+	for _, p := range c.Parts {
+		if p != nil && p.Text != "" && !p.Thought {
+			parts = append(parts, p.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// normalize folds the case and the spacing of s.
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
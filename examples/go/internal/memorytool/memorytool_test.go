@@ -0,0 +1,186 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorytool
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/fakellm"
+	"github.com/google/adk-docs/examples/go/internal/localsession"
+)
+
+var day1 = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// newMemory returns a memory holding a past session with texts.
+func newMemory(t *testing.T, texts ...string) memory.Service {
+	t.Helper()
+	var events []*session.Event
+	for i, text := range texts {
+		e := session.NewEvent("inv")
+		e.ID = fmt.Sprintf("past-%d", i)
+		e.Timestamp = day1.Add(time.Duration(i) * time.Minute)
+		e.Author = "user"
+		e.Content = genai.NewContentFromText(text, genai.RoleUser)
+		events = append(events, e)
+	}
+	mem := memory.InMemoryService()
+	if err := mem.AddSession(t.Context(), localsession.New("app", "user", "past", nil, events, day1)); err != nil {
+		t.Fatal(err)
+	}
+	return mem
+}
+
+// run sends prompts to an agent with tools in a new session, and returns the
+// requests its model got.
+func run(t *testing.T, mem memory.Service, tools []tool.Tool, turns []fakellm.Turn, prompts ...string) []*model.LLMRequest {
+	t.Helper()
+	llm := fakellm.New("fake", turns...)
+	a, err := llmagent.New(llmagent.Config{Name: "assistant", Model: llm, Tools: tools})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := session.InMemoryService()
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: svc, MemoryService: mem})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range prompts {
+		for _, err := range r.Run(t.Context(), "user", "s1", genai.NewContentFromText(p, genai.RoleUser), agent.RunConfig{}) {
+			if err != nil {
+				t.Fatalf("Run(%q) failed: %v", p, err)
+			}
+		}
+	}
+	return llm.Requests()
+}
+
+func systemInstruction(req *model.LLMRequest) string {
+	if req.Config == nil {
+		return ""
+	}
+	return text(req.Config.SystemInstruction)
+}
+
+// loaded returns the memories of the last load_memory response of req.
+func loaded(t *testing.T, req *model.LLMRequest) []string {
+	t.Helper()
+	for _, c := range slices.Backward(req.Contents) {
+		for _, p := range c.Parts {
+			if p.FunctionResponse != nil && p.FunctionResponse.Name == "load_memory" {
+				memories, _ := p.FunctionResponse.Response["memories"].([]string)
+				return memories
+			}
+		}
+	}
+	t.Fatal("request has no load_memory response")
+	return nil
+}
+
+func loadMemory(query string) fakellm.Turn {
+	return fakellm.Call(&genai.FunctionCall{Name: "load_memory", Args: map[string]any{"query": query}})
+}
+
+func TestLoad(t *testing.T) {
+	mem := newMemory(t, "My favorite project is Alpha.", "I had pasta for lunch.")
+	reqs := run(t, mem, []tool.Tool{NewLoad(Config{})},
+		[]fakellm.Turn{loadMemory("project"), fakellm.Text("Alpha.")},
+		"Which project do I like?")
+	if len(reqs) != 2 {
+		t.Fatalf("model was called %d times, want 2", len(reqs))
+	}
+	if !strings.Contains(systemInstruction(reqs[0]), "`load_memory`") {
+		t.Errorf("system instruction = %q, want it to mention load_memory", systemInstruction(reqs[0]))
+	}
+	if got, want := loaded(t, reqs[1]), []string{"[2025-06-01 12:00] user: My favorite project is Alpha."}; !slices.Equal(got, want) {
+		t.Errorf("load_memory returned %q, want %q", got, want)
+	}
+}
+
+func TestLoadSkipsHistory(t *testing.T) {
+	mem := newMemory(t, "My favorite project is Alpha.", "Alpha is a project planner for gardens.")
+	format := func(m memory.Entry) string { return text(m.Content) }
+	turns := []fakellm.Turn{
+		fakellm.Text("Noted."),
+		loadMemory("project"), loadMemory("project"), fakellm.Text("Alpha."),
+	}
+	reqs := run(t, mem, []tool.Tool{NewLoad(Config{Format: format})}, turns,
+		"My favorite project is Alpha.", "What is Alpha?")
+	if len(reqs) != 4 {
+		t.Fatalf("model was called %d times, want 4", len(reqs))
+	}
+	// The first memory is already in the session; the second was loaded by
+	// the first call.
+	if got, want := loaded(t, reqs[2]), []string{"Alpha is a project planner for gardens."}; !slices.Equal(got, want) {
+		t.Errorf("first load_memory returned %q, want %q", got, want)
+	}
+	if got := loaded(t, reqs[3]); len(got) != 0 {
+		t.Errorf("second load_memory returned %q, want nothing new", got)
+	}
+
+	reqs = run(t, mem, []tool.Tool{NewLoad(Config{Format: format, KeepDuplicates: true})},
+		[]fakellm.Turn{loadMemory("project"), fakellm.Text("Alpha.")}, "My favorite project is Alpha.")
+	if got := loaded(t, reqs[1]); len(got) != 2 {
+		t.Errorf("load_memory with KeepDuplicates returned %q, want both memories", got)
+	}
+}
+
+func TestPreload(t *testing.T) {
+	mem := newMemory(t, "My favorite project is Alpha.", "Alpha is a project planner for gardens.", "I had pasta for lunch.")
+	reqs := run(t, mem, []tool.Tool{NewPreload(Config{Limit: 1})},
+		[]fakellm.Turn{fakellm.Text("Alpha.")}, "Which project do I like?")
+	if len(reqs) != 1 {
+		t.Fatalf("model was called %d times, want 1", len(reqs))
+	}
+	got := systemInstruction(reqs[0])
+	if !strings.Contains(got, "[2025-06-01 12:00] user: My favorite project is Alpha.") {
+		t.Errorf("system instruction = %q, want the first memory", got)
+	}
+	if strings.Contains(got, "planner") || strings.Contains(got, "pasta") {
+		t.Errorf("system instruction = %q, want only one memory", got)
+	}
+	if len(reqs[0].Config.Tools) != 0 {
+		t.Errorf("preload_memory declared %d tools, want none", len(reqs[0].Config.Tools))
+	}
+
+	// A memory that repeats the history is left out.
+	reqs = run(t, mem, []tool.Tool{NewPreload(Config{Format: func(m memory.Entry) string { return "* " + text(m.Content) }})},
+		[]fakellm.Turn{fakellm.Text("Noted."), fakellm.Text("Sure.")},
+		"My favorite project is Alpha.", "Tell me about that project please")
+	got = systemInstruction(reqs[1])
+	if !strings.Contains(got, "* Alpha is a project planner for gardens.") || strings.Contains(got, "* My favorite project is Alpha.") {
+		t.Errorf("system instruction = %q, want only the memory not in the history", got)
+	}
+
+	reqs = run(t, mem, []tool.Tool{NewPreload(Config{})}, []fakellm.Turn{fakellm.Text("Hi.")}, "Hello there")
+	if got := systemInstruction(reqs[0]); got != "" {
+		t.Errorf("system instruction without matching memories = %q, want none", got)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapitool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Auth authenticates the requests of the tools.
+type Auth interface {
+	// Apply adds credentials to req.
+	Apply(ctx context.Context, req *http.Request) error
+}
+
+// APIKey sends an API key in a header, a query parameter or a cookie.
+type APIKey struct {
+	// Name is the name of the header, parameter or cookie.
+	Name string
+	// In is "header", "query" or "cookie". It defaults to "header".
+	In  string
+	Key string
+}
+
+// Apply adds the key to req.
+func (a APIKey) Apply(ctx context.Context, req *http.Request) error {
+	switch a.In {
+	case "", "header":
+		req.Header.Set(a.Name, a.Key)
+	case "query":
+		q := req.URL.Query()
+		q.Set(a.Name, a.Key)
+		req.URL.RawQuery = q.Encode()
+	case "cookie":
+		req.AddCookie(&http.Cookie{Name: a.Name, Value: a.Key})
+	default:
+		return fmt.Errorf("unknown API key location %q", a.In)
+	}
+	return nil
+}
+
+// Bearer sends a bearer token in the Authorization header.
+type Bearer struct {
+	Token string
+}
+
+// Apply adds the token to req.
+func (b Bearer) Apply(ctx context.Context, req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+b.Token)
+	return nil
+}
+
+// ClientCredentials gets bearer tokens with the OAuth 2.0 client credentials
+// grant, and reuses each until shortly before it expires. It must not be
+// copied after first use.
+type ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// Client sends the token requests. It defaults to http.DefaultClient.
+	Client *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time // zero if the token does not expire
+}
+
+// Apply adds a valid token to req, getting a new one if needed.
+func (c *ClientCredentials) Apply(ctx context.Context, req *http.Request) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == "" || !c.expiry.IsZero() && time.Now().After(c.expiry) {
+		if err := c.refresh(ctx); err != nil {
+			return err
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	return nil
+}
+
+// refresh gets a new token. Tokens are renewed a minute, or half their
+// lifetime, before they expire, so that they do not expire on their way.
+func (c *ClientCredentials) refresh(ctx context.Context) error {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get token: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to get token: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get token: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return fmt.Errorf("failed to decode token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return errors.New("got no token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return fmt.Errorf("got a token of type %q, want a bearer token", token.TokenType)
+	}
+	c.token, c.expiry = token.AccessToken, time.Time{}
+	if token.ExpiresIn > 0 {
+		lifetime := time.Duration(token.ExpiresIn) * time.Second
+		c.expiry = time.Now().Add(lifetime - min(time.Minute, lifetime/2))
+	}
+	return nil
+}
@@ -0,0 +1,494 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openapitool generates tools from an OpenAPI 3 document, one per
+// operation, so that an agent can call an HTTP API without a hand-written
+// tool for every endpoint.
+//
+// New parses the document, in JSON or YAML, and returns a tool.Toolset:
+//
+//	orders, err := openapitool.New(openapitool.Config{
+//		Spec: spec,
+//		Auth: openapitool.Bearer{Token: token},
+//	})
+//	...
+//	a, err := llmagent.New(llmagent.Config{
+//		Name:     "order_agent",
+//		Model:    model,
+//		Toolsets: []tool.Toolset{orders},
+//	})
+//
+// A tool is named after the operationId of its operation, or else after its
+// method and path, such as get_orders_orderId. Its parameters are those of
+// the operation, with the JSON schemas of the document, and "body" for the
+// JSON request body. The tool fills in the path, the query, the headers and
+// the cookies of the request from them, and returns the status code of the
+// response and its body, decoded if it is JSON:
+//
+//	{"status": 200, "body": {"state": "shipped"}}
+//
+// A body over Config.MaxResponseBytes is cut short and returned as text,
+// with "truncated": true. An error status adds "error" to the result, so
+// that the model can recover from it.
+package openapitool
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+	"gopkg.in/yaml.v3"
+
+	"github.com/google/adk-docs/examples/go/internal/llmrequest"
+)
+
+// DefaultMaxResponseBytes is the default Config.MaxResponseBytes.
+const DefaultMaxResponseBytes = 16 << 10
+
+// Config configures a Toolset.
+type Config struct {
+	// Name is the name of the toolset. It defaults to the title of the
+	// document.
+	Name string
+	// Spec is the OpenAPI 3 document, in JSON or YAML.
+	Spec []byte
+	// BaseURL is the URL the paths of the document are relative to. It
+	// defaults to the URL of the first server of the document.
+	BaseURL string
+	// Client sends the requests. It defaults to http.DefaultClient.
+	Client *http.Client
+	// Auth, if set, authenticates every request.
+	Auth Auth
+	// MaxResponseBytes is the most bytes of a response body returned to the
+	// model. It defaults to DefaultMaxResponseBytes.
+	MaxResponseBytes int
+	// Filter, if set, selects the tools of the toolset.
+	Filter tool.Predicate
+}
+
+// Toolset is the toolset of the operations of an OpenAPI document.
+type Toolset struct {
+	name   string
+	tools  []tool.Tool
+	filter tool.Predicate
+}
+
+// New parses the document of cfg and returns its toolset.
+func New(cfg Config) (*Toolset, error) {
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.MaxResponseBytes <= 0 {
+		cfg.MaxResponseBytes = DefaultMaxResponseBytes
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(cfg.Spec, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	doc, _ = stringKeys(doc).(map[string]any)
+	if v, _ := doc["openapi"].(string); !strings.HasPrefix(v, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q, want 3.x", v)
+	}
+	if cfg.Name == "" {
+		cfg.Name, _ = lookup(doc, "info", "title").(string)
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = serverURL(doc)
+	}
+	base, err := url.Parse(cfg.BaseURL)
+	if err != nil || !base.IsAbs() {
+		return nil, fmt.Errorf("base URL %q is not an absolute URL", cfg.BaseURL)
+	}
+
+	ops, err := operations(doc)
+	if err != nil {
+		return nil, err
+	}
+	ts := &Toolset{name: cfg.Name, filter: cfg.Filter}
+	for _, op := range ops {
+		ts.tools = append(ts.tools, &operationTool{op: op, cfg: &cfg, base: base})
+	}
+	return ts, nil
+}
+
+// Name returns the name of the toolset.
+func (ts *Toolset) Name() string {
+	return ts.name
+}
+
+// Tools returns a tool per operation of the document, in the order of their
+// paths and methods, without those Config.Filter rejects.
+func (ts *Toolset) Tools(ctx agent.ReadonlyContext) ([]tool.Tool, error) {
+	var tools []tool.Tool
+	for _, t := range ts.tools {
+		if ts.filter == nil || ts.filter(ctx, t) {
+			tools = append(tools, t)
+		}
+	}
+	return tools, nil
+}
+
+// operation is an operation of the document, with its references resolved.
+type operation struct {
+	name        string
+	description string
+	method      string
+	path        string
+	params      []param
+	body        map[string]any // schema of the JSON body, or nil
+	bodyNeeded  bool
+}
+
+type param struct {
+	name     string // name of the argument
+	key      string // name in the request
+	in       string // "path", "query", "header" or "cookie"
+	required bool
+	schema   map[string]any
+}
+
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// operations returns the operations of doc.
+func operations(doc map[string]any) ([]*operation, error) {
+	paths, _ := doc["paths"].(map[string]any)
+	var ops []*operation
+	names := map[string]bool{}
+	for _, p := range sortedKeys(paths) {
+		item, _ := resolve(doc, paths[p]).(map[string]any)
+		for _, method := range methods {
+			o, ok := item[method].(map[string]any)
+			if !ok {
+				continue
+			}
+			op, err := newOperation(doc, p, method, item, o)
+			if err != nil {
+				return nil, fmt.Errorf("operation %s %s: %w", strings.ToUpper(method), p, err)
+			}
+			if names[op.name] {
+				return nil, fmt.Errorf("operation %s %s: duplicate tool name %q", strings.ToUpper(method), p, op.name)
+			}
+			names[op.name] = true
+			ops = append(ops, op)
+		}
+	}
+	return ops, nil
+}
+
+func newOperation(doc map[string]any, path, method string, item, o map[string]any) (*operation, error) {
+	op := &operation{method: strings.ToUpper(method), path: path}
+	if id, _ := o["operationId"].(string); id != "" {
+		op.name = toolName(id)
+	} else {
+		op.name = toolName(method + path)
+	}
+	summary, _ := o["summary"].(string)
+	description, _ := o["description"].(string)
+	op.description = strings.TrimSpace(summary + "\n\n" + description)
+	if op.description == "" {
+		op.description = op.method + " " + path
+	}
+
+	// The parameters of the operation override those of its path.
+	var params []param
+	for _, list := range []any{item["parameters"], o["parameters"]} {
+		list, _ := list.([]any)
+		for _, p := range list {
+			p, _ := resolve(doc, p).(map[string]any)
+			name, _ := p["name"].(string)
+			in, _ := p["in"].(string)
+			if name == "" || !slices.Contains([]string{"path", "query", "header", "cookie"}, in) {
+				return nil, fmt.Errorf("invalid parameter %v", p)
+			}
+			// These headers are set by the tool, as the specification
+			// says.
+			if in == "header" && slices.Contains([]string{"accept", "content-type", "authorization"}, strings.ToLower(name)) {
+				continue
+			}
+			schema, _ := inline(doc, p["schema"]).(map[string]any)
+			if schema == nil {
+				schema = map[string]any{"type": "string"}
+			}
+			if d, ok := p["description"].(string); ok {
+				schema["description"] = d
+			}
+			required, _ := p["required"].(bool)
+			params = slices.DeleteFunc(params, func(q param) bool { return q.key == name && q.in == in })
+			params = append(params, param{key: name, in: in, required: required || in == "path", schema: schema})
+		}
+	}
+	// Parameters in different places may share a name; those of the path
+	// keep it.
+	taken := map[string]bool{"body": true}
+	for _, p := range params {
+		if p.in == "path" {
+			taken[p.key] = true
+		}
+	}
+	for i, p := range params {
+		params[i].name = p.key
+		if p.in != "path" {
+			if taken[p.key] {
+				params[i].name = p.in + "_" + p.key
+			}
+			taken[params[i].name] = true
+		}
+	}
+	op.params = params
+
+	if rb, ok := resolve(doc, o["requestBody"]).(map[string]any); ok {
+		content, _ := rb["content"].(map[string]any)
+		for mimeType, media := range content {
+			if mt, _, _ := mime.ParseMediaType(mimeType); mt != "application/json" && !strings.HasSuffix(mt, "+json") {
+				continue
+			}
+			media, _ := media.(map[string]any)
+			op.body, _ = inline(doc, media["schema"]).(map[string]any)
+			if op.body == nil {
+				op.body = map[string]any{}
+			}
+			if d, ok := rb["description"].(string); ok {
+				op.body["description"] = d
+			}
+			op.bodyNeeded, _ = rb["required"].(bool)
+		}
+		if op.body == nil && len(content) > 0 {
+			return nil, fmt.Errorf("unsupported request body types %v, want JSON", sortedKeys(content))
+		}
+	}
+	return op, nil
+}
+
+var invalidName = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// toolName turns s into a valid function name of at most 64 characters.
+func toolName(s string) string {
+	s = strings.Trim(invalidName.ReplaceAllString(s, "_"), "_")
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		s = "op_" + s
+	}
+	if len(s) > 64 {
+		s = s[:64]
+	}
+	return s
+}
+
+// serverURL returns the URL of the first server of doc, with the default
+// values of its variables.
+func serverURL(doc map[string]any) string {
+	servers, _ := doc["servers"].([]any)
+	if len(servers) == 0 {
+		return ""
+	}
+	server, _ := servers[0].(map[string]any)
+	u, _ := server["url"].(string)
+	vars, _ := server["variables"].(map[string]any)
+	for name, v := range vars {
+		v, _ := v.(map[string]any)
+		u = strings.ReplaceAll(u, "{"+name+"}", fmt.Sprint(v["default"]))
+	}
+	return u
+}
+
+type operationTool struct {
+	op   *operation
+	cfg  *Config
+	base *url.URL
+}
+
+func (t *operationTool) Name() string {
+	return t.op.name
+}
+
+func (t *operationTool) Description() string {
+	return t.op.description
+}
+
+func (t *operationTool) IsLongRunning() bool {
+	return false
+}
+
+func (t *operationTool) Declaration() *genai.FunctionDeclaration {
+	properties := map[string]any{}
+	required := []string{}
+	for _, p := range t.op.params {
+		properties[p.name] = p.schema
+		if p.required {
+			required = append(required, p.name)
+		}
+	}
+	if t.op.body != nil {
+		properties["body"] = t.op.body
+		if t.op.bodyNeeded {
+			required = append(required, "body")
+		}
+	}
+	return &genai.FunctionDeclaration{
+		Name:        t.op.name,
+		Description: t.op.description,
+		ParametersJsonSchema: map[string]any{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		},
+	}
+}
+
+// ProcessRequest adds the tool to req.
+func (t *operationTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	return llmrequest.AddTool(req, t)
+}
+
+// Run sends the request of the operation built from args and returns its
+// response, as described in the package documentation.
+func (t *operationTool) Run(ctx tool.Context, args any) (map[string]any, error) {
+	m, ok := args.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected args type, got: %T", args)
+	}
+	req, err := t.request(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(t.cfg.MaxResponseBytes)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	result := map[string]any{"status": resp.StatusCode}
+	if resp.StatusCode >= 400 {
+		result["error"] = resp.Status
+	}
+	switch mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); {
+	case len(data) > t.cfg.MaxResponseBytes:
+		result["body"] = strings.ToValidUTF8(string(data[:t.cfg.MaxResponseBytes]), "")
+		result["truncated"] = true
+	case len(data) == 0:
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		var body any
+		if err := json.Unmarshal(data, &body); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		result["body"] = body
+	default:
+		result["body"] = string(data)
+	}
+	return result, nil
+}
+
+// request returns the HTTP request of the operation for args.
+func (t *operationTool) request(ctx tool.Context, args map[string]any) (*http.Request, error) {
+	path := t.op.path
+	query := url.Values{}
+	header := http.Header{}
+	var cookies []*http.Cookie
+	for _, p := range t.op.params {
+		v, ok := args[p.name]
+		if !ok || v == nil {
+			if p.required {
+				return nil, fmt.Errorf("missing required argument %q", p.name)
+			}
+			continue
+		}
+		values := format(v)
+		switch p.in {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+p.key+"}", url.PathEscape(strings.Join(values, ",")))
+		case "query":
+			if obj, ok := v.(map[string]any); ok {
+				for _, k := range sortedKeys(obj) {
+					query[k] = append(query[k], format(obj[k])...)
+				}
+				continue
+			}
+			query[p.key] = append(query[p.key], values...)
+		case "header":
+			header.Set(p.key, strings.Join(values, ","))
+		case "cookie":
+			cookies = append(cookies, &http.Cookie{Name: p.key, Value: strings.Join(values, ",")})
+		}
+	}
+
+	var body io.Reader
+	if b, ok := args["body"]; ok && t.op.body != nil {
+		data, err := json.Marshal(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode body: %w", err)
+		}
+		body = bytes.NewReader(data)
+		header.Set("Content-Type", "application/json")
+	} else if t.op.bodyNeeded {
+		return nil, errors.New(`missing required argument "body"`)
+	}
+
+	u := t.base.JoinPath(path)
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, t.op.method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	header.Set("Accept", "application/json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	if t.cfg.Auth != nil {
+		if err := t.cfg.Auth.Apply(ctx, req); err != nil {
+			return nil, fmt.Errorf("failed to authenticate request: %w", err)
+		}
+	}
+	return req, nil
+}
+
+// format returns the values of an argument as strings. A list has a value
+// per element.
+func format(v any) []string {
+	switch v := v.(type) {
+	case []any:
+		var values []string
+		for _, e := range v {
+			values = append(values, format(e)...)
+		}
+		return values
+	case string:
+		return []string{v}
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case map[string]any:
+		data, _ := json.Marshal(v)
+		return []string{string(data)}
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
@@ -0,0 +1,405 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapitool_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/fakellm"
+	"github.com/google/adk-docs/examples/go/internal/openapitool"
+)
+
+const spec = `
+openapi: 3.0.3
+info:
+  title: orders
+servers:
+  - url: https://orders.example.com/{version}
+    variables:
+      version:
+        default: v1
+paths:
+  /orders/{orderId}:
+    parameters:
+      - $ref: '#/components/parameters/OrderId'
+    get:
+      operationId: getOrder
+      summary: Gets the status of an order.
+      parameters:
+        - name: fields
+          in: query
+          schema:
+            type: array
+            items:
+              type: string
+        - name: X-Request-Id
+          in: header
+          schema:
+            type: string
+        - name: Accept
+          in: header
+          schema:
+            type: string
+      responses:
+        200:
+          description: The order.
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Order'
+  /orders:
+    post:
+      summary: Places an order.
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Order'
+      responses:
+        201:
+          description: The new order.
+  /catalog:
+    get:
+      operationId: listCatalog
+      responses:
+        200:
+          description: The whole catalog.
+components:
+  parameters:
+    OrderId:
+      name: orderId
+      in: path
+      description: The ID of the order.
+      schema:
+        type: string
+  schemas:
+    Order:
+      type: object
+      example: {id: "12345"}
+      properties:
+        id:
+          type: string
+        state:
+          type: string
+          enum: [placed, shipped]
+          nullable: true
+        parent:
+          $ref: '#/components/schemas/Order'
+`
+
+func newToolset(t *testing.T, cfg openapitool.Config) *openapitool.Toolset {
+	t.Helper()
+	cfg.Spec = []byte(spec)
+	ts, err := openapitool.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+func tools(t *testing.T, ts *openapitool.Toolset) map[string]tool.Tool {
+	t.Helper()
+	list, err := ts.Tools(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := map[string]tool.Tool{}
+	for _, tl := range list {
+		m[tl.Name()] = tl
+	}
+	return m
+}
+
+// call has an agent with ts call a function with args, and returns the
+// response of the tool.
+func call(t *testing.T, ts *openapitool.Toolset, name string, args map[string]any) map[string]any {
+	t.Helper()
+	llm := fakellm.New("fake", fakellm.Call(&genai.FunctionCall{Name: name, Args: args}), fakellm.Text("Done."))
+	a, err := llmagent.New(llmagent.Config{Name: "assistant", Model: llm, Toolsets: []tool.Toolset{ts}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := session.InMemoryService()
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessions.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range r.Run(t.Context(), "user", "s1", genai.NewContentFromText("Go ahead.", genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("Run() failed: %v", err)
+		}
+	}
+	reqs := llm.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("model was called %d times, want 2", len(reqs))
+	}
+	for _, p := range reqs[1].Contents[len(reqs[1].Contents)-1].Parts {
+		if p.FunctionResponse != nil && p.FunctionResponse.Name == name {
+			return p.FunctionResponse.Response
+		}
+	}
+	t.Fatalf("second request has no response of %s", name)
+	return nil
+}
+
+func TestTools(t *testing.T) {
+	ts := newToolset(t, openapitool.Config{})
+	if ts.Name() != "orders" {
+		t.Errorf("Name() = %q, want the title of the document", ts.Name())
+	}
+	got := tools(t, ts)
+	if names := slices.Sorted(maps.Keys(got)); !slices.Equal(names, []string{"getOrder", "listCatalog", "post_orders"}) {
+		t.Fatalf("tools = %q, want one per operation", names)
+	}
+
+	decl := got["getOrder"].(interface {
+		Declaration() *genai.FunctionDeclaration
+	}).Declaration()
+	if decl.Description != "Gets the status of an order." {
+		t.Errorf("description = %q, want the summary", decl.Description)
+	}
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"orderId":      map[string]any{"type": "string", "description": "The ID of the order."},
+			"fields":       map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"X-Request-Id": map[string]any{"type": "string"},
+		},
+		"required": []string{"orderId"},
+	}
+	if diff := cmp.Diff(want, decl.ParametersJsonSchema); diff != "" {
+		t.Errorf("getOrder parameters (-want +got):\n%s", diff)
+	}
+
+	decl = got["post_orders"].(interface {
+		Declaration() *genai.FunctionDeclaration
+	}).Declaration()
+	body := decl.ParametersJsonSchema.(map[string]any)["properties"].(map[string]any)["body"].(map[string]any)
+	props := body["properties"].(map[string]any)
+	if diff := cmp.Diff(map[string]any{"type": []any{"string", "null"}, "enum": []any{"placed", "shipped"}}, props["state"]); diff != "" {
+		t.Errorf("state schema (-want +got):\n%s", diff)
+	}
+	if _, ok := body["example"]; ok {
+		t.Error("body schema has an example, want only JSON schema keywords")
+	}
+	// The recursive schema is cut short rather than inlined forever.
+	if diff := cmp.Diff(map[string]any{}, props["parent"]); diff != "" {
+		t.Errorf("parent schema (-want +got):\n%s", diff)
+	}
+	if _, err := json.Marshal(decl.ParametersJsonSchema); err != nil {
+		t.Errorf("parameters do not marshal: %v", err)
+	}
+
+	filtered := newToolset(t, openapitool.Config{Filter: tool.StringPredicate([]string{"getOrder"})})
+	if got := tools(t, filtered); len(got) != 1 || got["getOrder"] == nil {
+		t.Errorf("filtered tools = %v, want getOrder", got)
+	}
+
+	for _, bad := range []string{"swagger: '2.0'", "openapi: 3.0.0\npaths: {}", "{"} {
+		if _, err := openapitool.New(openapitool.Config{Spec: []byte(bad)}); err == nil {
+			t.Errorf("New(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+func TestRecursiveSchemas(t *testing.T) {
+	ts, err := openapitool.New(openapitool.Config{Spec: []byte(`
+openapi: 3.0.3
+info:
+  title: trees
+servers:
+  - url: https://trees.example.com
+paths:
+  /nodes:
+    post:
+      operationId: addNode
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Node'
+      responses:
+        201:
+          description: The new node.
+components:
+  schemas:
+    Node:
+      type: object
+      properties:
+        left:
+          $ref: '#/components/schemas/Node'
+        right:
+          $ref: '#/components/schemas/Node'
+        parent:
+          $ref: '#/components/schemas/Node'
+        label:
+          $ref: '#/components/schemas/Label'
+    Label:
+      type: object
+      properties:
+        text:
+          type: string
+        node:
+          $ref: '#/components/schemas/Node'
+`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decl := tools(t, ts)["addNode"].(interface {
+		Declaration() *genai.FunctionDeclaration
+	}).Declaration()
+	// Each reference back to a schema it is in allows any value, rather than
+	// inlining the schema again.
+	want := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"left":   map[string]any{},
+			"right":  map[string]any{},
+			"parent": map[string]any{},
+			"label": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"text": map[string]any{"type": "string"},
+					"node": map[string]any{},
+				},
+			},
+		},
+	}
+	body := decl.ParametersJsonSchema.(map[string]any)["properties"].(map[string]any)["body"]
+	if diff := cmp.Diff(want, body); diff != "" {
+		t.Errorf("body schema (-want +got):\n%s", diff)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, gotBody = r, string(data)
+		switch {
+		case r.URL.EscapedPath() == "/v2/orders/a%2Fb":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"id": "a/b", "state": "shipped"}`)
+		case r.URL.Path == "/v2/orders":
+			w.WriteHeader(http.StatusCreated)
+		case r.URL.Path == "/v2/catalog":
+			io.WriteString(w, strings.Repeat("item\n", 100))
+		default:
+			http.Error(w, "no such order", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	ts := newToolset(t, openapitool.Config{BaseURL: srv.URL + "/v2", MaxResponseBytes: 40})
+
+	resp := call(t, ts, "getOrder", map[string]any{"orderId": "a/b", "fields": []any{"id", "state"}, "X-Request-Id": "r1"})
+	if diff := cmp.Diff(map[string]any{"status": 200, "body": map[string]any{"id": "a/b", "state": "shipped"}}, resp); diff != "" {
+		t.Errorf("getOrder response (-want +got):\n%s", diff)
+	}
+	if got.URL.RawQuery != "fields=id&fields=state" || got.Header.Get("X-Request-Id") != "r1" || got.Header.Get("Accept") != "application/json" {
+		t.Errorf("getOrder request = %s %v, want the fields in the query and the request ID in a header", got.URL, got.Header)
+	}
+
+	resp = call(t, ts, "getOrder", map[string]any{"orderId": "0"})
+	if resp["status"] != 404 || resp["error"] != "404 Not Found" {
+		t.Errorf("getOrder response = %v, want status 404 and an error", resp)
+	}
+	resp = call(t, ts, "getOrder", map[string]any{})
+	if err := fmt.Sprint(resp["error"]); !strings.Contains(err, `missing required argument "orderId"`) {
+		t.Errorf("getOrder response without an ID = %v, want an error", resp)
+	}
+
+	resp = call(t, ts, "post_orders", map[string]any{"body": map[string]any{"id": "42"}})
+	if resp["status"] != 201 || got.Method != http.MethodPost || gotBody != `{"id":"42"}` || got.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("post_orders = %v with request %s %q, want a POST of the body as JSON", resp, got.Method, gotBody)
+	}
+
+	resp = call(t, ts, "listCatalog", nil)
+	if diff := cmp.Diff(map[string]any{"status": 200, "body": strings.Repeat("item\n", 8), "truncated": true}, resp); diff != "" {
+		t.Errorf("listCatalog response (-want +got):\n%s", diff)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	var tokens atomic.Int32
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			id, secret, _ := r.BasicAuth()
+			r.ParseForm()
+			if id != "client" || secret != "s3cret" || r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("scope") != "orders.read" {
+				http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+				return
+			}
+			tokens.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"access_token":"t0k3n","token_type":"Bearer","expires_in":3600}`)
+			return
+		}
+		got = r
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name  string
+		auth  openapitool.Auth
+		check func(r *http.Request) bool
+	}{
+		{"header key", openapitool.APIKey{Name: "X-API-Key", Key: "k"}, func(r *http.Request) bool { return r.Header.Get("X-API-Key") == "k" }},
+		{"query key", openapitool.APIKey{Name: "key", In: "query", Key: "k"}, func(r *http.Request) bool { return r.URL.Query().Get("key") == "k" }},
+		{"bearer", openapitool.Bearer{Token: "abc"}, func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer abc" }},
+		{"client credentials", &openapitool.ClientCredentials{
+			TokenURL: srv.URL + "/token", ClientID: "client", ClientSecret: "s3cret", Scopes: []string{"orders.read"},
+		}, func(r *http.Request) bool { return r.Header.Get("Authorization") == "Bearer t0k3n" }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := newToolset(t, openapitool.Config{BaseURL: srv.URL, Auth: tc.auth})
+			for range 2 {
+				if resp := call(t, ts, "listCatalog", nil); resp["status"] != 200 || !tc.check(got) {
+					t.Errorf("listCatalog = %v with request %s %v, want it authenticated", resp, got.URL, got.Header)
+				}
+			}
+		})
+	}
+	if n := tokens.Load(); n != 1 {
+		t.Errorf("got %d tokens, want 1 reused", n)
+	}
+
+	ts := newToolset(t, openapitool.Config{BaseURL: srv.URL, Auth: &openapitool.ClientCredentials{TokenURL: srv.URL + "/token", ClientID: "client", ClientSecret: "wrong"}})
+	if err := fmt.Sprint(call(t, ts, "listCatalog", nil)["error"]); !strings.Contains(err, "401") {
+		t.Errorf("listCatalog with a wrong secret failed with %q, want the token error", err)
+	}
+}
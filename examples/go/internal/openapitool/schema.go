@@ -0,0 +1,168 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapitool
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// keywords are the JSON schema keywords kept in the schemas of tools. The
+// others, such as example or xml, only matter to the documentation of the
+// API.
+var keywords = map[string]bool{
+	"type": true, "format": true, "title": true, "description": true,
+	"enum": true, "const": true, "default": true,
+	"properties": true, "required": true, "additionalProperties": true,
+	"items": true, "minItems": true, "maxItems": true, "uniqueItems": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true, "multipleOf": true,
+	"minLength": true, "maxLength": true, "pattern": true,
+	"anyOf": true, "oneOf": true, "allOf": true, "not": true,
+}
+
+// maxDepth is the deepest a schema is inlined; deeper, schemas allow any
+// value.
+const maxDepth = 32
+
+// inline returns a copy of the schema v with its references replaced by what
+// they refer to, and only the keywords of JSON schema. A reference to a
+// schema it is inside of, as in recursive schemas, allows any value.
+func inline(doc map[string]any, v any) any {
+	return inlineRefs(doc, v, map[string]bool{}, 0)
+}
+
+// inlineRefs is inline, inside the schemas refs refers to.
+func inlineRefs(doc map[string]any, v any, refs map[string]bool, depth int) any {
+	s, ok := v.(map[string]any)
+	if !ok {
+		return v
+	}
+	if depth > maxDepth {
+		return map[string]any{}
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		if refs[ref] {
+			return map[string]any{}
+		}
+		refs[ref] = true
+		out := inlineRefs(doc, pointer(doc, ref), refs, depth+1)
+		delete(refs, ref)
+		return out
+	}
+	out := map[string]any{}
+	for k, v := range s {
+		if !keywords[k] {
+			continue
+		}
+		switch k {
+		case "properties":
+			props, _ := v.(map[string]any)
+			inlined := map[string]any{}
+			for name, p := range props {
+				inlined[name] = inlineRefs(doc, p, refs, depth+1)
+			}
+			out[k] = inlined
+		case "items", "additionalProperties", "not":
+			out[k] = inlineRefs(doc, v, refs, depth+1)
+		case "anyOf", "oneOf", "allOf":
+			list, _ := v.([]any)
+			var inlined []any
+			for _, e := range list {
+				inlined = append(inlined, inlineRefs(doc, e, refs, depth+1))
+			}
+			out[k] = inlined
+		default:
+			out[k] = v
+		}
+	}
+	// OpenAPI 3.0 marks schemas that allow null with nullable, where 3.1
+	// and JSON schema add "null" to their types.
+	if nullable, _ := s["nullable"].(bool); nullable {
+		if t, ok := out["type"].(string); ok {
+			out["type"] = []any{t, "null"}
+		}
+	}
+	return out
+}
+
+// resolve returns what v refers to if it is a reference, or v.
+func resolve(doc map[string]any, v any) any {
+	for range maxDepth {
+		m, _ := v.(map[string]any)
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return v
+		}
+		v = pointer(doc, ref)
+	}
+	return nil
+}
+
+// pointer returns the value of doc a local reference such as
+// "#/components/schemas/Order" points to, or nil.
+func pointer(doc map[string]any, ref string) any {
+	p, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil
+	}
+	var keys []string
+	for _, k := range strings.Split(p, "/") {
+		keys = append(keys, strings.ReplaceAll(strings.ReplaceAll(k, "~1", "/"), "~0", "~"))
+	}
+	return lookup(doc, keys...)
+}
+
+// lookup returns the value at keys in v, or nil.
+func lookup(v any, keys ...string) any {
+	for _, k := range keys {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[k]
+	}
+	return v
+}
+
+// stringKeys returns v with the keys of its maps as strings. YAML decodes
+// keys such as the status codes of responses as numbers.
+func stringKeys(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = stringKeys(e)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = stringKeys(e)
+		}
+		return m
+	case []any:
+		for i, e := range v {
+			v[i] = stringKeys(e)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
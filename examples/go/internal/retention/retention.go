@@ -0,0 +1,298 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package retention bounds the sessions a session.Service keeps, by age, by
+// number per user and by number of events per session.
+//
+// A Janitor applies a Policy per app, once with Sweep or periodically in the
+// background with Run:
+//
+//	j := retention.New(svc, retention.Config{
+//		Policies: map[string]retention.Policy{
+//			appName: {TTL: 30 * 24 * time.Hour, MaxSessionsPerUser: 100, MaxEventsPerSession: 500},
+//		},
+//		Archive: []retention.Hook{retention.ArchiveToMemory(memoryService)},
+//	})
+//	go j.Run(ctx, time.Hour)
+//
+// Archive hooks see every session in full before it is deleted or its
+// oldest events are dropped, for example to add it to a memory.Service so
+// that what was said can still be recalled.
+//
+// Deleting a session, or dropping its events, which replaces it (see
+// sessionfork.Trim), would lose an event a runner appends meanwhile. The
+// Janitor reads each session again before it deletes or trims it, only goes
+// on if it has not been updated since it was listed nor for Config.Grace,
+// and leaves it alone if it was updated while the Janitor archived it. A
+// runner that appends to a session idle for longer than the grace period at
+// the very moment it is deleted or replaced can still lose that event, so
+// pick a grace period longer than any pause in a conversation still in
+// progress.
+package retention
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"time"
+
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/session"
+
+	"github.com/google/adk-docs/examples/go/internal/sessionfork"
+)
+
+// Policy bounds the sessions of one app. Zero fields impose no bound.
+type Policy struct {
+	// TTL deletes sessions whose LastUpdateTime is older than this.
+	TTL time.Duration
+	// MaxSessionsPerUser deletes the least recently updated sessions of a
+	// user beyond this number.
+	MaxSessionsPerUser int
+	// MaxEventsPerSession drops the oldest events of a session beyond this
+	// number.
+	MaxEventsPerSession int
+}
+
+// Hook is called with a whole session before the Janitor deletes it or drops
+// some of its events. If it fails, the session is left alone.
+type Hook func(ctx context.Context, sess session.Session) error
+
+// ArchiveToMemory returns a Hook that adds the session to mem.
+func ArchiveToMemory(mem memory.Service) Hook {
+	return func(ctx context.Context, sess session.Session) error {
+		return mem.AddSession(ctx, sess)
+	}
+}
+
+// Config configures a Janitor.
+type Config struct {
+	// Policies holds the policy of each app, by app name. The sessions of
+	// other apps are never touched.
+	Policies map[string]Policy
+	// Archive hooks run in order before a session is deleted or trimmed.
+	Archive []Hook
+	// Grace is how long a session must have gone without an update before
+	// it is deleted or its events are dropped. It defaults to DefaultGrace.
+	Grace time.Duration
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+}
+
+// DefaultGrace is the default Config.Grace.
+const DefaultGrace = 10 * time.Minute
+
+// Janitor applies retention policies to a session.Service.
+type Janitor struct {
+	svc session.Service
+	cfg Config
+}
+
+// New returns a Janitor for svc.
+func New(svc session.Service, cfg Config) *Janitor {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.Grace == 0 {
+		cfg.Grace = DefaultGrace
+	}
+	return &Janitor{svc: svc, cfg: cfg}
+}
+
+// Report counts what a Sweep did.
+type Report struct {
+	Expired int // sessions deleted for their age
+	Evicted int // sessions deleted beyond the per-user maximum
+	Trimmed int // sessions whose oldest events were dropped
+}
+
+// Run sweeps every interval, and once right away, until ctx is done. It
+// logs the errors of a sweep and goes on. It returns ctx.Err().
+func (j *Janitor) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r, err := j.Sweep(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("retention: sweep failed: %v", err)
+		}
+		if r.Expired+r.Evicted+r.Trimmed > 0 {
+			log.Printf("retention: expired %d, evicted %d and trimmed %d sessions", r.Expired, r.Evicted, r.Trimmed)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep applies the policies once. It goes on past a session it fails to
+// handle, and returns the errors joined.
+func (j *Janitor) Sweep(ctx context.Context) (Report, error) {
+	var r Report
+	var errs []error
+	for _, appName := range slices.Sorted(maps.Keys(j.cfg.Policies)) {
+		if err := ctx.Err(); err != nil {
+			return r, err
+		}
+		if err := j.sweepApp(ctx, appName, j.cfg.Policies[appName], &r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return r, errors.Join(errs...)
+}
+
+func (j *Janitor) sweepApp(ctx context.Context, appName string, p Policy, r *Report) error {
+	resp, err := j.svc.List(ctx, &session.ListRequest{AppName: appName})
+	if err != nil {
+		return fmt.Errorf("failed to list sessions of app %s: %w", appName, err)
+	}
+	var errs []error
+	byUser := map[string][]session.Session{}
+	now := j.cfg.Now()
+	for _, sess := range resp.Sessions {
+		if p.TTL > 0 && now.Sub(sess.LastUpdateTime()) > p.TTL {
+			deleted, err := j.delete(ctx, sess)
+			if err != nil {
+				errs = append(errs, err)
+			} else if deleted {
+				r.Expired++
+			}
+			continue
+		}
+		byUser[sess.UserID()] = append(byUser[sess.UserID()], sess)
+	}
+
+	for _, userID := range slices.Sorted(maps.Keys(byUser)) {
+		sessions := byUser[userID]
+		if p.MaxSessionsPerUser > 0 && len(sessions) > p.MaxSessionsPerUser {
+			// Most recently updated first.
+			slices.SortFunc(sessions, func(a, b session.Session) int {
+				return cmp.Or(b.LastUpdateTime().Compare(a.LastUpdateTime()), cmp.Compare(a.ID(), b.ID()))
+			})
+			for _, sess := range sessions[p.MaxSessionsPerUser:] {
+				deleted, err := j.delete(ctx, sess)
+				if err != nil {
+					errs = append(errs, err)
+				} else if deleted {
+					r.Evicted++
+				}
+			}
+			sessions = sessions[:p.MaxSessionsPerUser]
+		}
+		if p.MaxEventsPerSession > 0 {
+			for _, sess := range sessions {
+				trimmed, err := j.trim(ctx, sess, p.MaxEventsPerSession)
+				if err != nil {
+					errs = append(errs, err)
+				} else if trimmed {
+					r.Trimmed++
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// delete archives the session and deletes it, if it has not been updated
+// since it was listed and has been idle for the grace period. A session
+// updated in the meantime is left for the next sweep.
+func (j *Janitor) delete(ctx context.Context, listed session.Session) (bool, error) {
+	sess, err := j.reread(ctx, listed)
+	if err != nil || sess == nil {
+		return false, err
+	}
+	// Taken before the hooks run: a service may update sess in place.
+	updated := sess.LastUpdateTime()
+	if err := j.archive(ctx, sess); err != nil {
+		return false, err
+	}
+	if len(j.cfg.Archive) > 0 {
+		// The session service cannot delete a session only if it is
+		// unchanged, so this leaves a much shorter window than the hooks
+		// took to run.
+		sess, err := j.reread(ctx, listed)
+		if err != nil || sess == nil || !sess.LastUpdateTime().Equal(updated) {
+			return false, err
+		}
+	}
+	err = j.svc.Delete(ctx, &session.DeleteRequest{AppName: sess.AppName(), UserID: sess.UserID(), SessionID: sess.ID()})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete session %s: %w", sess.ID(), err)
+	}
+	return true, nil
+}
+
+// trim archives the session and drops its oldest events, if it has more
+// than keep, has not been updated since it was listed and has been idle for
+// the grace period. A session updated in the meantime is left for the next
+// sweep.
+func (j *Janitor) trim(ctx context.Context, listed session.Session, keep int) (bool, error) {
+	sess, err := j.reread(ctx, listed)
+	if err != nil || sess == nil || sess.Events().Len() <= keep {
+		return false, err
+	}
+	// Taken before the hooks run: a service may update sess in place.
+	updated := sess.LastUpdateTime()
+	if err := j.archive(ctx, sess); err != nil {
+		return false, err
+	}
+	_, err = sessionfork.Trim(ctx, j.svc, &sessionfork.TrimRequest{
+		AppName:        sess.AppName(),
+		UserID:         sess.UserID(),
+		SessionID:      sess.ID(),
+		Keep:           keep,
+		LastUpdateTime: updated,
+	})
+	if errors.Is(err, sessionfork.ErrModified) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to trim session %s: %w", listed.ID(), err)
+	}
+	return true, nil
+}
+
+// reread reads the whole session listed names. It returns nil if the
+// session is gone, was updated since it was listed or has not been idle for
+// the grace period.
+func (j *Janitor) reread(ctx context.Context, listed session.Session) (session.Session, error) {
+	if j.cfg.Now().Sub(listed.LastUpdateTime()) < j.cfg.Grace {
+		return nil, nil
+	}
+	resp, err := j.svc.Get(ctx, &session.GetRequest{AppName: listed.AppName(), UserID: listed.UserID(), SessionID: listed.ID()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session %s: %w", listed.ID(), err)
+	}
+	sess := resp.Session
+	if sess == nil || !sess.LastUpdateTime().Equal(listed.LastUpdateTime()) || j.cfg.Now().Sub(sess.LastUpdateTime()) < j.cfg.Grace {
+		return nil, nil
+	}
+	return sess, nil
+}
+
+// archive runs the archive hooks on sess.
+func (j *Janitor) archive(ctx context.Context, sess session.Session) error {
+	for _, hook := range j.cfg.Archive {
+		if err := hook(ctx, sess); err != nil {
+			return fmt.Errorf("failed to archive session %s: %w", sess.ID(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+var now = time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+
+// add creates a session whose events have the given ages, oldest first.
+func add(t *testing.T, svc session.Service, appName, userID, id string, ages ...time.Duration) {
+	t.Helper()
+	resp, err := svc.Create(t.Context(), &session.CreateRequest{AppName: appName, UserID: userID, SessionID: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, age := range ages {
+		e := session.NewEvent("inv")
+		e.Timestamp = now.Add(-age)
+		e.Author = "agent"
+		e.Content = genai.NewContentFromText(fmt.Sprintf("%s message %d", id, i), genai.RoleModel)
+		if err := svc.AppendEvent(t.Context(), resp.Session, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func sessionIDs(t *testing.T, svc session.Service, appName string) []string {
+	t.Helper()
+	resp, err := svc.List(t.Context(), &session.ListRequest{AppName: appName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, s := range resp.Sessions {
+		ids = append(ids, s.ID())
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestSweep(t *testing.T) {
+	const day = 24 * time.Hour
+	svc := session.InMemoryService()
+	add(t, svc, "app", "alice", "old", 40*day)
+	add(t, svc, "app", "alice", "a1", 3*day)
+	add(t, svc, "app", "alice", "a2", 2*day)
+	add(t, svc, "app", "alice", "a3", 5*day, 4*day, 3*day, 2*day, day)
+	add(t, svc, "app", "bob", "b1", 10*day, 9*day, 8*day)
+	add(t, svc, "other", "alice", "untouched", 400*day)
+
+	mem := memory.InMemoryService()
+	j := New(svc, Config{
+		Policies: map[string]Policy{"app": {TTL: 30 * day, MaxSessionsPerUser: 2, MaxEventsPerSession: 2}},
+		Archive:  []Hook{ArchiveToMemory(mem)},
+		Now:      func() time.Time { return now },
+	})
+	r, err := j.Sweep(t.Context())
+	if err != nil {
+		t.Fatalf("Sweep() failed: %v", err)
+	}
+	if want := (Report{Expired: 1, Evicted: 1, Trimmed: 2}); r != want {
+		t.Errorf("Sweep() = %+v, want %+v", r, want)
+	}
+	if ids := sessionIDs(t, svc, "app"); !slices.Equal(ids, []string{"a2", "a3", "b1"}) {
+		t.Errorf("sessions left = %v, want [a2 a3 b1]", ids)
+	}
+	if ids := sessionIDs(t, svc, "other"); !slices.Equal(ids, []string{"untouched"}) {
+		t.Errorf("sessions of other app = %v, want [untouched]", ids)
+	}
+	for id, userID := range map[string]string{"a3": "alice", "b1": "bob"} {
+		resp, err := svc.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: userID, SessionID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := resp.Session.Events().Len(); n != 2 {
+			t.Errorf("session %s has %d events, want 2", id, n)
+		}
+	}
+
+	// Deleted and trimmed sessions were archived in full.
+	for _, query := range []string{"old message 0", "a1 message 0", "a3 message 0"} {
+		resp, err := mem.Search(t.Context(), &memory.SearchRequest{AppName: "app", UserID: "alice", Query: query})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Memories) == 0 {
+			t.Errorf("memory has nothing for %q", query)
+		}
+	}
+
+	// A second sweep has nothing left to do.
+	if r, err := j.Sweep(t.Context()); err != nil || r != (Report{}) {
+		t.Errorf("second Sweep() = %+v, %v, want nothing done", r, err)
+	}
+}
+
+func TestFailingHook(t *testing.T) {
+	svc := session.InMemoryService()
+	add(t, svc, "app", "alice", "old", 48*time.Hour)
+	j := New(svc, Config{
+		Policies: map[string]Policy{"app": {TTL: time.Hour}},
+		Archive: []Hook{func(ctx context.Context, sess session.Session) error {
+			return errors.New("archive unavailable")
+		}},
+		Now: func() time.Time { return now },
+	})
+	if _, err := j.Sweep(t.Context()); err == nil {
+		t.Error("Sweep() with a failing hook succeeded, want an error")
+	}
+	if ids := sessionIDs(t, svc, "app"); !slices.Equal(ids, []string{"old"}) {
+		t.Errorf("sessions left = %v, want [old] kept", ids)
+	}
+}
+
+func TestGrace(t *testing.T) {
+	svc := session.InMemoryService()
+	add(t, svc, "app", "alice", "busy", 3*time.Minute, 2*time.Minute, time.Minute)
+	clock := now
+	j := New(svc, Config{
+		Policies: map[string]Policy{"app": {MaxEventsPerSession: 1}},
+		Now:      func() time.Time { return clock },
+	})
+	events := func() int {
+		t.Helper()
+		resp, err := svc.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "alice", SessionID: "busy"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Session.Events().Len()
+	}
+
+	if r, err := j.Sweep(t.Context()); err != nil || r.Trimmed != 0 {
+		t.Errorf("Sweep() of a session updated a minute ago = %+v, %v, want nothing trimmed", r, err)
+	}
+	if n := events(); n != 3 {
+		t.Errorf("session has %d events, want 3", n)
+	}
+
+	// A runner appends while the janitor archives the idle session: the
+	// session is left alone rather than losing the event.
+	clock = now.Add(time.Hour)
+	j.cfg.Archive = []Hook{func(ctx context.Context, sess session.Session) error {
+		e := session.NewEvent("inv")
+		e.Timestamp = clock
+		return svc.AppendEvent(ctx, sess, e)
+	}}
+	if r, err := j.Sweep(t.Context()); err != nil || r.Trimmed != 0 {
+		t.Errorf("Sweep() racing an append = %+v, %v, want nothing trimmed", r, err)
+	}
+	if n := events(); n != 4 {
+		t.Errorf("session has %d events, want 4", n)
+	}
+
+	j.cfg.Archive = nil
+	clock = now.Add(2 * time.Hour)
+	if r, err := j.Sweep(t.Context()); err != nil || r.Trimmed != 1 {
+		t.Errorf("Sweep() of an idle session = %+v, %v, want it trimmed", r, err)
+	}
+	if n := events(); n != 1 {
+		t.Errorf("session has %d events, want 1", n)
+	}
+}
+
+// appendOnList appends an event of the given age to session id right after
+// it lists the sessions, as a runner could while the Janitor sweeps.
+type appendOnList struct {
+	session.Service
+	id  string
+	age time.Duration
+}
+
+func (s appendOnList) List(ctx context.Context, req *session.ListRequest) (*session.ListResponse, error) {
+	resp, err := s.Service.List(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	got, err := s.Service.Get(ctx, &session.GetRequest{AppName: req.AppName, UserID: "alice", SessionID: s.id})
+	if err != nil {
+		return nil, err
+	}
+	e := session.NewEvent("inv")
+	e.Timestamp = now.Add(-s.age)
+	return resp, s.Service.AppendEvent(ctx, got.Session, e)
+}
+
+func TestDeleteUpdated(t *testing.T) {
+	const day = 24 * time.Hour
+	for _, c := range []struct {
+		name   string
+		policy Policy
+	}{
+		{"expired", Policy{TTL: 3 * day}},
+		{"evicted", Policy{MaxSessionsPerUser: 1}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			svc := session.InMemoryService()
+			add(t, svc, "app", "alice", "stale", 5*day)
+			add(t, svc, "app", "alice", "recent", day)
+			// Still past the TTL and less recent than the other session,
+			// but no longer what was listed.
+			j := New(appendOnList{svc, "stale", 4 * day}, Config{
+				Policies: map[string]Policy{"app": c.policy},
+				Now:      func() time.Time { return now },
+			})
+			if r, err := j.Sweep(t.Context()); err != nil || r != (Report{}) {
+				t.Errorf("Sweep() updating the session after listing it = %+v, %v, want nothing done", r, err)
+			}
+			if ids := sessionIDs(t, svc, "app"); !slices.Equal(ids, []string{"recent", "stale"}) {
+				t.Errorf("sessions left = %v, want [recent stale]", ids)
+			}
+
+			// A runner appends while the janitor archives the session.
+			j = New(svc, Config{
+				Policies: map[string]Policy{"app": c.policy},
+				Archive: []Hook{func(ctx context.Context, sess session.Session) error {
+					e := session.NewEvent("inv")
+					e.Timestamp = now.Add(-3 * day)
+					return svc.AppendEvent(ctx, sess, e)
+				}},
+				Now: func() time.Time { return now },
+			})
+			if r, err := j.Sweep(t.Context()); err != nil || r != (Report{}) {
+				t.Errorf("Sweep() racing an append = %+v, %v, want nothing done", r, err)
+			}
+			if ids := sessionIDs(t, svc, "app"); !slices.Equal(ids, []string{"recent", "stale"}) {
+				t.Errorf("sessions left = %v, want [recent stale]", ids)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	svc := session.InMemoryService()
+	add(t, svc, "app", "alice", "old", 48*time.Hour)
+	j := New(svc, Config{
+		Policies: map[string]Policy{"app": {TTL: time.Hour}},
+		Now:      func() time.Time { return now },
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error)
+	go func() { done <- j.Run(ctx, time.Millisecond) }()
+	deadline := time.After(5 * time.Second)
+	for len(sessionIDs(t, svc, "app")) > 0 {
+		select {
+		case <-deadline:
+			t.Fatal("Run() did not delete the expired session")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Run() = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after cancellation")
+	}
+}
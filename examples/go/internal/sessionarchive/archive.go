@@ -0,0 +1,205 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sessionarchive moves sessions between session.Service
+// implementations through a versioned JSON archive.
+//
+// Export reads a session through any service; Import rebuilds it in another
+// one through Create and AppendEvent, then reads it back and reports every
+// field the target did not keep. An archive holds the events with their
+// genai parts, inline blobs included as base64, the state of each scope and
+// the timestamps.
+package sessionarchive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// Version is the archive format version this package writes. Read accepts
+// archives up to this version.
+const Version = 1
+
+// Archive is the top-level JSON document.
+type Archive struct {
+	Version    int        `json:"version"`
+	ExportTime time.Time  `json:"exportTime"`
+	Sessions   []*Session `json:"sessions"`
+}
+
+// Session is an archived session.
+type Session struct {
+	AppName        string    `json:"appName"`
+	UserID         string    `json:"userId"`
+	ID             string    `json:"id"`
+	LastUpdateTime time.Time `json:"lastUpdateTime"`
+	State          State     `json:"state"`
+	Events         []*Event  `json:"events"`
+}
+
+// State is the state a session sees, split by scope. App and user keys are
+// stored without their prefix.
+type State struct {
+	App     map[string]any `json:"app,omitempty"`
+	User    map[string]any `json:"user,omitempty"`
+	Session map[string]any `json:"session,omitempty"`
+}
+
+// Event is an archived session.Event.
+type Event struct {
+	ID                 string                                      `json:"id"`
+	Timestamp          time.Time                                   `json:"timestamp"`
+	InvocationID       string                                      `json:"invocationId,omitempty"`
+	Branch             string                                      `json:"branch,omitempty"`
+	Author             string                                      `json:"author,omitempty"`
+	Content            *genai.Content                              `json:"content,omitempty"`
+	Actions            Actions                                     `json:"actions"`
+	LongRunningToolIDs []string                                    `json:"longRunningToolIds,omitempty"`
+	CustomMetadata     map[string]any                              `json:"customMetadata,omitempty"`
+	CitationMetadata   *genai.CitationMetadata                     `json:"citationMetadata,omitempty"`
+	GroundingMetadata  *genai.GroundingMetadata                    `json:"groundingMetadata,omitempty"`
+	UsageMetadata      *genai.GenerateContentResponseUsageMetadata `json:"usageMetadata,omitempty"`
+	LogprobsResult     *genai.LogprobsResult                       `json:"logprobsResult,omitempty"`
+	AvgLogprobs        float64                                     `json:"avgLogprobs,omitempty"`
+	TurnComplete       bool                                        `json:"turnComplete,omitempty"`
+	Interrupted        bool                                        `json:"interrupted,omitempty"`
+	FinishReason       genai.FinishReason                          `json:"finishReason,omitempty"`
+	ErrorCode          string                                      `json:"errorCode,omitempty"`
+	ErrorMessage       string                                      `json:"errorMessage,omitempty"`
+}
+
+// Actions is an archived session.EventActions.
+type Actions struct {
+	StateDelta        map[string]any   `json:"stateDelta,omitempty"`
+	ArtifactDelta     map[string]int64 `json:"artifactDelta,omitempty"`
+	SkipSummarization bool             `json:"skipSummarization,omitempty"`
+	TransferToAgent   string           `json:"transferToAgent,omitempty"`
+	Escalate          bool             `json:"escalate,omitempty"`
+}
+
+// Read decodes an archive and checks its version.
+func Read(r io.Reader) (*Archive, error) {
+	var a Archive
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, fmt.Errorf("failed to decode archive: %w", err)
+	}
+	if a.Version < 1 || a.Version > Version {
+		return nil, fmt.Errorf("unsupported archive version %d, want 1 to %d", a.Version, Version)
+	}
+	return &a, nil
+}
+
+// Write encodes a as indented JSON, setting its version.
+func Write(w io.Writer, a *Archive) error {
+	a.Version = Version
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+// NewSession archives sess, which should hold all of its events.
+func NewSession(sess session.Session) *Session {
+	s := &Session{
+		AppName:        sess.AppName(),
+		UserID:         sess.UserID(),
+		ID:             sess.ID(),
+		LastUpdateTime: sess.LastUpdateTime(),
+		State:          State{App: map[string]any{}, User: map[string]any{}, Session: map[string]any{}},
+		Events:         []*Event{},
+	}
+	for k, v := range sess.State().All() {
+		if key, ok := strings.CutPrefix(k, session.KeyPrefixApp); ok {
+			s.State.App[key] = v
+		} else if key, ok := strings.CutPrefix(k, session.KeyPrefixUser); ok {
+			s.State.User[key] = v
+		} else {
+			s.State.Session[k] = v
+		}
+	}
+	for e := range sess.Events().All() {
+		s.Events = append(s.Events, NewEvent(e))
+	}
+	return s
+}
+
+// NewEvent archives e.
+func NewEvent(e *session.Event) *Event {
+	return &Event{
+		ID:                 e.ID,
+		Timestamp:          e.Timestamp,
+		InvocationID:       e.InvocationID,
+		Branch:             e.Branch,
+		Author:             e.Author,
+		Content:            e.Content,
+		LongRunningToolIDs: e.LongRunningToolIDs,
+		CustomMetadata:     e.CustomMetadata,
+		CitationMetadata:   e.CitationMetadata,
+		GroundingMetadata:  e.GroundingMetadata,
+		UsageMetadata:      e.UsageMetadata,
+		LogprobsResult:     e.LogprobsResult,
+		AvgLogprobs:        e.AvgLogprobs,
+		TurnComplete:       e.TurnComplete,
+		Interrupted:        e.Interrupted,
+		FinishReason:       e.FinishReason,
+		ErrorCode:          e.ErrorCode,
+		ErrorMessage:       e.ErrorMessage,
+		Actions: Actions{
+			StateDelta:        e.Actions.StateDelta,
+			ArtifactDelta:     e.Actions.ArtifactDelta,
+			SkipSummarization: e.Actions.SkipSummarization,
+			TransferToAgent:   e.Actions.TransferToAgent,
+			Escalate:          e.Actions.Escalate,
+		},
+	}
+}
+
+// SessionEvent returns the session.Event e archives.
+func (e *Event) SessionEvent() *session.Event {
+	return &session.Event{
+		LLMResponse: model.LLMResponse{
+			Content:           e.Content,
+			CitationMetadata:  e.CitationMetadata,
+			GroundingMetadata: e.GroundingMetadata,
+			UsageMetadata:     e.UsageMetadata,
+			CustomMetadata:    e.CustomMetadata,
+			LogprobsResult:    e.LogprobsResult,
+			TurnComplete:      e.TurnComplete,
+			Interrupted:       e.Interrupted,
+			ErrorCode:         e.ErrorCode,
+			ErrorMessage:      e.ErrorMessage,
+			FinishReason:      e.FinishReason,
+			AvgLogprobs:       e.AvgLogprobs,
+		},
+		ID:           e.ID,
+		Timestamp:    e.Timestamp,
+		InvocationID: e.InvocationID,
+		Branch:       e.Branch,
+		Author:       e.Author,
+		Actions: session.EventActions{
+			StateDelta:        e.Actions.StateDelta,
+			ArtifactDelta:     e.Actions.ArtifactDelta,
+			SkipSummarization: e.Actions.SkipSummarization,
+			TransferToAgent:   e.Actions.TransferToAgent,
+			Escalate:          e.Actions.Escalate,
+		},
+		LongRunningToolIDs: e.LongRunningToolIDs,
+	}
+}
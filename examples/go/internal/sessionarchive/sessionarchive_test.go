@@ -0,0 +1,212 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessionarchive
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/filesession"
+	"github.com/google/adk-docs/examples/go/internal/sqlitesession"
+)
+
+// source returns a session with every kind of content an archive carries.
+func source(t *testing.T) session.Session {
+	t.Helper()
+	ctx := t.Context()
+	svc := session.InMemoryService()
+	resp, err := svc.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1",
+		State: map[string]any{"app:version": "2", "user:name": "Ada", "topic": "cats"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	newEvent := func(i int, author string, content *genai.Content) *session.Event {
+		e := session.NewEvent("inv1")
+		e.Timestamp = start.Add(time.Duration(i) * time.Second)
+		e.Author = author
+		e.Content = content
+		return e
+	}
+
+	user := newEvent(0, "user", &genai.Content{Role: genai.RoleUser, Parts: []*genai.Part{
+		genai.NewPartFromText("What is in this picture?"),
+		genai.NewPartFromBytes([]byte{0x89, 'P', 'N', 'G', 0, 1, 2}, "image/png"),
+	}})
+	call := newEvent(1, "agent", genai.NewContentFromFunctionCall("describe", map[string]any{"detail": 3}, genai.RoleModel))
+	call.Content.Parts[0].FunctionCall.ID = "call1"
+	call.LongRunningToolIDs = []string{"call1"}
+	result := newEvent(2, "agent", genai.NewContentFromFunctionResponse("describe", map[string]any{"animals": []any{"cat"}}, genai.RoleUser))
+	result.Actions.StateDelta = map[string]any{"app:seen": 1, "user:last": "cat", "count": 1, "temp:scratch": true}
+	result.Actions.ArtifactDelta = map[string]int64{"picture.png": 0}
+	reply := newEvent(3, "agent", genai.NewContentFromText("A cat.", genai.RoleModel))
+	reply.Branch = "root.agent"
+	reply.TurnComplete = true
+	reply.FinishReason = genai.FinishReasonStop
+	reply.CustomMetadata = map[string]any{"source": "test"}
+	reply.UsageMetadata = &genai.GenerateContentResponseUsageMetadata{PromptTokenCount: 12, CandidatesTokenCount: 3}
+
+	for _, e := range []*session.Event{user, call, result, reply} {
+		if err := svc.AppendEvent(ctx, resp.Session, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := svc.Get(ctx, &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got.Session
+}
+
+func TestRoundTrip(t *testing.T) {
+	for name, newService := range map[string]func(t *testing.T) session.Service{
+		"memory": func(t *testing.T) session.Service { return session.InMemoryService() },
+		"files": func(t *testing.T) session.Service {
+			s, err := filesession.Open(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+		"sqlite": func(t *testing.T) session.Service {
+			s, err := sqlitesession.Open(filepath.Join(t.TempDir(), "sessions.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { s.Close() })
+			return s
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, &Archive{Sessions: []*Session{NewSession(source(t))}}); err != nil {
+				t.Fatalf("Write() failed: %v", err)
+			}
+			a, err := Read(&buf)
+			if err != nil {
+				t.Fatalf("Read() failed: %v", err)
+			}
+
+			svc := newService(t)
+			report, err := Import(t.Context(), svc, a.Sessions[0])
+			if err != nil {
+				t.Fatalf("Import() failed: %v", err)
+			}
+			for _, l := range report.Losses {
+				t.Errorf("Import() lost %s", l)
+			}
+
+			got, err := Export(t.Context(), svc, "app", "user", "s1")
+			if err != nil {
+				t.Fatalf("Export() failed: %v", err)
+			}
+			if n := len(got.Events); n != 4 {
+				t.Fatalf("imported session has %d events, want 4", n)
+			}
+			blob := got.Events[0].Content.Parts[1].InlineData
+			if blob == nil || !bytes.Equal(blob.Data, []byte{0x89, 'P', 'N', 'G', 0, 1, 2}) || blob.MIMEType != "image/png" {
+				t.Errorf("imported inline data = %+v, want the PNG bytes", blob)
+			}
+			if v := got.State.User["last"]; v != "cat" {
+				t.Errorf("imported user state last = %v, want cat", v)
+			}
+			if _, ok := got.State.Session["scratch"]; ok {
+				t.Error("imported session kept a temp: key")
+			}
+		})
+	}
+}
+
+// lossyService drops the custom metadata of the events it stores.
+type lossyService struct {
+	session.Service
+}
+
+func (s lossyService) AppendEvent(ctx context.Context, sess session.Session, e *session.Event) error {
+	e.CustomMetadata = nil
+	return s.Service.AppendEvent(ctx, sess, e)
+}
+
+func TestImportReportsLosses(t *testing.T) {
+	s := NewSession(source(t))
+	report, err := Import(t.Context(), lossyService{session.InMemoryService()}, s)
+	if err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+	if len(report.Losses) != 1 {
+		t.Fatalf("Import() reported %d losses, want 1: %v", len(report.Losses), report.Losses)
+	}
+	l := report.Losses[0]
+	if l.EventID != s.Events[3].ID || l.Field != "customMetadata" || l.Got != "" {
+		t.Errorf("Import() reported %+v, want the custom metadata of the last event", l)
+	}
+	if want := `was not kept`; !strings.Contains(l.String(), want) {
+		t.Errorf("Loss.String() = %q, want it to contain %q", l.String(), want)
+	}
+}
+
+// createRecorder remembers the state sessions are created with.
+type createRecorder struct {
+	session.Service
+	state map[string]any
+}
+
+func (s *createRecorder) Create(ctx context.Context, req *session.CreateRequest) (*session.CreateResponse, error) {
+	s.state = req.State
+	return s.Service.Create(ctx, req)
+}
+
+func TestImportInitialState(t *testing.T) {
+	svc := &createRecorder{Service: session.InMemoryService()}
+	if _, err := Import(t.Context(), svc, NewSession(source(t))); err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+	// The keys the events set are left to the events.
+	want := map[string]any{"app:version": "2", "user:name": "Ada", "topic": "cats"}
+	if diff := cmp.Diff(want, svc.state); diff != "" {
+		t.Errorf("state the session was created with (-want +got):\n%s", diff)
+	}
+}
+
+func TestImportExisting(t *testing.T) {
+	svc := session.InMemoryService()
+	s := NewSession(source(t))
+	if _, err := Import(t.Context(), svc, s); err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+	if _, err := Import(t.Context(), svc, s); err == nil {
+		t.Error("Import() of an existing session succeeded, want an error")
+	}
+}
+
+func TestReadVersion(t *testing.T) {
+	for _, doc := range []string{`{"sessions":[]}`, `{"version":2,"sessions":[]}`, `not json`} {
+		if _, err := Read(strings.NewReader(doc)); err == nil {
+			t.Errorf("Read(%s) succeeded, want an error", doc)
+		}
+	}
+	if _, err := Read(strings.NewReader(`{"version":1,"sessions":[]}`)); err != nil {
+		t.Errorf("Read() of a version 1 archive failed: %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessionarchive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"google.golang.org/adk/session"
+
+	"github.com/google/adk-docs/examples/go/internal/localsession"
+)
+
+// Export archives a session of svc.
+func Export(ctx context.Context, svc session.Service, appName, userID, sessionID string) (*Session, error) {
+	resp, err := svc.Get(ctx, &session.GetRequest{AppName: appName, UserID: userID, SessionID: sessionID})
+	if err != nil {
+		return nil, err
+	}
+	return NewSession(resp.Session), nil
+}
+
+// ExportAll archives every session of an app, or of one of its users if
+// userID is set.
+func ExportAll(ctx context.Context, svc session.Service, appName, userID string) ([]*Session, error) {
+	resp, err := svc.List(ctx, &session.ListRequest{AppName: appName, UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	sessions := []*Session{}
+	for _, sess := range resp.Sessions {
+		s, err := Export(ctx, svc, appName, sess.UserID(), sess.ID())
+		if err != nil {
+			return nil, fmt.Errorf("failed to export session %s: %w", sess.ID(), err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// Loss is something an import did not carry over to the target service.
+type Loss struct {
+	EventID string // empty for state and session fields
+	Field   string // the JSON name of the field, such as "content" or "state.user.theme"
+	Want    string // JSON encoding in the archive
+	Got     string // JSON encoding in the target, or empty if missing
+}
+
+func (l Loss) String() string {
+	where := l.Field
+	if l.EventID != "" {
+		where = fmt.Sprintf("event %s: %s", l.EventID, l.Field)
+	}
+	if l.Got == "" {
+		return fmt.Sprintf("%s: %s was not kept", where, l.Want)
+	}
+	return fmt.Sprintf("%s: archived %s, got %s", where, l.Want, l.Got)
+}
+
+// Report is the outcome of an Import.
+type Report struct {
+	Session session.Session // as read back from the target
+	Losses  []Loss
+}
+
+// Import creates s in svc, with the state its events do not set, and
+// appends its events in order, whose deltas set the rest. The app and user
+// keys of the target that no event sets are overwritten with the archived
+// values. Timestamps and event IDs are kept, but a service sets a session's
+// creation time itself, so a session without events gets a new
+// LastUpdateTime.
+//
+// Import then reads the session back and compares it with s, field by field
+// of the JSON encoding; values that encode the same, like an int and the
+// float64 a JSON store turns it into, are not a loss. It fails only if the
+// session cannot be created or an event cannot be appended.
+func Import(ctx context.Context, svc session.Service, s *Session) (*Report, error) {
+	// The session is created with the state it had before its events, as
+	// far as the archive tells: the keys the events set get their values
+	// from the deltas. Creating it with their final values would set the
+	// app and user keys, which the target shares with its other sessions,
+	// back to older values as the events are replayed.
+	state := localsession.MergeState(s.State.App, s.State.User, s.State.Session)
+	for _, e := range s.Events {
+		for k := range e.Actions.StateDelta {
+			delete(state, k)
+		}
+	}
+	created, err := svc.Create(ctx, &session.CreateRequest{AppName: s.AppName, UserID: s.UserID, SessionID: s.ID, State: state})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session %s: %w", s.ID, err)
+	}
+	for _, e := range s.Events {
+		if err := svc.AppendEvent(ctx, created.Session, e.SessionEvent()); err != nil {
+			return nil, fmt.Errorf("failed to append event %s: %w", e.ID, err)
+		}
+	}
+
+	resp, err := svc.Get(ctx, &session.GetRequest{AppName: s.AppName, UserID: s.UserID, SessionID: s.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read back session %s: %w", s.ID, err)
+	}
+	return &Report{Session: resp.Session, Losses: Compare(s, NewSession(resp.Session))}, nil
+}
+
+// Compare lists how got differs from want.
+func Compare(want, got *Session) []Loss {
+	var losses []Loss
+	if len(want.Events) > 0 && !want.LastUpdateTime.Equal(got.LastUpdateTime) {
+		losses = append(losses, Loss{Field: "lastUpdateTime", Want: encode(want.LastUpdateTime), Got: encode(got.LastUpdateTime)})
+	}
+	losses = append(losses, compareMaps("", "state.app.", want.State.App, got.State.App)...)
+	losses = append(losses, compareMaps("", "state.user.", want.State.User, got.State.User)...)
+	losses = append(losses, compareMaps("", "state.session.", want.State.Session, got.State.Session)...)
+
+	gotByID := map[string]*Event{}
+	for _, e := range got.Events {
+		gotByID[e.ID] = e
+	}
+	for _, e := range want.Events {
+		g, ok := gotByID[e.ID]
+		if !ok {
+			losses = append(losses, Loss{EventID: e.ID, Field: "event", Want: "the event"})
+			continue
+		}
+		losses = append(losses, compareMaps(e.ID, "", fields(e), fields(g))...)
+	}
+	return losses
+}
+
+// fields returns the top-level fields of the JSON encoding of e.
+func fields(e *Event) map[string]any {
+	var m map[string]any
+	if err := json.Unmarshal([]byte(encode(e)), &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+func compareMaps(eventID, prefix string, want, got map[string]any) []Loss {
+	var losses []Loss
+	for _, k := range slices.Sorted(maps.Keys(want)) {
+		w := encode(want[k])
+		g, ok := got[k]
+		switch {
+		case !ok:
+			losses = append(losses, Loss{EventID: eventID, Field: prefix + k, Want: w})
+		case w != encode(g):
+			losses = append(losses, Loss{EventID: eventID, Field: prefix + k, Want: w, Got: encode(g)})
+		}
+	}
+	return losses
+}
+
+// encode returns the compact JSON encoding of v, with map keys sorted.
+func encode(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	// Decode and encode again so that numbers and nested maps of any Go
+	// type compare equal to their JSON form.
+	var norm any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&norm); err != nil {
+		return string(data)
+	}
+	out, _ := json.Marshal(norm)
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,213 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sessionfork branches a conversation from an earlier event, either
+// into a new session (Fork) or by cutting the session itself back (Rewind).
+// Trim drops the oldest events of a session instead.
+//
+// All of them work on any session.Service, through Get, Create, Delete and
+// AppendEvent only. The session-scoped state of the result is recomputed from
+// the state deltas of the events kept. App and user state is shared with
+// other sessions, so it is left as it is: the kept events are appended
+// without their app: and user: deltas, which would otherwise set those keys
+// back to old values.
+package sessionfork
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"google.golang.org/adk/session"
+
+	"github.com/google/adk-docs/examples/go/internal/localsession"
+)
+
+// ForkRequest names the event to fork a session at.
+type ForkRequest struct {
+	AppName   string
+	UserID    string
+	SessionID string
+	// EventID is the last event the fork keeps.
+	EventID string
+	// NewSessionID is the ID of the fork. If empty, the service picks one.
+	NewSessionID string
+}
+
+// Fork creates a session with the events of the session req names up to
+// and including req.EventID. The events keep their IDs and timestamps.
+func Fork(ctx context.Context, svc session.Service, req *ForkRequest) (session.Session, error) {
+	src, err := svc.Get(ctx, &session.GetRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID})
+	if err != nil {
+		return nil, err
+	}
+	state, events, err := cut(src.Session, req.EventID)
+	if err != nil {
+		return nil, err
+	}
+	return create(ctx, svc, req.AppName, req.UserID, req.NewSessionID, state, events)
+}
+
+// RewindRequest names the event to rewind a session to.
+type RewindRequest struct {
+	AppName   string
+	UserID    string
+	SessionID string
+	// EventID is the last event the session keeps.
+	EventID string
+}
+
+// Rewind drops the events after req.EventID from the session req names and
+// returns the session as it is now.
+//
+// A session.Service cannot remove events, so Rewind deletes the session and
+// creates it again with the same ID. This is not atomic: other writers must
+// not use the session meanwhile. If creating it again fails, Rewind tries to
+// restore the session as it was before returning the error.
+func Rewind(ctx context.Context, svc session.Service, req *RewindRequest) (session.Session, error) {
+	src, err := svc.Get(ctx, &session.GetRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID})
+	if err != nil {
+		return nil, err
+	}
+	state, events, err := cut(src.Session, req.EventID)
+	if err != nil {
+		return nil, err
+	}
+	return replace(ctx, svc, src.Session, state, events)
+}
+
+// TrimRequest names a session to drop the oldest events of.
+type TrimRequest struct {
+	AppName   string
+	UserID    string
+	SessionID string
+	// Keep is the number of most recent events the session keeps.
+	Keep int
+	// LastUpdateTime, if set, is the last update time of the session as
+	// the caller read it. Trim then fails with ErrModified if the session
+	// was updated since.
+	LastUpdateTime time.Time
+}
+
+// ErrModified reports a session that was updated since the caller read it.
+var ErrModified = errors.New("session was modified")
+
+// Trim drops all but the req.Keep most recent events of the session req
+// names, and returns the session as it is now. It keeps fewer if the first
+// event kept would be a function response, so that it keeps no response
+// without its call. The session state is left as it is. Like Rewind, Trim
+// replaces the session and must not race other writers; req.LastUpdateTime
+// narrows the window of a race to the time between reading the session and
+// deleting it.
+func Trim(ctx context.Context, svc session.Service, req *TrimRequest) (session.Session, error) {
+	src, err := svc.Get(ctx, &session.GetRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID})
+	if err != nil {
+		return nil, err
+	}
+	if !req.LastUpdateTime.IsZero() && !src.Session.LastUpdateTime().Equal(req.LastUpdateTime) {
+		return nil, fmt.Errorf("cannot trim session %s: %w", req.SessionID, ErrModified)
+	}
+	all := slices.Collect(src.Session.Events().All())
+	if len(all) <= req.Keep {
+		return src.Session, nil
+	}
+	kept := all[len(all)-max(req.Keep, 0):]
+	// A function response without its call is rejected by the model.
+	for len(kept) > 0 && isFunctionResponse(kept[0]) {
+		kept = kept[1:]
+	}
+	// The kept deltas are the last ones, so appending them again to the
+	// current state leaves it unchanged.
+	_, _, state := localsession.SplitState(maps.Collect(src.Session.State().All()))
+	return replace(ctx, svc, src.Session, state, kept)
+}
+
+// replace deletes src and creates it again with state and events. If that
+// fails, it tries to restore src.
+func replace(ctx context.Context, svc session.Service, src session.Session, state map[string]any, events []*session.Event) (session.Session, error) {
+	del := &session.DeleteRequest{AppName: src.AppName(), UserID: src.UserID(), SessionID: src.ID()}
+	if err := svc.Delete(ctx, del); err != nil {
+		return nil, fmt.Errorf("failed to delete session %s: %w", src.ID(), err)
+	}
+	sess, err := create(ctx, svc, src.AppName(), src.UserID(), src.ID(), state, events)
+	if err == nil {
+		return sess, nil
+	}
+	// Put the whole session back.
+	_ = svc.Delete(ctx, del)
+	all := slices.Collect(src.Events().All())
+	if _, restoreErr := create(ctx, svc, src.AppName(), src.UserID(), src.ID(), initialState(src, all), all); restoreErr != nil {
+		return nil, fmt.Errorf("%w; restoring the session failed too: %v", err, restoreErr)
+	}
+	return nil, err
+}
+
+// cut returns the events of sess up to and including the one with ID
+// eventID, and the session state to create a session with so that
+// appending them recomputes the state they lead to.
+func cut(sess session.Session, eventID string) (map[string]any, []*session.Event, error) {
+	all := slices.Collect(sess.Events().All())
+	for i, e := range all {
+		if e.ID == eventID {
+			return initialState(sess, all[:i+1]), all[:i+1], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("event %s not found in session %s", eventID, sess.ID())
+}
+
+// initialState returns the session-scoped state to create a session with
+// before appending events, as far as it can be known: the keys events set
+// are left out, for their deltas to set, and the others keep their current
+// value, even if an event of sess after events set it.
+func initialState(sess session.Session, events []*session.Event) map[string]any {
+	_, _, state := localsession.SplitState(maps.Collect(sess.State().All()))
+	for _, e := range events {
+		for k := range e.Actions.StateDelta {
+			delete(state, k)
+		}
+	}
+	return state
+}
+
+// create creates a session with state and appends copies of events to it,
+// keeping only their session-scoped state deltas.
+func create(ctx context.Context, svc session.Service, appName, userID, id string, state map[string]any, events []*session.Event) (session.Session, error) {
+	resp, err := svc.Create(ctx, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: id, State: maps.Clone(state)})
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		c := *e
+		_, _, c.Actions.StateDelta = localsession.SplitState(e.Actions.StateDelta)
+		if err := svc.AppendEvent(ctx, resp.Session, &c); err != nil {
+			return nil, fmt.Errorf("failed to append event %s: %w", e.ID, err)
+		}
+	}
+	return resp.Session, nil
+}
+
+func isFunctionResponse(e *session.Event) bool {
+	if e.Content == nil {
+		return false
+	}
+	for _, p := range e.Content.Parts {
+		if p.FunctionResponse != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,256 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessionfork
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/filesession"
+	"github.com/google/adk-docs/examples/go/internal/sqlitesession"
+)
+
+var services = map[string]func(t *testing.T) session.Service{
+	"memory": func(t *testing.T) session.Service { return session.InMemoryService() },
+	"files": func(t *testing.T) session.Service {
+		s, err := filesession.Open(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	},
+	"sqlite": func(t *testing.T) session.Service {
+		s, err := sqlitesession.Open(filepath.Join(t.TempDir(), "sessions.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	},
+}
+
+// ticket creates a session whose events e0 to e3 set "step" to their index,
+// e2 also sets "escalated" and "user:tickets", and e3 "app:open".
+func ticket(t *testing.T, svc session.Service) {
+	t.Helper()
+	resp, err := svc.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1",
+		State: map[string]any{"ticket": "T-1", "step": "new"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		e := session.NewEvent("inv")
+		e.ID = fmt.Sprintf("e%d", i)
+		e.Timestamp = start.Add(time.Duration(i) * time.Minute)
+		e.Author = "agent"
+		e.Content = genai.NewContentFromText(e.ID, genai.RoleModel)
+		e.Actions.StateDelta["step"] = fmt.Sprint(i)
+		switch i {
+		case 2:
+			e.Actions.StateDelta["escalated"] = "yes"
+			e.Actions.StateDelta["user:tickets"] = "1"
+		case 3:
+			e.Actions.StateDelta["app:open"] = "1"
+		}
+		if err := svc.AppendEvent(t.Context(), resp.Session, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func eventIDs(sess session.Session) []string {
+	var ids []string
+	for e := range sess.Events().All() {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func checkState(t *testing.T, sess session.Session, want map[string]any) {
+	t.Helper()
+	for k, v := range sess.State().All() {
+		if w, ok := want[k]; !ok || w != v {
+			t.Errorf("state[%q] = %v, want %v", k, v, want[k])
+		}
+	}
+	for k, w := range want {
+		if _, err := sess.State().Get(k); err != nil {
+			t.Errorf("state[%q] is missing, want %v", k, w)
+		}
+	}
+}
+
+func get(t *testing.T, svc session.Service, id string) session.Session {
+	t.Helper()
+	resp, err := svc.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: id})
+	if err != nil {
+		t.Fatalf("Get(%s) failed: %v", id, err)
+	}
+	return resp.Session
+}
+
+func TestFork(t *testing.T) {
+	for name, newService := range services {
+		t.Run(name, func(t *testing.T) {
+			svc := newService(t)
+			ticket(t, svc)
+
+			fork, err := Fork(t.Context(), svc, &ForkRequest{AppName: "app", UserID: "user", SessionID: "s1", EventID: "e1", NewSessionID: "s2"})
+			if err != nil {
+				t.Fatalf("Fork() failed: %v", err)
+			}
+			if fork.ID() != "s2" {
+				t.Errorf("Fork() ID = %s, want s2", fork.ID())
+			}
+
+			got := get(t, svc, "s2")
+			if ids := eventIDs(got); !slices.Equal(ids, []string{"e0", "e1"}) {
+				t.Errorf("fork events = %v, want [e0 e1]", ids)
+			}
+			if want := time.Date(2025, 6, 1, 12, 1, 0, 0, time.UTC); !got.LastUpdateTime().Equal(want) {
+				t.Errorf("fork LastUpdateTime() = %v, want %v", got.LastUpdateTime(), want)
+			}
+			// The shared scopes keep their current values, and so do the keys
+			// only the events left out set.
+			checkState(t, got, map[string]any{"ticket": "T-1", "step": "1", "escalated": "yes", "user:tickets": "1", "app:open": "1"})
+
+			// The original is untouched.
+			orig := get(t, svc, "s1")
+			if ids := eventIDs(orig); !slices.Equal(ids, []string{"e0", "e1", "e2", "e3"}) {
+				t.Errorf("original events = %v, want [e0 e1 e2 e3]", ids)
+			}
+			checkState(t, orig, map[string]any{"ticket": "T-1", "step": "3", "escalated": "yes", "user:tickets": "1", "app:open": "1"})
+		})
+	}
+}
+
+func TestForkNewID(t *testing.T) {
+	svc := session.InMemoryService()
+	ticket(t, svc)
+	fork, err := Fork(t.Context(), svc, &ForkRequest{AppName: "app", UserID: "user", SessionID: "s1", EventID: "e3"})
+	if err != nil {
+		t.Fatalf("Fork() failed: %v", err)
+	}
+	if fork.ID() == "" || fork.ID() == "s1" {
+		t.Errorf("Fork() ID = %q, want a new ID", fork.ID())
+	}
+	if n := get(t, svc, fork.ID()).Events().Len(); n != 4 {
+		t.Errorf("fork has %d events, want 4", n)
+	}
+}
+
+func TestRewind(t *testing.T) {
+	for name, newService := range services {
+		t.Run(name, func(t *testing.T) {
+			svc := newService(t)
+			ticket(t, svc)
+
+			if _, err := Rewind(t.Context(), svc, &RewindRequest{AppName: "app", UserID: "user", SessionID: "s1", EventID: "e0"}); err != nil {
+				t.Fatalf("Rewind() failed: %v", err)
+			}
+			got := get(t, svc, "s1")
+			if ids := eventIDs(got); !slices.Equal(ids, []string{"e0"}) {
+				t.Errorf("rewound events = %v, want [e0]", ids)
+			}
+			checkState(t, got, map[string]any{"ticket": "T-1", "step": "0", "escalated": "yes", "user:tickets": "1", "app:open": "1"})
+		})
+	}
+}
+
+func TestTrim(t *testing.T) {
+	for name, newService := range services {
+		t.Run(name, func(t *testing.T) {
+			svc := newService(t)
+			ticket(t, svc)
+
+			if _, err := Trim(t.Context(), svc, &TrimRequest{AppName: "app", UserID: "user", SessionID: "s1", Keep: 2}); err != nil {
+				t.Fatalf("Trim() failed: %v", err)
+			}
+			got := get(t, svc, "s1")
+			if ids := eventIDs(got); !slices.Equal(ids, []string{"e2", "e3"}) {
+				t.Errorf("trimmed events = %v, want [e2 e3]", ids)
+			}
+			checkState(t, got, map[string]any{"ticket": "T-1", "step": "3", "escalated": "yes", "user:tickets": "1", "app:open": "1"})
+		})
+	}
+}
+
+func TestTrimModified(t *testing.T) {
+	svc := session.InMemoryService()
+	ticket(t, svc)
+	seen := get(t, svc, "s1").LastUpdateTime()
+	e := session.NewEvent("inv")
+	e.Timestamp = seen.Add(time.Minute)
+	if err := svc.AppendEvent(t.Context(), get(t, svc, "s1"), e); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Trim(t.Context(), svc, &TrimRequest{AppName: "app", UserID: "user", SessionID: "s1", Keep: 2, LastUpdateTime: seen})
+	if !errors.Is(err, ErrModified) {
+		t.Errorf("Trim() of a session updated since = %v, want ErrModified", err)
+	}
+	if n := get(t, svc, "s1").Events().Len(); n != 5 {
+		t.Errorf("session has %d events, want all 5 kept", n)
+	}
+}
+
+func TestTrimKeepsCalls(t *testing.T) {
+	svc := session.InMemoryService()
+	resp, err := svc.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, content := range []*genai.Content{
+		genai.NewContentFromText("hi", genai.RoleUser),
+		genai.NewContentFromFunctionCall("lookup", nil, genai.RoleModel),
+		genai.NewContentFromFunctionResponse("lookup", map[string]any{"ok": true}, genai.RoleUser),
+		genai.NewContentFromText("done", genai.RoleModel),
+	} {
+		e := session.NewEvent("inv")
+		e.ID = fmt.Sprintf("e%d", i)
+		e.Content = content
+		if err := svc.AppendEvent(t.Context(), resp.Session, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := Trim(t.Context(), svc, &TrimRequest{AppName: "app", UserID: "user", SessionID: "s1", Keep: 2}); err != nil {
+		t.Fatalf("Trim() failed: %v", err)
+	}
+	if ids := eventIDs(get(t, svc, "s1")); !slices.Equal(ids, []string{"e3"}) {
+		t.Errorf("trimmed events = %v, want [e3]", ids)
+	}
+}
+
+func TestUnknownEvent(t *testing.T) {
+	svc := session.InMemoryService()
+	ticket(t, svc)
+	if _, err := Fork(t.Context(), svc, &ForkRequest{AppName: "app", UserID: "user", SessionID: "s1", EventID: "nope"}); err == nil {
+		t.Error("Fork() at an unknown event succeeded, want an error")
+	}
+	if _, err := Rewind(t.Context(), svc, &RewindRequest{AppName: "app", UserID: "user", SessionID: "s1", EventID: "nope"}); err == nil {
+		t.Error("Rewind() to an unknown event succeeded, want an error")
+	}
+	if n := get(t, svc, "s1").Events().Len(); n != 4 {
+		t.Errorf("session has %d events after failed calls, want 4", n)
+	}
+}
@@ -0,0 +1,487 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sessiontest checks that a session.Service behaves like
+// session.InMemoryService, which the suite is also run against.
+//
+//	func TestService(t *testing.T) {
+//		sessiontest.Run(t, func(t *testing.T) session.Service {
+//			return newService(t)
+//		})
+//	}
+//
+// State values and function call arguments in the suite are strings, so
+// that services which store them as JSON return them unchanged.
+package sessiontest
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+const (
+	appName = "test_app"
+	userID  = "user1"
+)
+
+// Run runs the suite, calling newService for a fresh, empty service in
+// every subtest.
+func Run(t *testing.T, newService func(t *testing.T) session.Service) {
+	t.Run("Create", func(t *testing.T) { testCreate(t, newService) })
+	t.Run("Get", func(t *testing.T) { testGet(t, newService) })
+	t.Run("List", func(t *testing.T) { testList(t, newService) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newService) })
+	t.Run("AppendEvent", func(t *testing.T) { testAppendEvent(t, newService) })
+	t.Run("State", func(t *testing.T) { testState(t, newService) })
+	t.Run("Concurrent", func(t *testing.T) { testConcurrent(t, newService) })
+}
+
+func testCreate(t *testing.T, newService func(t *testing.T) session.Service) {
+	t.Run("full key", func(t *testing.T) {
+		s := newService(t)
+		got := create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1",
+			State: map[string]any{"k": "v"}})
+		if got.ID() != "s1" || got.AppName() != appName || got.UserID() != userID {
+			t.Errorf("Create() = %s/%s/%s, want %s/%s/s1", got.AppName(), got.UserID(), got.ID(), appName, userID)
+		}
+		checkState(t, got, map[string]any{"k": "v"})
+		if got.Events().Len() != 0 {
+			t.Errorf("new session has %d events", got.Events().Len())
+		}
+		if got.LastUpdateTime().IsZero() {
+			t.Error("new session has no LastUpdateTime")
+		}
+	})
+
+	t.Run("generated session id", func(t *testing.T) {
+		s := newService(t)
+		a := create(t, s, &session.CreateRequest{AppName: appName, UserID: userID})
+		b := create(t, s, &session.CreateRequest{AppName: appName, UserID: userID})
+		if a.ID() == "" || a.ID() == b.ID() {
+			t.Errorf("generated session IDs %q and %q, want distinct non-empty IDs", a.ID(), b.ID())
+		}
+	})
+
+	t.Run("fails when already exists", func(t *testing.T) {
+		s := newService(t)
+		create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1"})
+		if _, err := s.Create(t.Context(), &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1"}); err == nil {
+			t.Error("Create() of an existing session succeeded")
+		}
+	})
+
+	t.Run("fails without app or user", func(t *testing.T) {
+		s := newService(t)
+		for _, req := range []*session.CreateRequest{{UserID: userID}, {AppName: appName}} {
+			if _, err := s.Create(t.Context(), req); err == nil {
+				t.Errorf("Create(%+v) succeeded", req)
+			}
+		}
+	})
+
+	t.Run("initial state is scoped", func(t *testing.T) {
+		s := newService(t)
+		create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1",
+			State: map[string]any{"app:a": "1", "user:u": "2", "k": "3"}})
+		other := create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s2"})
+		checkState(t, other, map[string]any{"app:a": "1", "user:u": "2"})
+		checkState(t, get(t, s, "s1"), map[string]any{"app:a": "1", "user:u": "2", "k": "3"})
+	})
+}
+
+func testGet(t *testing.T, newService func(t *testing.T) session.Service) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	withEvents := func(t *testing.T) session.Service {
+		s := newService(t)
+		sess := create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1"})
+		for i := range 4 {
+			appendEvent(t, s, sess, textEvent(fmt.Sprintf("e%d", i), base.Add(time.Duration(i)*time.Minute)))
+		}
+		return s
+	}
+
+	for _, tt := range []struct {
+		name string
+		req  session.GetRequest
+		want []string
+	}{
+		{"all events", session.GetRequest{}, []string{"e0", "e1", "e2", "e3"}},
+		{"num recent events", session.GetRequest{NumRecentEvents: 2}, []string{"e2", "e3"}},
+		{"more recent events than stored", session.GetRequest{NumRecentEvents: 10}, []string{"e0", "e1", "e2", "e3"}},
+		{"after timestamp", session.GetRequest{After: base.Add(time.Minute)}, []string{"e1", "e2", "e3"}},
+		{"combined filters", session.GetRequest{NumRecentEvents: 3, After: base.Add(2 * time.Minute)}, []string{"e2", "e3"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := withEvents(t)
+			req := tt.req
+			req.AppName, req.UserID, req.SessionID = appName, userID, "s1"
+			resp, err := s.Get(t.Context(), &req)
+			if err != nil {
+				t.Fatalf("Get() failed: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, eventIDs(resp.Session)); diff != "" {
+				t.Errorf("Get() events mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("fails when not found", func(t *testing.T) {
+		s := newService(t)
+		if _, err := s.Get(t.Context(), &session.GetRequest{AppName: appName, UserID: userID, SessionID: "missing"}); err == nil {
+			t.Error("Get() of a missing session succeeded")
+		}
+	})
+
+	t.Run("respects user id", func(t *testing.T) {
+		s := newService(t)
+		create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1"})
+		if _, err := s.Get(t.Context(), &session.GetRequest{AppName: appName, UserID: "user2", SessionID: "s1"}); err == nil {
+			t.Error("Get() of another user's session succeeded")
+		}
+	})
+
+	t.Run("fails without key", func(t *testing.T) {
+		s := newService(t)
+		if _, err := s.Get(t.Context(), &session.GetRequest{AppName: appName, UserID: userID}); err == nil {
+			t.Error("Get() without a session ID succeeded")
+		}
+	})
+}
+
+func testList(t *testing.T, newService func(t *testing.T) session.Service) {
+	s := newService(t)
+	for _, k := range [][2]string{{"user1", "s1"}, {"user1", "s2"}, {"user2", "s3"}} {
+		sess := create(t, s, &session.CreateRequest{AppName: appName, UserID: k[0], SessionID: k[1],
+			State: map[string]any{"owner": k[0]}})
+		appendEvent(t, s, sess, textEvent("e", time.Now()))
+	}
+	create(t, s, &session.CreateRequest{AppName: "other_app", UserID: "user1", SessionID: "s4"})
+
+	for _, tt := range []struct {
+		name   string
+		userID string
+		want   []string
+	}{
+		{"one user", "user1", []string{"user1/s1", "user1/s2"}},
+		{"other user", "user2", []string{"user2/s3"}},
+		{"unknown user", "user3", nil},
+		{"all users", "", []string{"user1/s1", "user1/s2", "user2/s3"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.List(t.Context(), &session.ListRequest{AppName: appName, UserID: tt.userID})
+			if err != nil {
+				t.Fatalf("List() failed: %v", err)
+			}
+			var got []string
+			for _, sess := range resp.Sessions {
+				got = append(got, sess.UserID()+"/"+sess.ID())
+				checkState(t, sess, map[string]any{"owner": sess.UserID()})
+			}
+			slices.Sort(got)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("List() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("fails without app", func(t *testing.T) {
+		if _, err := s.List(t.Context(), &session.ListRequest{UserID: userID}); err == nil {
+			t.Error("List() without an app name succeeded")
+		}
+	})
+}
+
+func testDelete(t *testing.T, newService func(t *testing.T) session.Service) {
+	t.Run("deletes", func(t *testing.T) {
+		s := newService(t)
+		sess := create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1"})
+		appendEvent(t, s, sess, textEvent("e", time.Now()))
+		if err := s.Delete(t.Context(), &session.DeleteRequest{AppName: appName, UserID: userID, SessionID: "s1"}); err != nil {
+			t.Fatalf("Delete() failed: %v", err)
+		}
+		if _, err := s.Get(t.Context(), &session.GetRequest{AppName: appName, UserID: userID, SessionID: "s1"}); err == nil {
+			t.Error("Get() of a deleted session succeeded")
+		}
+		// A new session with the same ID starts out empty.
+		again := create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1"})
+		if n := get(t, s, again.ID()).Events().Len(); n != 0 {
+			t.Errorf("recreated session has %d events, want 0", n)
+		}
+	})
+
+	t.Run("no error when not found", func(t *testing.T) {
+		s := newService(t)
+		if err := s.Delete(t.Context(), &session.DeleteRequest{AppName: appName, UserID: userID, SessionID: "missing"}); err != nil {
+			t.Errorf("Delete() of a missing session failed: %v", err)
+		}
+	})
+}
+
+func testAppendEvent(t *testing.T, newService func(t *testing.T) session.Service) {
+	t.Run("updates session and storage", func(t *testing.T) {
+		s := newService(t)
+		sess := create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1"})
+		ts := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		e := fullEvent(ts)
+		appendEvent(t, s, sess, e)
+
+		for name, got := range map[string]session.Session{"passed session": sess, "stored session": get(t, s, "s1")} {
+			if got.Events().Len() != 1 {
+				t.Fatalf("%s has %d events, want 1", name, got.Events().Len())
+			}
+			if diff := cmp.Diff(e, got.Events().At(0), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("%s event mismatch (-want +got):\n%s", name, diff)
+			}
+			if !got.LastUpdateTime().Equal(ts) {
+				t.Errorf("%s LastUpdateTime() = %v, want %v", name, got.LastUpdateTime(), ts)
+			}
+			checkState(t, got, map[string]any{"k": "v"})
+		}
+	})
+
+	t.Run("appends in order", func(t *testing.T) {
+		s := newService(t)
+		sess := create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1"})
+		for _, id := range []string{"a", "b", "c"} {
+			appendEvent(t, s, sess, textEvent(id, time.Now()))
+		}
+		if diff := cmp.Diff([]string{"a", "b", "c"}, eventIDs(get(t, s, "s1"))); diff != "" {
+			t.Errorf("stored events mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("ignores partial events", func(t *testing.T) {
+		s := newService(t)
+		sess := create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1"})
+		e := textEvent("partial", time.Now())
+		e.Partial = true
+		e.Actions.StateDelta = map[string]any{"k": "v"}
+		appendEvent(t, s, sess, e)
+		stored := get(t, s, "s1")
+		if n := stored.Events().Len(); n != 0 {
+			t.Errorf("stored session has %d events, want 0", n)
+		}
+		checkState(t, stored, map[string]any{})
+	})
+
+	t.Run("fails when not found", func(t *testing.T) {
+		s := newService(t)
+		sess := create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1"})
+		if err := s.Delete(t.Context(), &session.DeleteRequest{AppName: appName, UserID: userID, SessionID: "s1"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.AppendEvent(t.Context(), sess, textEvent("e", time.Now())); err == nil {
+			t.Error("AppendEvent() to a deleted session succeeded")
+		}
+	})
+}
+
+func testState(t *testing.T, newService func(t *testing.T) session.Service) {
+	delta := func(kv ...string) *session.Event {
+		e := textEvent("e", time.Now())
+		for i := 0; i < len(kv); i += 2 {
+			e.Actions.StateDelta[kv[i]] = kv[i+1]
+		}
+		return e
+	}
+
+	t.Run("app state is shared", func(t *testing.T) {
+		s := newService(t)
+		s1 := create(t, s, &session.CreateRequest{AppName: appName, UserID: "user1", SessionID: "s1"})
+		appendEvent(t, s, s1, delta("app:k", "v"))
+		s2 := create(t, s, &session.CreateRequest{AppName: appName, UserID: "user2", SessionID: "s2"})
+		checkState(t, s2, map[string]any{"app:k": "v"})
+		other := create(t, s, &session.CreateRequest{AppName: "other_app", UserID: "user1", SessionID: "s3"})
+		checkState(t, other, map[string]any{})
+	})
+
+	t.Run("user state is user specific", func(t *testing.T) {
+		s := newService(t)
+		s1 := create(t, s, &session.CreateRequest{AppName: appName, UserID: "user1", SessionID: "s1"})
+		appendEvent(t, s, s1, delta("user:k", "v"))
+		s2 := create(t, s, &session.CreateRequest{AppName: appName, UserID: "user1", SessionID: "s2"})
+		checkState(t, s2, map[string]any{"user:k": "v"})
+		s3 := create(t, s, &session.CreateRequest{AppName: appName, UserID: "user2", SessionID: "s3"})
+		checkState(t, s3, map[string]any{})
+	})
+
+	t.Run("session state is not shared", func(t *testing.T) {
+		s := newService(t)
+		s1 := create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1"})
+		appendEvent(t, s, s1, delta("k", "v"))
+		s2 := create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s2"})
+		checkState(t, s2, map[string]any{})
+		checkState(t, get(t, s, "s1"), map[string]any{"k": "v"})
+	})
+
+	t.Run("later deltas overwrite", func(t *testing.T) {
+		s := newService(t)
+		s1 := create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1"})
+		appendEvent(t, s, s1, delta("k", "1", "user:u", "1"))
+		appendEvent(t, s, s1, delta("k", "2", "user:u", "2"))
+		want := map[string]any{"k": "2", "user:u": "2"}
+		checkState(t, s1, want)
+		checkState(t, get(t, s, "s1"), want)
+	})
+
+	t.Run("temp state is not persisted", func(t *testing.T) {
+		s := newService(t)
+		s1 := create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1"})
+		e := delta("temp:k", "v", "k", "v")
+		appendEvent(t, s, s1, e)
+		if _, ok := e.Actions.StateDelta["temp:k"]; ok {
+			t.Error("appended event still has the temp: key in its state delta")
+		}
+		stored := get(t, s, "s1")
+		checkState(t, stored, map[string]any{"k": "v"})
+		if got := stored.Events().At(0).Actions.StateDelta; !maps.Equal(got, map[string]any{"k": "v"}) {
+			t.Errorf("stored state delta = %v, want only k", got)
+		}
+	})
+}
+
+// testConcurrent appends to one session through several copies of it at
+// once, as concurrent runners would.
+func testConcurrent(t *testing.T, newService func(t *testing.T) session.Service) {
+	const goroutines, perGoroutine = 8, 10
+	s := newService(t)
+	create(t, s, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "s1"})
+
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := s.Get(t.Context(), &session.GetRequest{AppName: appName, UserID: userID, SessionID: "s1"})
+			if err != nil {
+				t.Errorf("Get() failed: %v", err)
+				return
+			}
+			for i := range perGoroutine {
+				e := textEvent(fmt.Sprintf("g%d-%d", g, i), time.Now())
+				e.Actions.StateDelta[fmt.Sprintf("k%d", g)] = fmt.Sprint(i)
+				e.Actions.StateDelta["user:last"] = e.ID
+				if err := s.AppendEvent(t.Context(), resp.Session, e); err != nil {
+					t.Errorf("AppendEvent() failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	stored := get(t, s, "s1")
+	if n := stored.Events().Len(); n != goroutines*perGoroutine {
+		t.Errorf("stored session has %d events, want %d", n, goroutines*perGoroutine)
+	}
+	for g := range goroutines {
+		key := fmt.Sprintf("k%d", g)
+		if v, err := stored.State().Get(key); err != nil || v != fmt.Sprint(perGoroutine-1) {
+			t.Errorf("State().Get(%q) = %v, %v, want %d", key, v, err, perGoroutine-1)
+		}
+	}
+}
+
+func create(t *testing.T, s session.Service, req *session.CreateRequest) session.Session {
+	t.Helper()
+	resp, err := s.Create(t.Context(), req)
+	if err != nil {
+		t.Fatalf("Create(%+v) failed: %v", req, err)
+	}
+	return resp.Session
+}
+
+func get(t *testing.T, s session.Service, id string) session.Session {
+	t.Helper()
+	resp, err := s.Get(t.Context(), &session.GetRequest{AppName: appName, UserID: userID, SessionID: id})
+	if err != nil {
+		t.Fatalf("Get(%q) failed: %v", id, err)
+	}
+	return resp.Session
+}
+
+func appendEvent(t *testing.T, s session.Service, sess session.Session, e *session.Event) {
+	t.Helper()
+	if err := s.AppendEvent(t.Context(), sess, e); err != nil {
+		t.Fatalf("AppendEvent() failed: %v", err)
+	}
+}
+
+func checkState(t *testing.T, sess session.Session, want map[string]any) {
+	t.Helper()
+	got := maps.Collect(sess.State().All())
+	if diff := cmp.Diff(want, got, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("session %s state mismatch (-want +got):\n%s", sess.ID(), diff)
+	}
+}
+
+func eventIDs(sess session.Session) []string {
+	var ids []string
+	for e := range sess.Events().All() {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func textEvent(id string, ts time.Time) *session.Event {
+	e := session.NewEvent("inv")
+	e.ID = id
+	e.Timestamp = ts
+	e.Author = "agent"
+	e.Content = genai.NewContentFromText("text of "+id, genai.RoleModel)
+	return e
+}
+
+// fullEvent returns an event that sets every field a stored event has to
+// keep.
+func fullEvent(ts time.Time) *session.Event {
+	return &session.Event{
+		LLMResponse: model.LLMResponse{
+			Content: &genai.Content{Role: genai.RoleModel, Parts: []*genai.Part{
+				genai.NewPartFromText("hello"),
+				{FunctionCall: &genai.FunctionCall{ID: "call-1", Name: "lookup", Args: map[string]any{"q": "x"}}},
+				{FunctionResponse: &genai.FunctionResponse{ID: "call-1", Name: "lookup", Response: map[string]any{"r": "y"}}},
+				genai.NewPartFromBytes([]byte{0, 1, 2}, "application/octet-stream"),
+			}},
+			CustomMetadata: map[string]any{"m": "n"},
+			TurnComplete:   true,
+			ErrorCode:      "code",
+			ErrorMessage:   "message",
+			FinishReason:   genai.FinishReasonStop,
+		},
+		ID:           "e1",
+		Timestamp:    ts,
+		InvocationID: "inv",
+		Branch:       "root.agent",
+		Author:       "agent",
+		Actions: session.EventActions{
+			StateDelta:        map[string]any{"k": "v"},
+			ArtifactDelta:     map[string]int64{"file.txt": 1},
+			SkipSummarization: true,
+			TransferToAgent:   "other",
+			Escalate:          true,
+		},
+		LongRunningToolIDs: []string{"call-1"},
+	}
+}
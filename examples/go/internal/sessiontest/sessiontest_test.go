@@ -0,0 +1,30 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessiontest_test
+
+import (
+	"testing"
+
+	"google.golang.org/adk/session"
+
+	"github.com/google/adk-docs/examples/go/internal/sessiontest"
+)
+
+// TestInMemoryService checks the suite against the behavior it describes.
+func TestInMemoryService(t *testing.T) {
+	sessiontest.Run(t, func(t *testing.T) session.Service {
+		return session.InMemoryService()
+	})
+}
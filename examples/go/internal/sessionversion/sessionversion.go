@@ -0,0 +1,344 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sessionversion adds optimistic concurrency control to a
+// session.Service, so that two writers of one session cannot silently
+// overwrite each other's state.
+//
+// The version of a session is the number of events appended to it. Every
+// session the wrapper's Create and Get return remembers the version it was
+// read at, and AppendEvent only succeeds if the session has not moved on
+// since; a stale append fails with a *ConflictError. With Config.Merge set,
+// the wrapper retries a stale append itself when none of the events it
+// missed changed a state key the new event changes:
+//
+//	svc := sessionversion.Wrap(session.InMemoryService(), sessionversion.Config{Merge: true})
+//	r, err := runner.New(runner.Config{AppName: appName, Agent: a, SessionService: svc})
+//
+// A store that implements Store, as sqlitesession and filesession do, keeps
+// the version itself and checks it in the same transaction as the append,
+// so writers in other processes, or in this one after a restart, are
+// checked too. For any other store, such as session.InMemoryService, the
+// versions are kept by the wrapper, in memory: only writers that go through
+// the same *Service are checked against each other.
+package sessionversion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/session"
+)
+
+// ErrConflict matches every *ConflictError with errors.Is.
+var ErrConflict = errors.New("session version conflict")
+
+// ConflictError reports an append to a session that changed since it was
+// read.
+type ConflictError struct {
+	AppName, UserID, SessionID string
+	// Expected is the version the append was based on, Actual the version
+	// of the stored session.
+	Expected, Actual int
+	// Keys are the state keys both the new event and a missed event
+	// changed, if a merge was attempted.
+	Keys []string
+}
+
+func (e *ConflictError) Error() string {
+	msg := fmt.Sprintf("session %s was modified: append based on version %d, session is at version %d", e.SessionID, e.Expected, e.Actual)
+	if len(e.Keys) > 0 {
+		msg += fmt.Sprintf("; both changed %s", strings.Join(e.Keys, ", "))
+	}
+	return msg
+}
+
+func (e *ConflictError) Is(target error) bool { return target == ErrConflict }
+
+// Config configures a Service.
+type Config struct {
+	// Merge makes AppendEvent retry a stale append when the new event's
+	// state delta shares no key with the deltas of the events appended
+	// since the session was read. The session passed in is then refreshed
+	// with those events.
+	Merge bool
+}
+
+// Store is a session.Service that can check the version of a session and
+// append to it atomically.
+type Store interface {
+	session.Service
+	// AppendEventIf appends e to sess as AppendEvent does, but only if the
+	// stored session holds exactly version events. It returns the number
+	// of events the stored session held, and whether e was appended.
+	AppendEventIf(ctx context.Context, sess session.Session, version int, e *session.Event) (stored int, appended bool, err error)
+}
+
+// Service is a session.Service with versioned appends.
+type Service struct {
+	inner session.Service
+	cfg   Config
+
+	mu      sync.Mutex
+	entries map[key]*entry
+}
+
+type key struct{ appName, userID, id string }
+
+// entry serializes the appends to one session and holds its version.
+type entry struct {
+	mu      sync.Mutex
+	loaded  bool
+	version int
+}
+
+// Wrap returns svc with versioned appends.
+func Wrap(svc session.Service, cfg Config) *Service {
+	return &Service{inner: svc, cfg: cfg, entries: map[key]*entry{}}
+}
+
+func (s *Service) Create(ctx context.Context, req *session.CreateRequest) (*session.CreateResponse, error) {
+	resp, err := s.inner.Create(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	sess := resp.Session
+	e := s.entry(sess.AppName(), sess.UserID(), sess.ID())
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.loaded, e.version = true, sess.Events().Len()
+	return &session.CreateResponse{Session: &Session{inner: sess, version: e.version}}, nil
+}
+
+func (s *Service) Get(ctx context.Context, req *session.GetRequest) (*session.GetResponse, error) {
+	if _, ok := s.inner.(Store); ok {
+		resp, err := s.inner.Get(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		version := resp.Session.Events().Len()
+		if req.NumRecentEvents > 0 || !req.After.IsZero() {
+			// The events are filtered: count them all.
+			all, err := s.inner.Get(ctx, &session.GetRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID})
+			if err != nil {
+				return nil, err
+			}
+			version = all.Session.Events().Len()
+		}
+		return &session.GetResponse{Session: &Session{inner: resp.Session, version: version}}, nil
+	}
+
+	e := s.entry(req.AppName, req.UserID, req.SessionID)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := s.load(ctx, e, req.AppName, req.UserID, req.SessionID); err != nil {
+		return nil, err
+	}
+	resp, err := s.inner.Get(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &session.GetResponse{Session: &Session{inner: resp.Session, version: e.version}}, nil
+}
+
+// List returns the sessions of the wrapped service as they are. Appending
+// to one of them is not checked; Get the session to append to it safely.
+func (s *Service) List(ctx context.Context, req *session.ListRequest) (*session.ListResponse, error) {
+	return s.inner.List(ctx, req)
+}
+
+func (s *Service) Delete(ctx context.Context, req *session.DeleteRequest) error {
+	e := s.entry(req.AppName, req.UserID, req.SessionID)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := s.inner.Delete(ctx, req); err != nil {
+		return err
+	}
+	e.loaded = false
+	return nil
+}
+
+// AppendEvent appends e if curSession is at the stored version, and fails
+// with a *ConflictError otherwise, unless Config.Merge is set and the
+// append can be merged. Sessions that did not come from this Service are
+// appended to without a check.
+func (s *Service) AppendEvent(ctx context.Context, curSession session.Session, e *session.Event) error {
+	sess, ok := curSession.(*Session)
+	if !ok {
+		return s.inner.AppendEvent(ctx, curSession, e)
+	}
+	return s.appendEvent(ctx, sess, e, s.cfg.Merge)
+}
+
+func (s *Service) appendEvent(ctx context.Context, sess *Session, e *session.Event, merge bool) error {
+	if e.Partial {
+		return s.inner.AppendEvent(ctx, sess.session(), e)
+	}
+	if st, ok := s.inner.(Store); ok {
+		return s.appendStored(ctx, st, sess, e, merge)
+	}
+
+	en := s.entry(sess.AppName(), sess.UserID(), sess.ID())
+	en.mu.Lock()
+	defer en.mu.Unlock()
+	if err := s.load(ctx, en, sess.AppName(), sess.UserID(), sess.ID()); err != nil {
+		return err
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.version != en.version {
+		if !merge {
+			return sess.conflict(en.version, nil)
+		}
+		if err := s.refresh(ctx, sess, en.version, e); err != nil {
+			return err
+		}
+	}
+	if err := s.inner.AppendEvent(ctx, sess.inner, e); err != nil {
+		return err
+	}
+	en.version++
+	sess.version = en.version
+	return nil
+}
+
+// appendStored appends e through st, which checks the version of sess. A
+// merged append is retried until no other writer gets in between.
+func (s *Service) appendStored(ctx context.Context, st Store, sess *Session, e *session.Event, merge bool) error {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	for {
+		stored, appended, err := st.AppendEventIf(ctx, sess.inner, sess.version, e)
+		if err != nil {
+			return err
+		}
+		if appended {
+			sess.version = stored + 1
+			return nil
+		}
+		if !merge {
+			return sess.conflict(stored, nil)
+		}
+		if err := s.refresh(ctx, sess, stored, e); err != nil {
+			return err
+		}
+	}
+}
+
+// refresh replaces the stale session sess is based on with the stored one
+// at version, if none of the events it misses changed a state key e
+// changes. The caller holds the lock of sess, and of its entry if the
+// wrapper keeps the version.
+func (s *Service) refresh(ctx context.Context, sess *Session, version int, e *session.Event) error {
+	resp, err := s.inner.Get(ctx, &session.GetRequest{AppName: sess.inner.AppName(), UserID: sess.inner.UserID(), SessionID: sess.inner.ID()})
+	if err != nil {
+		return err
+	}
+	events := resp.Session.Events()
+	changed := map[string]bool{}
+	for i := sess.version; i < version && i < events.Len(); i++ {
+		for k := range events.At(i).Actions.StateDelta {
+			changed[k] = true
+		}
+	}
+	var both []string
+	for k := range e.Actions.StateDelta {
+		if changed[k] {
+			both = append(both, k)
+		}
+	}
+	if len(both) > 0 {
+		slices.Sort(both)
+		return sess.conflict(version, both)
+	}
+	sess.inner, sess.version = resp.Session, version
+	return nil
+}
+
+func (s *Service) entry(appName, userID, id string) *entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := key{appName, userID, id}
+	e, ok := s.entries[k]
+	if !ok {
+		e = &entry{}
+		s.entries[k] = e
+	}
+	return e
+}
+
+// load reads the version of a session the first time it is used. The caller
+// holds e.mu.
+func (s *Service) load(ctx context.Context, e *entry, appName, userID, id string) error {
+	if e.loaded {
+		return nil
+	}
+	resp, err := s.inner.Get(ctx, &session.GetRequest{AppName: appName, UserID: userID, SessionID: id})
+	if err != nil {
+		return err
+	}
+	e.loaded, e.version = true, resp.Session.Events().Len()
+	return nil
+}
+
+// Session is a session read through a Service, together with its version.
+type Session struct {
+	mu      sync.RWMutex
+	inner   session.Session
+	version int
+}
+
+// Version returns the version of the session the next append is based on.
+func (s *Session) Version() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.version
+}
+
+func (s *Session) session() session.Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.inner
+}
+
+func (s *Session) ID() string                { return s.session().ID() }
+func (s *Session) AppName() string           { return s.session().AppName() }
+func (s *Session) UserID() string            { return s.session().UserID() }
+func (s *Session) State() session.State      { return s.session().State() }
+func (s *Session) Events() session.Events    { return s.session().Events() }
+func (s *Session) LastUpdateTime() time.Time { return s.session().LastUpdateTime() }
+
+func (s *Session) conflict(actual int, keys []string) *ConflictError {
+	return &ConflictError{
+		AppName:   s.inner.AppName(),
+		UserID:    s.inner.UserID(),
+		SessionID: s.inner.ID(),
+		Expected:  s.version,
+		Actual:    actual,
+		Keys:      keys,
+	}
+}
+
+// Merge appends e to sess as AppendEvent does with Config.Merge set: a
+// stale append is retried on the refreshed session, unless a missed event
+// changed one of the state keys e changes.
+func (s *Service) Merge(ctx context.Context, sess *Session, e *session.Event) error {
+	return s.appendEvent(ctx, sess, e, true)
+}
@@ -0,0 +1,269 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sessionversion
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/filesession"
+	"github.com/google/adk-docs/examples/go/internal/sqlitesession"
+)
+
+func create(t *testing.T, svc *Service) session.Session {
+	t.Helper()
+	resp, err := svc.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Session
+}
+
+func get(t *testing.T, svc session.Service) session.Session {
+	t.Helper()
+	resp, err := svc.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Session
+}
+
+func event(delta map[string]any) *session.Event {
+	e := session.NewEvent("inv")
+	e.Author = "agent"
+	e.Content = genai.NewContentFromText("ok", genai.RoleModel)
+	e.Actions.StateDelta = delta
+	return e
+}
+
+func TestConflict(t *testing.T) {
+	svc := Wrap(session.InMemoryService(), Config{})
+	create(t, svc)
+	a, b := get(t, svc), get(t, svc)
+
+	if err := svc.AppendEvent(t.Context(), a, event(map[string]any{"x": 1})); err != nil {
+		t.Fatalf("AppendEvent() on a fresh session failed: %v", err)
+	}
+	if v := a.(*Session).Version(); v != 1 {
+		t.Errorf("Version() after one append = %d, want 1", v)
+	}
+	err := svc.AppendEvent(t.Context(), b, event(map[string]any{"y": 2}))
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) || !errors.Is(err, ErrConflict) {
+		t.Fatalf("AppendEvent() on a stale session = %v, want a *ConflictError", err)
+	}
+	if conflict.Expected != 0 || conflict.Actual != 1 {
+		t.Errorf("conflict versions = %d, %d, want 0, 1", conflict.Expected, conflict.Actual)
+	}
+	if n := get(t, svc).Events().Len(); n != 1 {
+		t.Errorf("session has %d events, want 1", n)
+	}
+
+	// Reading the session again resolves the conflict.
+	if err := svc.AppendEvent(t.Context(), get(t, svc), event(map[string]any{"y": 2})); err != nil {
+		t.Errorf("AppendEvent() on a reread session failed: %v", err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	svc := Wrap(session.InMemoryService(), Config{})
+	create(t, svc)
+	a, b := get(t, svc), get(t, svc).(*Session)
+
+	if err := svc.AppendEvent(t.Context(), a, event(map[string]any{"x": 1, "shared": "a"})); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Merge(t.Context(), b, event(map[string]any{"y": 2})); err != nil {
+		t.Fatalf("Merge() of a disjoint delta failed: %v", err)
+	}
+	if v := b.Version(); v != 2 {
+		t.Errorf("Version() after merge = %d, want 2", v)
+	}
+	// The merged session now sees the other writer's state too.
+	if v, err := b.State().Get("x"); err != nil || v != 1 {
+		t.Errorf("merged State().Get(x) = %v, %v, want 1", v, err)
+	}
+
+	c := get(t, svc)
+	if err := svc.AppendEvent(t.Context(), get(t, svc), event(map[string]any{"shared": "a2"})); err != nil {
+		t.Fatal(err)
+	}
+	err := svc.Merge(t.Context(), c.(*Session), event(map[string]any{"shared": "c", "z": 3}))
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Merge() of an overlapping delta = %v, want a *ConflictError", err)
+	}
+	if !slices.Equal(conflict.Keys, []string{"shared"}) {
+		t.Errorf("conflict keys = %v, want [shared]", conflict.Keys)
+	}
+	if v, _ := get(t, svc).State().Get("shared"); v != "a2" {
+		t.Errorf("State().Get(shared) = %v, want a2", v)
+	}
+}
+
+// TestStores shares a store between two wrappers over two handles of it,
+// the way two processes would, and checks that the version kept by the store
+// catches their stale appends.
+func TestStores(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		open func(t *testing.T, path string) Store
+	}{
+		{"sqlitesession", func(t *testing.T, path string) Store {
+			svc, err := sqlitesession.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { svc.Close() })
+			return svc
+		}},
+		{"filesession", func(t *testing.T, path string) Store {
+			svc, err := filesession.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return svc
+		}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store")
+			one := Wrap(c.open(t, path), Config{})
+			two := Wrap(c.open(t, path), Config{})
+			create(t, one)
+			a, b := get(t, one), get(t, two).(*Session)
+
+			if err := one.AppendEvent(t.Context(), a, event(map[string]any{"x": 1})); err != nil {
+				t.Fatal(err)
+			}
+			err := two.AppendEvent(t.Context(), b, event(map[string]any{"x": 2}))
+			var conflict *ConflictError
+			if !errors.As(err, &conflict) {
+				t.Fatalf("AppendEvent() through the other wrapper = %v, want a *ConflictError", err)
+			}
+			if conflict.Expected != 0 || conflict.Actual != 1 {
+				t.Errorf("conflict versions = %d, %d, want 0, 1", conflict.Expected, conflict.Actual)
+			}
+
+			if err := two.Merge(t.Context(), b, event(map[string]any{"y": 2})); err != nil {
+				t.Fatalf("Merge() of a disjoint delta failed: %v", err)
+			}
+			if v := b.Version(); v != 2 {
+				t.Errorf("Version() after merge = %d, want 2", v)
+			}
+			// A new wrapper, as after a restart, reads the version from the store.
+			three := Wrap(c.open(t, path), Config{})
+			recent, err := three.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1", NumRecentEvents: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v := recent.Session.(*Session).Version(); v != 2 {
+				t.Errorf("Version() of a filtered Get = %d, want 2", v)
+			}
+			if err := three.AppendEvent(t.Context(), a, event(nil)); !errors.Is(err, ErrConflict) {
+				t.Errorf("AppendEvent() of a stale session after a restart = %v, want ErrConflict", err)
+			}
+			if n := get(t, three).Events().Len(); n != 2 {
+				t.Errorf("session has %d events, want 2", n)
+			}
+		})
+	}
+}
+
+func TestUnversionedSession(t *testing.T) {
+	inner := session.InMemoryService()
+	svc := Wrap(inner, Config{})
+	create(t, svc)
+	stale := get(t, inner)
+	if err := svc.AppendEvent(t.Context(), get(t, svc), event(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.AppendEvent(t.Context(), stale, event(nil)); err != nil {
+		t.Errorf("AppendEvent() on a session of the wrapped service failed: %v", err)
+	}
+}
+
+// TestHammer runs many writers of one session at once; run it with -race.
+// Each merging writer owns a key, so all of its appends must succeed and no
+// update may be lost.
+func TestHammer(t *testing.T) {
+	const writers, appends = 16, 25
+	svc := Wrap(session.InMemoryService(), Config{Merge: true})
+	create(t, svc)
+
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sess := get(t, svc)
+			for i := range appends {
+				key := fmt.Sprintf("writer%d", w)
+				if err := svc.AppendEvent(t.Context(), sess, event(map[string]any{key: i})); err != nil {
+					t.Errorf("writer %d: AppendEvent() failed: %v", w, err)
+					return
+				}
+				// Read the state while others append.
+				_, _ = sess.State().Get(key)
+				_ = sess.Events().Len()
+			}
+		}()
+	}
+	// Writers of a shared key without merging: every stale append must be
+	// rejected rather than overwrite a newer value.
+	var rejected int
+	var mu sync.Mutex
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range appends {
+				sess := get(t, svc).(*Session)
+				err := svc.appendEvent(t.Context(), sess, event(map[string]any{"counter": sess.Version()}), false)
+				if errors.Is(err, ErrConflict) {
+					mu.Lock()
+					rejected++
+					mu.Unlock()
+				} else if err != nil {
+					t.Errorf("AppendEvent() failed: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	sess := get(t, svc)
+	if want := 2*writers*appends - rejected; sess.Events().Len() != want {
+		t.Errorf("session has %d events, want %d", sess.Events().Len(), want)
+	}
+	for w := range writers {
+		key := fmt.Sprintf("writer%d", w)
+		if v, err := sess.State().Get(key); err != nil || v != appends-1 {
+			t.Errorf("State().Get(%s) = %v, %v, want %d", key, v, err, appends-1)
+		}
+	}
+	// Each accepted counter append was based on the version before it.
+	for i := 0; i < sess.Events().Len(); i++ {
+		if v, ok := sess.Events().At(i).Actions.StateDelta["counter"]; ok && v != i {
+			t.Errorf("counter event %d was based on version %v", i, v)
+		}
+	}
+}
@@ -0,0 +1,461 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlitesession provides a session.Service that keeps sessions in an
+// SQLite database file, so they survive a restart of the program.
+//
+// It behaves like session.InMemoryService: app: and user: state is shared
+// between the sessions of an app and of a user, temp: state is never
+// stored, and partial events are ignored. Several runners, in one process or
+// in several, may use the same file at once; every call runs in its own
+// transaction.
+//
+// Events and state values are stored as JSON, so a value read back has the
+// type encoding/json decodes it to: a state value set to an int comes back
+// as a float64.
+package sqlitesession
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+	"google.golang.org/adk/session"
+
+	"github.com/google/adk-docs/examples/go/internal/localsession"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	app_name    TEXT NOT NULL,
+	user_id     TEXT NOT NULL,
+	id          TEXT NOT NULL,
+	update_time INTEGER NOT NULL,
+	PRIMARY KEY (app_name, user_id, id)
+);
+CREATE TABLE IF NOT EXISTS events (
+	app_name   TEXT NOT NULL,
+	user_id    TEXT NOT NULL,
+	session_id TEXT NOT NULL,
+	seq        INTEGER NOT NULL,
+	id         TEXT NOT NULL,
+	timestamp  INTEGER NOT NULL,
+	event      TEXT NOT NULL,
+	PRIMARY KEY (app_name, user_id, session_id, seq)
+);
+-- App state has an empty user_id and session_id, user state an empty
+-- session_id.
+CREATE TABLE IF NOT EXISTS states (
+	app_name   TEXT NOT NULL,
+	user_id    TEXT NOT NULL,
+	session_id TEXT NOT NULL,
+	key        TEXT NOT NULL,
+	value      TEXT NOT NULL,
+	PRIMARY KEY (app_name, user_id, session_id, key)
+);
+`
+
+// Service is a session.Service backed by an SQLite database file.
+type Service struct {
+	db *sql.DB
+}
+
+// Open opens the database at path, creating it if needed.
+func Open(path string) (*Service, error) {
+	// Transactions take the write lock up front, so two appends to the same
+	// session never deadlock upgrading a read lock, and wait for each other
+	// up to the busy timeout instead of failing.
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_busy_timeout=10000&_txlock=immediate")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema in %s: %w", path, err)
+	}
+	return &Service{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Service) Close() error {
+	return s.db.Close()
+}
+
+func (s *Service) Create(ctx context.Context, req *session.CreateRequest) (*session.CreateResponse, error) {
+	if req.AppName == "" || req.UserID == "" {
+		return nil, fmt.Errorf("app_name and user_id are required, got app_name: %q, user_id: %q", req.AppName, req.UserID)
+	}
+	id := req.SessionID
+	if id == "" {
+		id = uuid.NewString()
+	}
+
+	var sess *localsession.Session
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := lookup(ctx, tx, req.AppName, req.UserID, id); err == nil {
+			return fmt.Errorf("session %s already exists", id)
+		} else if !errors.Is(err, errNotFound) {
+			return err
+		}
+
+		now := time.Now()
+		if _, err := tx.ExecContext(ctx, `INSERT INTO sessions (app_name, user_id, id, update_time) VALUES (?, ?, ?, ?)`,
+			req.AppName, req.UserID, id, now.UnixNano()); err != nil {
+			return err
+		}
+		if err := setState(ctx, tx, req.AppName, req.UserID, id, req.State); err != nil {
+			return err
+		}
+		state, err := loadState(ctx, tx, req.AppName, req.UserID, id)
+		if err != nil {
+			return err
+		}
+		sess = localsession.New(req.AppName, req.UserID, id, state, nil, now)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session.CreateResponse{Session: sess}, nil
+}
+
+func (s *Service) Get(ctx context.Context, req *session.GetRequest) (*session.GetResponse, error) {
+	appName, userID, id := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || id == "" {
+		return nil, fmt.Errorf("app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", appName, userID, id)
+	}
+
+	var sess *localsession.Session
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		updatedAt, err := lookup(ctx, tx, appName, userID, id)
+		if err != nil {
+			return err
+		}
+		state, err := loadState(ctx, tx, appName, userID, id)
+		if err != nil {
+			return err
+		}
+		events, err := loadEvents(ctx, tx, req)
+		if err != nil {
+			return err
+		}
+		sess = localsession.New(appName, userID, id, state, events, updatedAt)
+		return nil
+	})
+	if errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("session %s not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session.GetResponse{Session: sess}, nil
+}
+
+// List returns the sessions of an app, or of one of its users if
+// req.UserID is set, without their events.
+func (s *Service) List(ctx context.Context, req *session.ListRequest) (*session.ListResponse, error) {
+	if req.AppName == "" {
+		return nil, fmt.Errorf("app_name is required, got app_name: %q", req.AppName)
+	}
+
+	sessions := []session.Session{}
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `SELECT user_id, id, update_time FROM sessions
+			WHERE app_name = ? AND (? = '' OR user_id = ?) ORDER BY user_id, id`,
+			req.AppName, req.UserID, req.UserID)
+		if err != nil {
+			return err
+		}
+		type key struct {
+			userID, id string
+			updatedAt  int64
+		}
+		var keys []key
+		for rows.Next() {
+			var k key
+			if err := rows.Scan(&k.userID, &k.id, &k.updatedAt); err != nil {
+				rows.Close()
+				return err
+			}
+			keys = append(keys, k)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			state, err := loadState(ctx, tx, req.AppName, k.userID, k.id)
+			if err != nil {
+				return err
+			}
+			sessions = append(sessions, localsession.New(req.AppName, k.userID, k.id, state, nil, fromNanos(k.updatedAt)))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session.ListResponse{Sessions: sessions}, nil
+}
+
+// Delete deletes a session with its events and session state. Deleting a
+// session that does not exist is not an error.
+func (s *Service) Delete(ctx context.Context, req *session.DeleteRequest) error {
+	appName, userID, id := req.AppName, req.UserID, req.SessionID
+	if appName == "" || userID == "" || id == "" {
+		return fmt.Errorf("app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", appName, userID, id)
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, table := range []string{"sessions", "events", "states"} {
+			col := "session_id"
+			if table == "sessions" {
+				col = "id"
+			}
+			q := fmt.Sprintf(`DELETE FROM %s WHERE app_name = ? AND user_id = ? AND %s = ?`, table, col)
+			if _, err := tx.ExecContext(ctx, q, appName, userID, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AppendEvent stores e and its state delta, then appends it to curSession,
+// which must have been returned by s.
+func (s *Service) AppendEvent(ctx context.Context, curSession session.Session, e *session.Event) error {
+	_, _, err := s.appendEvent(ctx, curSession, -1, e)
+	return err
+}
+
+// AppendEventIf appends e to curSession as AppendEvent does, but only if the
+// stored session holds exactly version events. It returns the number of
+// events the stored session held, and whether e was appended. The check and
+// the append run in one transaction, so sessionversion can use it to check
+// the appends of every process sharing the database.
+func (s *Service) AppendEventIf(ctx context.Context, curSession session.Session, version int, e *session.Event) (stored int, appended bool, err error) {
+	return s.appendEvent(ctx, curSession, version, e)
+}
+
+// appendEvent appends e if the session holds version events, or in any case
+// if version is negative.
+func (s *Service) appendEvent(ctx context.Context, curSession session.Session, version int, e *session.Event) (stored int, appended bool, err error) {
+	if curSession == nil {
+		return 0, false, fmt.Errorf("session is nil")
+	}
+	if e == nil {
+		return 0, false, fmt.Errorf("event is nil")
+	}
+	if e.Partial {
+		return 0, false, nil
+	}
+	sess, ok := curSession.(*localsession.Session)
+	if !ok {
+		return 0, false, fmt.Errorf("unexpected session type %T", curSession)
+	}
+
+	localsession.TrimTempDelta(e)
+	data, err := json.Marshal(e)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to encode event: %w", err)
+	}
+	appName, userID, id := sess.AppName(), sess.UserID(), sess.ID()
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := lookup(ctx, tx, appName, userID, id); err != nil {
+			return err
+		}
+		// Events are numbered from 1 without gaps.
+		if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(seq), 0) FROM events WHERE app_name = ? AND user_id = ? AND session_id = ?`,
+			appName, userID, id).Scan(&stored); err != nil {
+			return err
+		}
+		if version >= 0 && stored != version {
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO events (app_name, user_id, session_id, seq, id, timestamp, event) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			appName, userID, id, stored+1, e.ID, nanos(e.Timestamp), string(data)); err != nil {
+			return err
+		}
+		if err := setState(ctx, tx, appName, userID, id, e.Actions.StateDelta); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE sessions SET update_time = ? WHERE app_name = ? AND user_id = ? AND id = ?`,
+			nanos(e.Timestamp), appName, userID, id); err != nil {
+			return err
+		}
+		appended = true
+		return nil
+	})
+	if errors.Is(err, errNotFound) {
+		return 0, false, fmt.Errorf("session not found, cannot apply event")
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if appended {
+		sess.Append(e)
+	}
+	return stored, appended, nil
+}
+
+var errNotFound = errors.New("session not found")
+
+// inTx runs f in a transaction and commits it if f succeeds.
+func (s *Service) inTx(ctx context.Context, f func(*sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// lookup returns the last update time of a session, or errNotFound.
+func lookup(ctx context.Context, tx *sql.Tx, appName, userID, id string) (time.Time, error) {
+	var updatedAt int64
+	err := tx.QueryRowContext(ctx, `SELECT update_time FROM sessions WHERE app_name = ? AND user_id = ? AND id = ?`,
+		appName, userID, id).Scan(&updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, errNotFound
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fromNanos(updatedAt), nil
+}
+
+// setState writes a state delta to the rows of each scope.
+func setState(ctx context.Context, tx *sql.Tx, appName, userID, id string, delta map[string]any) error {
+	app, user, sess := localsession.SplitState(delta)
+	for _, sc := range []struct {
+		userID, id string
+		delta      map[string]any
+	}{
+		{"", "", app},
+		{userID, "", user},
+		{userID, id, sess},
+	} {
+		for k, v := range sc.delta {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("failed to encode state key %q: %w", k, err)
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO states (app_name, user_id, session_id, key, value) VALUES (?, ?, ?, ?, ?)
+				ON CONFLICT (app_name, user_id, session_id, key) DO UPDATE SET value = excluded.value`,
+				appName, sc.userID, sc.id, k, string(data)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadState returns the state a session sees: its own, its user's and its
+// app's.
+func loadState(ctx context.Context, tx *sql.Tx, appName, userID, id string) (map[string]any, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT user_id, session_id, key, value FROM states
+		WHERE app_name = ? AND ((user_id = '' AND session_id = '') OR (user_id = ? AND session_id IN ('', ?)))`,
+		appName, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	app, user, sess := map[string]any{}, map[string]any{}, map[string]any{}
+	for rows.Next() {
+		var rowUser, rowSession, key, value string
+		if err := rows.Scan(&rowUser, &rowSession, &key, &value); err != nil {
+			return nil, err
+		}
+		var v any
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return nil, fmt.Errorf("failed to decode state key %q: %w", key, err)
+		}
+		switch {
+		case rowUser == "":
+			app[key] = v
+		case rowSession == "":
+			user[key] = v
+		default:
+			sess[key] = v
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return localsession.MergeState(app, user, sess), nil
+}
+
+// loadEvents returns the events of a session in append order, filtered the
+// way req asks for.
+func loadEvents(ctx context.Context, tx *sql.Tx, req *session.GetRequest) ([]*session.Event, error) {
+	limit := -1 // no limit
+	if req.NumRecentEvents > 0 {
+		limit = req.NumRecentEvents
+	}
+	after := int64(math.MinInt64)
+	if !req.After.IsZero() {
+		after = req.After.UnixNano()
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT event FROM (
+			SELECT seq, timestamp, event FROM events
+			WHERE app_name = ? AND user_id = ? AND session_id = ?
+			ORDER BY seq DESC LIMIT ?
+		) WHERE timestamp >= ? ORDER BY seq`,
+		req.AppName, req.UserID, req.SessionID, limit, after)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*session.Event
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		e := &session.Event{}
+		if err := json.Unmarshal([]byte(data), e); err != nil {
+			return nil, fmt.Errorf("failed to decode event: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// nanos returns t as Unix nanoseconds, with the zero time sorting first.
+func nanos(t time.Time) int64 {
+	if t.IsZero() {
+		return math.MinInt64
+	}
+	return t.UnixNano()
+}
+
+func fromNanos(n int64) time.Time {
+	if n == math.MinInt64 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+var _ session.Service = (*Service)(nil)
@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlitesession
+
+import (
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/sessiontest"
+)
+
+func open(t *testing.T, path string) *Service {
+	t.Helper()
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open(%q) failed: %v", path, err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestService(t *testing.T) {
+	sessiontest.Run(t, func(t *testing.T) session.Service {
+		return open(t, filepath.Join(t.TempDir(), "sessions.db"))
+	})
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	s := open(t, path)
+	resp, err := s.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1",
+		State: map[string]any{"app:a": "1", "user:u": "2", "k": "3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := session.NewEvent("inv")
+	e.Author = "agent"
+	e.Content = genai.NewContentFromText("hello", genai.RoleModel)
+	e.Actions.StateDelta["count"] = 1
+	if err := s.AppendEvent(t.Context(), resp.Session, e); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	got, err := open(t, path).Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatalf("Get() after reopening failed: %v", err)
+	}
+	if n := got.Session.Events().Len(); n != 1 {
+		t.Fatalf("reopened session has %d events, want 1", n)
+	}
+	if text := got.Session.Events().At(0).Content.Parts[0].Text; text != "hello" {
+		t.Errorf("reopened event text = %q, want %q", text, "hello")
+	}
+	if !got.Session.LastUpdateTime().Equal(e.Timestamp) {
+		t.Errorf("LastUpdateTime() = %v, want %v", got.Session.LastUpdateTime(), e.Timestamp)
+	}
+	// Numbers come back the way encoding/json decodes them.
+	for key, want := range map[string]any{"app:a": "1", "user:u": "2", "k": "3", "count": 1.0} {
+		if v, err := got.Session.State().Get(key); err != nil || v != want {
+			t.Errorf("State().Get(%q) = %v, %v, want %v", key, v, err, want)
+		}
+	}
+}
+
+func TestSharedFile(t *testing.T) {
+	// Two services on one file see each other's writes, as two processes
+	// would.
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	a, b := open(t, path), open(t, path)
+	resp, err := a.Create(t.Context(), &session.CreateRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := b.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatalf("Get() through the second service failed: %v", err)
+	}
+	for i, sess := range []session.Session{resp.Session, got.Session} {
+		svc := []*Service{a, b}[i]
+		e := session.NewEvent("inv")
+		e.Actions.StateDelta["user:n"] = i
+		if err := svc.AppendEvent(t.Context(), sess, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	final, err := a.Get(t.Context(), &session.GetRequest{AppName: "app", UserID: "user", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := final.Session.Events().Len(); n != 2 {
+		t.Errorf("session has %d events, want 2", n)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolconfirm_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/fakellm"
+	"github.com/google/adk-docs/examples/go/internal/toolconfirm"
+)
+
+// This example pauses a transfer until a human approves it, in the human in
+// the loop pattern of the multi-agent docs.
+func Example() {
+	ctx := context.Background()
+	type transferFundsArgs struct {
+		Amount float64 `json:"amount" jsonschema:"The amount to transfer."`
+		Reason string  `json:"reason" jsonschema:"The reason for the transfer."`
+	}
+	transferFunds, err := functiontool.New(functiontool.Config{Name: "transfer_funds", Description: "Transfers funds."},
+		func(ctx tool.Context, args transferFundsArgs) map[string]any {
+			fmt.Printf("Transferred %v for %s.\n", args.Amount, args.Reason)
+			return map[string]any{"status": "transferred"}
+		})
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Every call of approvalTool waits for a human to approve, deny or edit it.
+	approvalTool, err := toolconfirm.Require(transferFunds, func(ctx tool.Context, t tool.Tool, args map[string]any) (string, bool) {
+		return fmt.Sprintf("Transfer %v for %v?", args["amount"], args["reason"]), true
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sessionService := session.InMemoryService()
+	a, err := llmagent.New(llmagent.Config{
+		Name: "RequestHumanApproval",
+		Model: fakellm.New("fake",
+			fakellm.Call(&genai.FunctionCall{Name: "transfer_funds", Args: map[string]any{"amount": 100, "reason": "team dinner"}}),
+			fakellm.Text("Done: 100 was transferred for the team dinner."),
+		),
+		Instruction:          "Use the transfer_funds tool with the amount and the reason the user gives.",
+		Tools:                []tool.Tool{approvalTool},
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{toolconfirm.Resume(sessionService)},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "app", Agent: a, SessionService: sessionService})
+	if err != nil {
+		log.Fatal(err)
+	}
+	s, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "user"})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// The first run ends with the request for approval.
+	var reqs []toolconfirm.Request
+	msg := genai.NewContentFromText("Transfer 100 for the team dinner.", genai.RoleUser)
+	for ev, err := range r.Run(ctx, "user", s.Session.ID(), msg, agent.RunConfig{}) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		reqs = append(reqs, toolconfirm.Requests(ev)...)
+	}
+	for _, req := range reqs {
+		fmt.Println(req.Hint)
+	}
+
+	// The next run resumes with the decision of the human.
+	msg = genai.NewContentFromParts([]*genai.Part{
+		toolconfirm.Reply(reqs[0], toolconfirm.Decision{Action: toolconfirm.Approve}),
+	}, genai.RoleUser)
+	for ev, err := range r.Run(ctx, "user", s.Session.ID(), msg, agent.RunConfig{}) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		if ev.Content != nil && ev.Content.Parts[0].Text != "" {
+			fmt.Println(ev.Content.Parts[0].Text)
+		}
+	}
+	// Output:
+	// Transfer 100 for team dinner?
+	// Transferred 100 for team dinner.
+	// Done: 100 was transferred for the team dinner.
+}
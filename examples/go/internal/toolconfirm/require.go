@@ -0,0 +1,70 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package toolconfirm
+
+import (
+	"fmt"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/llmrequest"
+)
+
+// functionTool is a tool the agent calls with its Run method.
+type functionTool interface {
+	tool.Tool
+	Declaration() *genai.FunctionDeclaration
+	Run(ctx tool.Context, args any) (map[string]any, error)
+}
+
+// Require returns t, such as a tool of functiontool, asking for a
+// confirmation of the calls that p says need one. A nil p asks for every
+// call, with the description of t as the hint.
+func Require(t tool.Tool, p Policy) (tool.Tool, error) {
+	ft, ok := t.(functionTool)
+	if !ok {
+		return nil, fmt.Errorf("tool %q is not a function tool", t.Name())
+	}
+	if p == nil {
+		p = func(ctx tool.Context, t tool.Tool, args map[string]any) (string, bool) {
+			return t.Description(), true
+		}
+	}
+	return &confirmedTool{functionTool: ft, policy: p}, nil
+}
+
+type confirmedTool struct {
+	functionTool
+	policy Policy
+}
+
+// ProcessRequest adds the tool to req.
+func (t *confirmedTool) ProcessRequest(ctx tool.Context, req *model.LLMRequest) error {
+	return llmrequest.AddTool(req, t)
+}
+
+// Run runs the tool if the call needs no confirmation or was approved, and
+// otherwise requests a confirmation.
+func (t *confirmedTool) Run(ctx tool.Context, args any) (map[string]any, error) {
+	m, _ := args.(map[string]any)
+	if hint, ok := t.policy(ctx, t.functionTool, m); ok {
+		if resp := gate(ctx, hint, m); resp != nil {
+			return resp, nil
+		}
+	}
+	return t.functionTool.Run(ctx, args)
+}
@@ -0,0 +1,323 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package toolconfirm pauses a run until a human approves, denies or edits
+// a tool call.
+//
+// A call needs a confirmation when its tool was wrapped by Require, or when
+// a BeforeToolCallback from BeforeTool says so, for tools that are not
+// wrapped. Either way, the agent needs Resume, with the session service of
+// its runner, as its first BeforeModelCallback:
+//
+//	a, err := llmagent.New(llmagent.Config{
+//		Name:  "payments_agent",
+//		Model: model,
+//		Tools: []tool.Tool{transferTool},
+//		BeforeToolCallbacks: []llmagent.BeforeToolCallback{
+//			toolconfirm.BeforeTool(func(ctx tool.Context, t tool.Tool, args map[string]any) (string, bool) {
+//				return fmt.Sprintf("Transfer %v?", args["amount"]), t.Name() == "transfer"
+//			}),
+//		},
+//		BeforeModelCallbacks: []llmagent.BeforeModelCallback{toolconfirm.Resume(sessionService)},
+//	})
+//
+// Such a call does not run: its response is a request for confirmation,
+// and the run ends with the event of that response. Requests returns the
+// requests of an event, and Pending those of a session still waiting for a
+// decision, say after a restart:
+//
+//	for _, req := range toolconfirm.Requests(ev) {
+//		// Show req.Hint, req.Tool and req.Args to the user.
+//	}
+//
+// A later run resumes with a user message answering the requests, each by
+// its function call ID:
+//
+//	msg := genai.NewContentFromParts([]*genai.Part{
+//		toolconfirm.Reply(req, toolconfirm.Decision{Action: toolconfirm.Approve}),
+//	}, genai.RoleUser)
+//	for ev, err := range r.Run(ctx, userID, sessionID, msg, agent.RunConfig{}) {
+//
+// Resume then calls the tool again, in place of the model, with the
+// arguments of the request or those of an Edit, and that call runs. A
+// denied call is not run: the model sees the decision as the response of
+// the call, and answers the user. Resume only takes decisions for the
+// pending requests of the session, so a message cannot approve a call that
+// was never requested, or approve it with other arguments.
+//
+// The requests and the decisions are kept in the session, so a run can
+// resume in another process when the session service is persistent.
+package toolconfirm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// StatePrefix prefixes the state keys that hold the decision for each call,
+// followed by its function call ID.
+const StatePrefix = "confirmation:"
+
+// responseKey is the key of a request, or of a decision, in a function
+// response.
+const responseKey = "confirmation"
+
+// Action is what the user decided for a call.
+type Action string
+
+const (
+	// Approve runs the call as the model made it.
+	Approve Action = "approve"
+	// Deny does not run the call.
+	Deny Action = "deny"
+	// Edit runs the call with the arguments of the decision.
+	Edit Action = "edit"
+)
+
+// Request is a request for the confirmation of a call.
+type Request struct {
+	// CallID is the function call ID of the call.
+	CallID string
+	// Tool is the name of the tool called, and Args its arguments.
+	Tool string
+	Args map[string]any
+	// Hint tells the user what they confirm.
+	Hint string
+}
+
+// Decision is the answer of the user to a Request.
+type Decision struct {
+	Action Action
+	// Args replace the arguments of the call for Edit.
+	Args map[string]any
+	// Reason, if set, tells the model why, typically for Deny.
+	Reason string
+}
+
+// Policy reports whether a call needs a confirmation, and the hint to show
+// the user.
+type Policy func(ctx tool.Context, t tool.Tool, args map[string]any) (hint string, ok bool)
+
+// BeforeTool returns a BeforeToolCallback that asks for a confirmation of
+// the calls that p says need one.
+func BeforeTool(p Policy) llmagent.BeforeToolCallback {
+	return func(ctx tool.Context, t tool.Tool, args map[string]any) (map[string]any, error) {
+		hint, ok := p(ctx, t, args)
+		if !ok {
+			return nil, nil
+		}
+		return gate(ctx, hint, args), nil
+	}
+}
+
+// gate returns nil if the call of ctx was approved, and otherwise a
+// response requesting a confirmation, ending the run.
+func gate(ctx tool.Context, hint string, args map[string]any) map[string]any {
+	if d, _ := decided(ctx.State(), ctx.FunctionCallID()); d == Approve {
+		return nil
+	}
+	// Ends the run once the responses of the calls are in.
+	ctx.Actions().SkipSummarization = true
+	req := map[string]any{"hint": hint}
+	if args != nil {
+		req["args"] = args
+	}
+	return map[string]any{
+		"status":    "waiting for the user to confirm the call",
+		responseKey: req,
+	}
+}
+
+// Resume returns a BeforeModelCallback that carries out the decisions in
+// the user message of the run. In place of the model, it calls again the
+// tools of the approved and edited calls, which then run; denied calls are
+// left for the model to answer. It must come before any other callback
+// that may return a response.
+//
+// Each decision is carried out once, and recorded in the state. A decision
+// must answer a pending request of the session, which it finds in
+// sessions, and name its tool; an approved call runs with the arguments of
+// the request, whatever the message says.
+func Resume(sessions session.Service) llmagent.BeforeModelCallback {
+	return func(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
+		return resume(ctx, sessions)
+	}
+}
+
+func resume(ctx agent.CallbackContext, sessions session.Service) (*model.LLMResponse, error) {
+	msg := ctx.UserContent()
+	if msg == nil {
+		return nil, nil
+	}
+	var calls []*genai.Part
+	var pending map[string]Request
+	for _, p := range msg.Parts {
+		fr := p.FunctionResponse
+		if fr == nil || fr.ID == "" || fr.Response[responseKey] == nil {
+			continue
+		}
+		if _, ok := decided(ctx.State(), fr.ID); ok {
+			continue
+		}
+		if pending == nil {
+			resp, err := sessions.Get(ctx, &session.GetRequest{AppName: ctx.AppName(), UserID: ctx.UserID(), SessionID: ctx.SessionID()})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the requests for confirmation: %w", err)
+			}
+			pending = map[string]Request{}
+			for _, r := range Pending(resp.Session) {
+				pending[r.CallID] = r
+			}
+		}
+		stored, ok := pending[fr.ID]
+		if !ok {
+			return nil, fmt.Errorf("decision for call %s, which has no pending request for confirmation", fr.ID)
+		}
+		if fr.Name != stored.Tool {
+			return nil, fmt.Errorf("decision for call %s names tool %q, want %q", fr.ID, fr.Name, stored.Tool)
+		}
+		var d struct {
+			Action Action         `json:"action"`
+			Args   map[string]any `json:"args"`
+			Reason string         `json:"reason"`
+		}
+		if err := convert(fr.Response[responseKey], &d); err != nil {
+			return nil, fmt.Errorf("invalid decision for call %s: %w", fr.ID, err)
+		}
+		mark := map[string]any{"action": string(d.Action)}
+		if d.Reason != "" {
+			mark["reason"] = d.Reason
+		}
+		switch d.Action {
+		case Approve, Edit:
+			id := "adk-" + uuid.NewString()
+			mark["callId"] = id
+			if err := ctx.State().Set(StatePrefix+id, map[string]any{"action": string(Approve)}); err != nil {
+				return nil, err
+			}
+			args := stored.Args
+			if d.Action == Edit {
+				args = d.Args
+			}
+			if args == nil {
+				args = map[string]any{}
+			}
+			calls = append(calls, &genai.Part{FunctionCall: &genai.FunctionCall{ID: id, Name: stored.Tool, Args: args}})
+		case Deny:
+		default:
+			return nil, fmt.Errorf("invalid decision for call %s: unknown action %q", fr.ID, d.Action)
+		}
+		if err := ctx.State().Set(StatePrefix+fr.ID, mark); err != nil {
+			return nil, err
+		}
+	}
+	if len(calls) == 0 {
+		return nil, nil
+	}
+	return &model.LLMResponse{Content: &genai.Content{Role: genai.RoleModel, Parts: calls}}, nil
+}
+
+// Reply returns the part of a user message that answers req with d. The
+// parts answering several requests can go in one message.
+func Reply(req Request, d Decision) *genai.Part {
+	answer := map[string]any{"action": string(d.Action)}
+	if d.Action == Edit {
+		answer["args"] = d.Args
+	}
+	if d.Reason != "" {
+		answer["reason"] = d.Reason
+	}
+	return &genai.Part{FunctionResponse: &genai.FunctionResponse{
+		ID:       req.CallID,
+		Name:     req.Tool,
+		Response: map[string]any{responseKey: answer},
+	}}
+}
+
+// Requests returns the requests for confirmation in ev, in the order of
+// the calls. Events of the user have none.
+func Requests(ev *session.Event) []Request {
+	if ev == nil || ev.Content == nil || ev.Author == "user" {
+		return nil
+	}
+	var reqs []Request
+	for _, p := range ev.Content.Parts {
+		fr := p.FunctionResponse
+		// Decisions have no status.
+		if fr == nil || fr.Response["status"] == nil || fr.Response[responseKey] == nil {
+			continue
+		}
+		var r struct {
+			Hint string         `json:"hint"`
+			Args map[string]any `json:"args"`
+		}
+		if convert(fr.Response[responseKey], &r) != nil {
+			continue
+		}
+		reqs = append(reqs, Request{CallID: fr.ID, Tool: fr.Name, Args: r.Args, Hint: r.Hint})
+	}
+	return reqs
+}
+
+// Pending returns the requests for confirmation in sess that no decision
+// was carried out for yet, in order.
+func Pending(sess session.Session) []Request {
+	var reqs []Request
+	for ev := range sess.Events().All() {
+		for _, r := range Requests(ev) {
+			if _, ok := decided(sess.State(), r.CallID); !ok {
+				reqs = append(reqs, r)
+			}
+		}
+	}
+	return reqs
+}
+
+// decided returns the action decided for the call with the given ID, if
+// any.
+func decided(state session.ReadonlyState, callID string) (Action, bool) {
+	v, err := state.Get(StatePrefix + callID)
+	if err != nil || v == nil {
+		return "", false
+	}
+	var mark struct {
+		Action Action `json:"action"`
+	}
+	if convert(v, &mark) != nil {
+		return "", false
+	}
+	return mark.Action, true
+}
+
+// convert converts v, as decoded from JSON, to the struct pointed to by
+// out.
+func convert(v, out any) error {
+	if _, ok := v.(map[string]any); !ok {
+		return errors.New("not an object")
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vectormemory
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/memorychunk"
+)
+
+// Embedder turns texts into vectors, one per text, whose cosine similarity
+// reflects how close the texts are in meaning.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedderFunc adapts a function to an Embedder.
+type EmbedderFunc func(ctx context.Context, texts []string) ([][]float32, error)
+
+func (f EmbedderFunc) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return f(ctx, texts)
+}
+
+// DefaultDimensions is the size of the vectors of the default HashEmbedder.
+const DefaultDimensions = 512
+
+// HashEmbedder embeds a text by hashing its words, and the three-letter
+// pieces of each word, into a vector of Dimensions counts. The same text
+// always gives the same vector, with no model involved. Texts that share
+// words, or words that share pieces such as "project" and "projects", come
+// out similar; synonyms do not.
+type HashEmbedder struct {
+	Dimensions int
+}
+
+func (h HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if h.Dimensions <= 0 {
+		return nil, fmt.Errorf("HashEmbedder needs a positive number of dimensions, got %d", h.Dimensions)
+	}
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, h.Dimensions)
+		for _, w := range memorychunk.Words(text) {
+			h.add(v, "w:"+w, 1)
+			padded := []rune("^" + w + "$")
+			for j := 0; j+3 <= len(padded); j++ {
+				h.add(v, "t:"+string(padded[j:j+3]), 0.5)
+			}
+		}
+		normalize(v)
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+// add adds weight to the dimension feature hashes to, with a sign taken
+// from the hash too, so that collisions tend to cancel out.
+func (h HashEmbedder) add(v []float32, feature string, weight float32) {
+	f := fnv.New64a()
+	f.Write([]byte(feature))
+	sum := f.Sum64()
+	if sum&(1<<63) != 0 {
+		weight = -weight
+	}
+	v[sum%uint64(len(v))] += weight
+}
+
+func normalize(v []float32) {
+	var n float64
+	for _, x := range v {
+		n += float64(x) * float64(x)
+	}
+	if n == 0 {
+		return
+	}
+	n = math.Sqrt(n)
+	for i := range v {
+		v[i] = float32(float64(v[i]) / n)
+	}
+}
+
+// GenAIEmbedder returns an Embedder that calls the embedding model of the
+// Gemini API or Vertex AI client, such as "text-embedding-004".
+func GenAIEmbedder(client *genai.Client, model string) Embedder {
+	// The API embeds at most this many texts per request.
+	const batch = 100
+	return EmbedderFunc(func(ctx context.Context, texts []string) ([][]float32, error) {
+		vectors := make([][]float32, 0, len(texts))
+		for start := 0; start < len(texts); start += batch {
+			var contents []*genai.Content
+			for _, text := range texts[start:min(start+batch, len(texts))] {
+				contents = append(contents, genai.NewContentFromText(text, genai.RoleUser))
+			}
+			resp, err := client.Models.EmbedContent(ctx, model, contents, nil)
+			if err != nil {
+				return nil, err
+			}
+			if len(resp.Embeddings) != len(contents) {
+				return nil, fmt.Errorf("model %s returned %d embeddings for %d texts", model, len(resp.Embeddings), len(contents))
+			}
+			for _, e := range resp.Embeddings {
+				vectors = append(vectors, e.Values)
+			}
+		}
+		return vectors, nil
+	})
+}
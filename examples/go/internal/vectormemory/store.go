@@ -0,0 +1,266 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vectormemory
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// FileStore keeps the records in memory and writes them all to a JSON Lines
+// file, one record per line, after every change. It suits a few thousand
+// records; use an SQLiteStore beyond that.
+type FileStore struct {
+	path string
+
+	mu      sync.RWMutex
+	records map[fileKey][]Record
+}
+
+type fileKey struct{ appName, userID, sessionID string }
+
+// fileRecord is a line of the file.
+type fileRecord struct {
+	AppName string `json:"appName"`
+	UserID  string `json:"userId"`
+	Record
+}
+
+// OpenFile loads the records in the file at path, if it exists. The file is
+// created on the first change.
+func OpenFile(path string) (*FileStore, error) {
+	s := &FileStore{path: path, records: map[fileKey][]Record{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, 64<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var r fileRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		k := fileKey{r.AppName, r.UserID, r.SessionID}
+		s.records[k] = append(s.records[k], r.Record)
+	}
+	return s, sc.Err()
+}
+
+func (s *FileStore) Replace(ctx context.Context, appName, userID, sessionID string, records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := fileKey{appName, userID, sessionID}
+	old, had := s.records[k]
+	if len(records) == 0 {
+		delete(s.records, k)
+	} else {
+		s.records[k] = slices.Clone(records)
+	}
+	if err := s.save(); err != nil {
+		if had {
+			s.records[k] = old
+		} else {
+			delete(s.records, k)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) Records(ctx context.Context, appName, userID string) ([]Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var records []Record
+	for _, k := range s.keys() {
+		if k.appName == appName && k.userID == userID {
+			records = append(records, s.records[k]...)
+		}
+	}
+	return records, nil
+}
+
+// keys returns the keys of s.records in a stable order.
+func (s *FileStore) keys() []fileKey {
+	var keys []fileKey
+	for k := range s.records {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b fileKey) int {
+		return cmp.Or(cmp.Compare(a.appName, b.appName), cmp.Compare(a.userID, b.userID), cmp.Compare(a.sessionID, b.sessionID))
+	})
+	return keys
+}
+
+// save writes all records to a temporary file and renames it over the file,
+// so that a crash leaves either the old or the new records. A FileStore
+// without a path only keeps the records in memory.
+func (s *FileStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, k := range s.keys() {
+		for _, r := range s.records[k] {
+			if err := enc.Encode(fileRecord{AppName: k.appName, UserID: k.userID, Record: r}); err != nil {
+				return err
+			}
+		}
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS chunks (
+	app_name   TEXT NOT NULL,
+	user_id    TEXT NOT NULL,
+	session_id TEXT NOT NULL,
+	seq        INTEGER NOT NULL,
+	event_id   TEXT NOT NULL,
+	author     TEXT NOT NULL,
+	role       TEXT NOT NULL,
+	timestamp  INTEGER NOT NULL,
+	text       TEXT NOT NULL,
+	-- Little-endian float32 values.
+	vector     BLOB NOT NULL,
+	PRIMARY KEY (app_name, user_id, session_id, seq)
+);
+`
+
+// SQLiteStore keeps the records in an SQLite database file.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the database at path, creating it if needed.
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_busy_timeout=10000&_txlock=immediate")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema in %s: %w", path, err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Close closes the database.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) Replace(ctx context.Context, appName, userID, sessionID string, records []Record) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.ExecContext(ctx, `DELETE FROM chunks WHERE app_name = ? AND user_id = ? AND session_id = ?`, appName, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	for i, r := range records {
+		_, err := tx.ExecContext(ctx, `INSERT INTO chunks VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			appName, userID, sessionID, i, r.EventID, r.Author, r.Role, r.Timestamp.UnixNano(), r.Text, encodeVector(r.Vector))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) Records(ctx context.Context, appName, userID string) ([]Record, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT session_id, event_id, author, role, timestamp, text, vector FROM chunks
+		WHERE app_name = ? AND user_id = ? ORDER BY session_id, seq`, appName, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []Record
+	for rows.Next() {
+		var r Record
+		var ts int64
+		var vector []byte
+		if err := rows.Scan(&r.SessionID, &r.EventID, &r.Author, &r.Role, &ts, &r.Text, &vector); err != nil {
+			return nil, err
+		}
+		r.Timestamp = time.Unix(0, ts).UTC()
+		r.Vector = decodeVector(vector)
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+func encodeVector(v []float32) []byte {
+	b := make([]byte, 0, 4*len(v))
+	for _, x := range v {
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(x))
+	}
+	return b
+}
+
+func decodeVector(b []byte) []float32 {
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
+
+var (
+	_ Store = (*FileStore)(nil)
+	_ Store = (*SQLiteStore)(nil)
+)
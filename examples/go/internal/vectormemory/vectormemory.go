@@ -0,0 +1,178 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vectormemory provides a memory.Service that finds memories by the
+// meaning of the query rather than by shared keywords.
+//
+// AddSession splits the text of a session into chunks and embeds each one as
+// a vector through an Embedder; Search embeds the query the same way and
+// returns the chunks closest to it by cosine similarity. The vectors are kept
+// in a Store, a flat file (OpenFile) or an SQLite database (OpenSQLite):
+//
+//	store, err := vectormemory.OpenSQLite("memory.db")
+//	...
+//	memoryService := vectormemory.New(vectormemory.Config{
+//		Embedder: vectormemory.GenAIEmbedder(client, "text-embedding-004"),
+//		Store:    store,
+//	})
+//
+// HashEmbedder needs no model: it is deterministic and works offline, which
+// suits tests, but it only matches words and parts of words.
+package vectormemory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/session"
+
+	"github.com/google/adk-docs/examples/go/internal/memorychunk"
+)
+
+// Record is a chunk of a session with its embedding.
+type Record struct {
+	memorychunk.Chunk
+	Vector []float32 `json:"vector"`
+}
+
+// Store keeps the records of the sessions added to a Service.
+type Store interface {
+	// Replace stores records as all the records of a session, replacing
+	// those it had.
+	Replace(ctx context.Context, appName, userID, sessionID string, records []Record) error
+	// Records returns the records of every session of a user.
+	Records(ctx context.Context, appName, userID string) ([]Record, error)
+}
+
+// Config configures a Service.
+type Config struct {
+	// Embedder embeds chunks and queries. It defaults to a HashEmbedder
+	// with DefaultDimensions.
+	Embedder Embedder
+	// Store keeps the vectors. It defaults to a store in memory only.
+	Store Store
+	// Chunking configures how sessions are split.
+	Chunking memorychunk.Options
+	// Limit is the most memories a search returns. It defaults to 5.
+	Limit int
+	// MinScore is the cosine similarity a memory must exceed to be
+	// returned.
+	MinScore float64
+}
+
+// Service is a memory.Service that ranks memories by cosine similarity.
+type Service struct {
+	cfg Config
+}
+
+// New returns a Service.
+func New(cfg Config) *Service {
+	if cfg.Embedder == nil {
+		cfg.Embedder = HashEmbedder{Dimensions: DefaultDimensions}
+	}
+	if cfg.Store == nil {
+		cfg.Store = &FileStore{records: map[fileKey][]Record{}}
+	}
+	if cfg.Limit <= 0 {
+		cfg.Limit = 5
+	}
+	return &Service{cfg: cfg}
+}
+
+// AddSession embeds the chunks of sess and replaces whatever was stored for
+// it before, so adding a session again as it grows is fine.
+func (s *Service) AddSession(ctx context.Context, sess session.Session) error {
+	chunks := memorychunk.Split(sess, s.cfg.Chunking)
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.Text
+	}
+	var vectors [][]float32
+	if len(texts) > 0 {
+		var err error
+		if vectors, err = s.cfg.Embedder.Embed(ctx, texts); err != nil {
+			return fmt.Errorf("failed to embed session %s: %w", sess.ID(), err)
+		}
+		if len(vectors) != len(texts) {
+			return fmt.Errorf("embedder returned %d vectors for %d texts", len(vectors), len(texts))
+		}
+	}
+	records := make([]Record, len(chunks))
+	for i, c := range chunks {
+		records[i] = Record{Chunk: c, Vector: vectors[i]}
+	}
+	return s.cfg.Store.Replace(ctx, sess.AppName(), sess.UserID(), sess.ID(), records)
+}
+
+// Search returns the memories of the user most similar to the query, most
+// similar first.
+func (s *Service) Search(ctx context.Context, req *memory.SearchRequest) (*memory.SearchResponse, error) {
+	records, err := s.cfg.Store.Records(ctx, req.AppName, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &memory.SearchResponse{Memories: []memory.Entry{}}
+	if len(records) == 0 || req.Query == "" {
+		return resp, nil
+	}
+	query, err := s.cfg.Embedder.Embed(ctx, []string{req.Query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(query) != 1 {
+		return nil, fmt.Errorf("embedder returned %d vectors for 1 text", len(query))
+	}
+
+	type scored struct {
+		r     *Record
+		score float64
+	}
+	var hits []scored
+	for i := range records {
+		if score := Cosine(query[0], records[i].Vector); score > s.cfg.MinScore {
+			hits = append(hits, scored{&records[i], score})
+		}
+	}
+	slices.SortStableFunc(hits, func(a, b scored) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), b.r.Timestamp.Compare(a.r.Timestamp))
+	})
+	for _, h := range hits[:min(len(hits), s.cfg.Limit)] {
+		resp.Memories = append(resp.Memories, h.r.Entry())
+	}
+	return resp, nil
+}
+
+// Cosine returns the cosine similarity of a and b, or 0 if their lengths
+// differ or either is zero.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+var _ memory.Service = (*Service)(nil)
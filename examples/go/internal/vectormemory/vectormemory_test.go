@@ -0,0 +1,147 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vectormemory
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/localsession"
+)
+
+func newSession(userID, id string, texts ...string) session.Session {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	var events []*session.Event
+	for i, text := range texts {
+		e := session.NewEvent("inv")
+		e.ID = fmt.Sprintf("%s-%d", id, i)
+		e.Timestamp = start.Add(time.Duration(i) * time.Minute)
+		e.Author = "user"
+		e.Content = genai.NewContentFromText(text, genai.RoleUser)
+		events = append(events, e)
+	}
+	return localsession.New("app", userID, id, nil, events, start)
+}
+
+func search(t *testing.T, svc memory.Service, userID, query string) []string {
+	t.Helper()
+	resp, err := svc.Search(t.Context(), &memory.SearchRequest{AppName: "app", UserID: userID, Query: query})
+	if err != nil {
+		t.Fatalf("Search(%q) failed: %v", query, err)
+	}
+	var texts []string
+	for _, m := range resp.Memories {
+		texts = append(texts, m.Content.Parts[0].Text)
+	}
+	return texts
+}
+
+var stores = map[string]func(t *testing.T, path string) Store{
+	"file": func(t *testing.T, path string) Store {
+		s, err := OpenFile(path + ".jsonl")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	},
+	"sqlite": func(t *testing.T, path string) Store {
+		s, err := OpenSQLite(path + ".db")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	},
+}
+
+func TestSearch(t *testing.T) {
+	for name, open := range stores {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "memory")
+			svc := New(Config{Store: open(t, path), Limit: 2})
+			sessions := []session.Session{
+				newSession("alice", "s1", "The weather in Paris was lovely today.", "My favourite project is the Gemini garden planner."),
+				newSession("alice", "s2", "I had pasta for lunch.", "Remind me to call the plumber on Monday."),
+				newSession("bob", "s3", "My favorite project is a compiler."),
+			}
+			for _, s := range sessions {
+				if err := svc.AddSession(t.Context(), s); err != nil {
+					t.Fatalf("AddSession(%s) failed: %v", s.ID(), err)
+				}
+			}
+
+			got := search(t, svc, "alice", "What is my favorite project?")
+			if len(got) == 0 || got[0] != "My favourite project is the Gemini garden planner." {
+				t.Errorf("Search() = %q, want the garden planner first", got)
+			}
+			if len(got) > 2 {
+				t.Errorf("Search() returned %d memories, want at most 2", len(got))
+			}
+			if got := search(t, svc, "bob", "favorite project"); !slices.Equal(got, []string{"My favorite project is a compiler."}) {
+				t.Errorf("Search() for bob = %q, want only his memory", got)
+			}
+
+			// A new Service on the same file finds the same memories.
+			svc = New(Config{Store: open(t, path), Limit: 2})
+			if got2 := search(t, svc, "alice", "What is my favorite project?"); !slices.Equal(got2, got) {
+				t.Errorf("Search() after reopening = %q, want %q", got2, got)
+			}
+
+			// Adding a session again replaces its memories.
+			if err := svc.AddSession(t.Context(), newSession("alice", "s1", "Nothing to remember.")); err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range search(t, svc, "alice", "favourite project garden planner") {
+				if m == "My favourite project is the Gemini garden planner." {
+					t.Error("Search() still returns a memory of the replaced session")
+				}
+			}
+		})
+	}
+}
+
+func TestHashEmbedder(t *testing.T) {
+	e := HashEmbedder{Dimensions: 64}
+	a, err := e.Embed(t.Context(), []string{"favorite projects", "favorite projects", "pasta for lunch", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(a[0], a[1]) {
+		t.Error("Embed() of the same text differs")
+	}
+	if len(a[0]) != 64 {
+		t.Errorf("Embed() vector has %d dimensions, want 64", len(a[0]))
+	}
+	b, err := e.Embed(t.Context(), []string{"my favourite project"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if near, far := Cosine(a[0], b[0]), Cosine(a[2], b[0]); near <= far {
+		t.Errorf("Cosine() of related texts = %v, not above unrelated ones = %v", near, far)
+	}
+	if c := Cosine(a[3], b[0]); c != 0 {
+		t.Errorf("Cosine() with an empty text = %v, want 0", c)
+	}
+	if _, err := (HashEmbedder{}).Embed(t.Context(), []string{"x"}); err == nil {
+		t.Error("Embed() without dimensions succeeded, want an error")
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/cmd/launcher/adk"
+	"google.golang.org/adk/cmd/launcher/universal"
+	"google.golang.org/adk/cmd/launcher/web"
+	"google.golang.org/adk/cmd/launcher/web/api"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/gemini"
+	"google.golang.org/adk/server/restapi/services"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/mcpserve"
+)
+
+type getCapitalCityArgs struct {
+	Country string `json:"country" jsonschema:"The country for which to find the capital city."`
+}
+
+type getCapitalCityResult struct {
+	Result       string `json:"result,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+func getCapitalCity(ctx tool.Context, args getCapitalCityArgs) getCapitalCityResult {
+	capitals := map[string]string{
+		"united states": "Washington, D.C.",
+		"canada":        "Ottawa",
+		"france":        "Paris",
+		"japan":         "Tokyo",
+	}
+	capital, ok := capitals[strings.ToLower(args.Country)]
+	if !ok {
+		result := fmt.Sprintf("Sorry, I couldn't find the capital for %s.", args.Country)
+		return getCapitalCityResult{ErrorMessage: result}
+	}
+
+	return getCapitalCityResult{Result: capital}
+}
+
+// newCapitalAgent returns the get_capital_city tool, and an agent that uses
+// it.
+func newCapitalAgent(model model.LLM) (agent.Agent, tool.Tool, error) {
+	capitalTool, err := functiontool.New(
+		functiontool.Config{
+			Name:        "get_capital_city",
+			Description: "Retrieves the capital city for a given country.",
+		},
+		getCapitalCity,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create function tool: %w", err)
+	}
+
+	capitalAgent, err := llmagent.New(llmagent.Config{
+		Name:        "capital_agent",
+		Model:       model,
+		Description: "Agent to find the capital city of a country.",
+		Instruction: "I can answer your questions about the capital city of a country.",
+		Tools:       []tool.Tool{capitalTool},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create agent: %w", err)
+	}
+	return capitalAgent, capitalTool, nil
+}
+
+// --8<-- [start:mcp-launcher]
+func main() {
+	ctx := context.Background()
+
+	model, err := gemini.NewModel(ctx, "gemini-2.5-flash", &genai.ClientConfig{
+		APIKey: os.Getenv("GOOGLE_API_KEY"),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create model: %v", err)
+	}
+
+	capitalAgent, capitalTool, err := newCapitalAgent(model)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Serve the agent, and its tool on its own, over stdio by default, or
+	// over streamable HTTP with "web mcp".
+	l := universal.NewLauncher(
+		mcpserve.NewLauncher(capitalTool),
+		web.NewLauncher(api.NewLauncher(), mcpserve.NewWebLauncher(capitalTool)),
+	)
+
+	config := &adk.Config{
+		AgentLoader:    services.NewSingleAgentLoader(capitalAgent),
+		SessionService: session.InMemoryService(),
+	}
+
+	if err := l.Execute(ctx, config, os.Args[1:]); err != nil {
+		log.Fatalf("run failed: %v\n\n%s", err, l.CommandLineSyntax())
+	}
+}
+
+// --8<-- [end:mcp-launcher]
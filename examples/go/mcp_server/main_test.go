@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"google.golang.org/adk/tool"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+	"github.com/google/adk-docs/examples/go/internal/mcpserve"
+)
+
+// TestExample calls the agent and the tool main serves over MCP, through a
+// client connected in memory, on top of a recording session service.
+func TestExample(t *testing.T) {
+	m := golden.UseScript(t, "testdata/fakellm_script.json")
+	capitalAgent, capitalTool, err := newCapitalAgent(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &golden.Recorder{}
+	server, err := mcpserve.NewServer(mcpserve.Config{
+		Agent:          capitalAgent,
+		Tools:          []tool.Tool{capitalTool},
+		SessionService: rec.InMemoryService(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientTransport, serverTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(t.Context(), serverTransport, nil); err != nil {
+		t.Fatal(err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "v1.0.0"}, nil)
+	cs, err := client.Connect(t.Context(), clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	for _, c := range []struct {
+		name string
+		args map[string]any
+		want string
+	}{
+		{"capital_agent", map[string]any{"request": "What is the capital of Japan?"}, "The capital of Japan is Tokyo."},
+		{"get_capital_city", map[string]any{"country": "France"}, `{"result":"Paris"}`},
+	} {
+		res, err := cs.CallTool(t.Context(), &mcp.CallToolParams{Name: c.name, Arguments: c.args})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, content := range res.Content {
+			if tc, ok := content.(*mcp.TextContent); ok {
+				got = append(got, tc.Text)
+			}
+		}
+		if res.IsError || strings.Join(got, "\n") != c.want {
+			t.Errorf("%s = %v, %q, want %q", c.name, res.IsError, got, c.want)
+		}
+	}
+
+	golden.Check(t, "mcp_server", rec.Events())
+}
@@ -75,7 +75,6 @@ func NewStoryFlowAgent(
 }
 
 // --8<-- [end:init]
-
 // --8<-- [start:executionlogic]
 // Run defines the custom execution logic for the StoryFlowAgent.
 func (s *StoryFlowAgent) Run(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
@@ -145,7 +144,6 @@ func (s *StoryFlowAgent) Run(ctx agent.InvocationContext) iter.Seq2[*session.Eve
 }
 
 // --8<-- [end:executionlogic]
-
 const (
 	modelName = "gemini-2.0-flash"
 	appName   = "story_app"
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+// TestExample runs NewStoryFlowAgent with the sub-agents of main, which is
+// documented as a whole, on top of a recording session service. The scripted
+// tone check answers "negative" so the story is regenerated at the end.
+func TestExample(t *testing.T) {
+	m := golden.UseScript(t, "testdata/fakellm_script.json")
+	ctx := t.Context()
+
+	var subAgents []agent.Agent
+	for _, cfg := range []llmagent.Config{
+		{Name: "StoryGenerator", Instruction: "You are a story writer. Write a short story (around 100 words) about a cat, based on the topic: {topic}", OutputKey: "current_story"},
+		{Name: "Critic", Instruction: "You are a story critic. Review the story: {current_story}. Provide 1-2 sentences of constructive criticism on how to improve it. Focus on plot or character.", OutputKey: "criticism"},
+		{Name: "Reviser", Instruction: "You are a story reviser. Revise the story: {current_story}, based on the criticism: {criticism}. Output only the revised story.", OutputKey: "current_story"},
+		{Name: "GrammarCheck", Instruction: "You are a grammar checker. Check the grammar of the story: {current_story}. Output only the suggested corrections as a list, or output 'Grammar is good!' if there are no errors.", OutputKey: "grammar_suggestions"},
+		{Name: "ToneCheck", Instruction: "You are a tone analyzer. Analyze the tone of the story: {current_story}. Output only one word: 'positive' if the tone is generally positive, 'negative' if the tone is generally negative, or 'neutral' otherwise.", OutputKey: "tone_check_result"},
+	} {
+		cfg.Model = m
+		a, err := llmagent.New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		subAgents = append(subAgents, a)
+	}
+	storyFlowAgent, err := NewStoryFlowAgent(subAgents[0], subAgents[1], subAgents[2], subAgents[3], subAgents[4])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &golden.Recorder{}
+	sessionService := rec.InMemoryService()
+	s, err := sessionService.Create(ctx, &session.CreateRequest{
+		AppName: appName,
+		UserID:  userID,
+		State:   map[string]any{"topic": "a brave kitten exploring a haunted house"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: appName, Agent: storyFlowAgent, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Run(t, r, userID, s.Session.ID(), "Generate a story about: a lonely robot finding a friend in a junkyard")
+
+	golden.Check(t, "storyflow_agent", rec.Events())
+}
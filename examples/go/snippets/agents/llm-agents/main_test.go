@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+// TestExample rebuilds the two agents of main, which is documented as a
+// whole, and calls them the way callAgent does, on top of a recording session
+// service. Instructions and descriptions do not change the event stream and
+// are left out.
+func TestExample(t *testing.T) {
+	m := golden.UseScript(t, "testdata/fakellm_script.json")
+	capitalTool, err := functiontool.New(functiontool.Config{
+		Name:        "get_capital_city",
+		Description: "Retrieves the capital city for a given country.",
+	}, getCapitalCity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	countryInputSchema := &genai.Schema{
+		Type:       genai.TypeObject,
+		Properties: map[string]*genai.Schema{"country": {Type: genai.TypeString}},
+		Required:   []string{"country"},
+	}
+	capitalAgentWithTool, err := llmagent.New(llmagent.Config{
+		Name:        "capital_agent_tool",
+		Model:       m,
+		Tools:       []tool.Tool{capitalTool},
+		InputSchema: countryInputSchema,
+		OutputKey:   "capital_tool_result",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	structuredInfoAgentSchema, err := llmagent.New(llmagent.Config{
+		Name:        "structured_info_agent_schema",
+		Model:       m,
+		InputSchema: countryInputSchema,
+		OutputSchema: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"capital":             {Type: genai.TypeString},
+				"population_estimate": {Type: genai.TypeString},
+			},
+			Required: []string{"capital", "population_estimate"},
+		},
+		OutputKey: "structured_info_result",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &golden.Recorder{}
+	call := func(a agent.Agent, prompt string) {
+		t.Helper()
+		sessionService := rec.InMemoryService()
+		s, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: appName, UserID: userID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := runner.New(runner.Config{AppName: appName, Agent: a, SessionService: sessionService})
+		if err != nil {
+			t.Fatal(err)
+		}
+		golden.Run(t, r, userID, s.Session.ID(), prompt)
+	}
+	call(capitalAgentWithTool, `{"country": "France"}`)
+	call(capitalAgentWithTool, `{"country": "Canada"}`)
+	call(structuredInfoAgentSchema, `{"country": "France"}`)
+	call(structuredInfoAgentSchema, `{"country": "Japan"}`)
+
+	golden.Check(t, "llm_agents", rec.Events())
+}
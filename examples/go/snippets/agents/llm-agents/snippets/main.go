@@ -6,6 +6,7 @@ import (
 	"log"
 	"strings"
 
+	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
 	"google.golang.org/adk/model"
 	"google.golang.org/adk/model/gemini"
@@ -15,6 +16,10 @@ import (
 	"google.golang.org/genai"
 )
 
+// snippetAgents holds the agent of each snippet, by region name, so that
+// the tests can run them.
+var snippetAgents = map[string]agent.Agent{}
+
 // --- Documentation Snippets ---
 // The following functions are self-contained examples for documentation.
 // They are not called by the main application.
@@ -35,6 +40,7 @@ func _snippet_identity(model model.LLM) {
 	}
 
 	fmt.Println("Agent created:", agent.Name())
+	snippetAgents["identity"] = agent
 }
 
 func _snippet_instruction(model model.LLM) {
@@ -60,6 +66,7 @@ Example Response: "The capital of France is Paris."`,
 	}
 
 	fmt.Println("Agent with instruction created:", agent.Name())
+	snippetAgents["instruction"] = agent
 }
 
 func _snippet_tool_example(model model.LLM) {
@@ -103,6 +110,7 @@ func _snippet_tool_example(model model.LLM) {
 	}
 
 	fmt.Println("Agent with tool created:", agent.Name())
+	snippetAgents["tool_example"] = agent
 }
 
 func _snippet_schema_example(model model.LLM) {
@@ -132,6 +140,7 @@ func _snippet_schema_example(model model.LLM) {
 		log.Fatal(err)
 	}
 	fmt.Println("Agent with output schema created:", agent.Name())
+	snippetAgents["schema_example"] = agent
 }
 
 func _snippet_gen_config(model model.LLM) {
@@ -151,6 +160,7 @@ func _snippet_gen_config(model model.LLM) {
 		log.Fatalf("Failed to create agent with generation config: %v", err)
 	}
 	fmt.Println("Agent with generation config created:", agent.Name())
+	snippetAgents["gen_config"] = agent
 }
 
 func _snippet_include_contents(model model.LLM) {
@@ -165,6 +175,7 @@ func _snippet_include_contents(model model.LLM) {
 		log.Fatalf("Failed to create agent with include contents none: %v", err)
 	}
 	fmt.Println("Stateless agent created:", agent.Name())
+	snippetAgents["include_contents"] = agent
 }
 
 func main() {
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+// TestExample builds the snippet agents through main, then runs those that
+// answer prompts, each in its own session, and checks the events against a
+// golden file. The gen_config agent only differs in its model settings and
+// is left out.
+func TestExample(t *testing.T) {
+	m := golden.UseScript(t, "testdata/fakellm_script.json")
+	main()
+
+	rec := &golden.Recorder{}
+	sessionService := rec.InMemoryService()
+	for _, c := range []struct {
+		snippet string
+		state   map[string]any
+		prompts []string
+	}{
+		{"identity", nil, []string{"Hi, what can you do?"}},
+		// The instruction reads {country} from the session state.
+		{"instruction", map[string]any{"country": "Japan"}, []string{"What's the capital of Japan?"}},
+		{"tool_example", nil, []string{"What's the capital of Canada?"}},
+		{"schema_example", nil, []string{"France"}},
+		{"include_contents", nil, []string{"Hi.", "Good morning."}},
+	} {
+		r, err := runner.New(runner.Config{AppName: "llm_agents", Agent: snippetAgents[c.snippet], SessionService: sessionService})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "llm_agents", UserID: "user", State: c.state})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, prompt := range c.prompts {
+			golden.Run(t, r, "user", s.Session.ID(), prompt)
+		}
+	}
+	golden.Check(t, "snippets", rec.Events())
+
+	// The stateless agent only sees the prompt it answers.
+	reqs := m.Requests()
+	if n := len(reqs[len(reqs)-1].Contents); n != 1 {
+		t.Errorf("include_contents: the last request holds %d contents, want 1", n)
+	}
+}
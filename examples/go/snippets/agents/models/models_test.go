@@ -0,0 +1,15 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// TestExample builds the Gemini models, which needs credentials but no
+// network access. Nothing is run, so there is no event stream to compare.
+func TestExample(t *testing.T) {
+	if os.Getenv("GOOGLE_API_KEY") == "" && os.Getenv("GEMINI_API_KEY") == "" && os.Getenv("GOOGLE_GENAI_USE_VERTEXAI") == "" {
+		t.Skip("set GOOGLE_API_KEY, GEMINI_API_KEY or GOOGLE_GENAI_USE_VERTEXAI to build the Gemini models")
+	}
+	main()
+}
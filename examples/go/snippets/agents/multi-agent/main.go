@@ -169,6 +169,10 @@ func agentInteractionSnippets(m model.LLM) {
 	_ = artistAgent // Avoid unused variable error
 }
 
+// patternAgents holds the root agent of each advanced pattern snippet, by
+// region name, so that the tests can run them.
+var patternAgents = map[string]agent.Agent{}
+
 func advancedPatternSnippets(m model.LLM) {
 	// --8<-- [start:coordinator-pattern]
 	// Conceptual Code: Coordinator using LLM Transfer
@@ -185,7 +189,7 @@ func advancedPatternSnippets(m model.LLM) {
 	// User asks "My payment failed" -> Coordinator's LLM should call transfer_to_agent(agent_name='Billing')
 	// User asks "I can't log in" -> Coordinator's LLM should call transfer_to_agent(agent_name='Support')
 	// --8<-- [end:coordinator-pattern]
-	_ = coordinator // Avoid unused variable error
+	patternAgents["coordinator-pattern"] = coordinator
 
 	// --8<-- [start:sequential-pipeline-pattern]
 	// Conceptual Code: Sequential Data Pipeline
@@ -200,7 +204,7 @@ func advancedPatternSnippets(m model.LLM) {
 	// processor runs -> reads state["validation_status"], saves to state["result"]
 	// reporter runs -> reads state["result"]
 	// --8<-- [end:sequential-pipeline-pattern]
-	_ = dataPipeline // Avoid unused variable error
+	patternAgents["sequential-pipeline-pattern"] = dataPipeline
 
 	// --8<-- [start:parallel-gather-pattern]
 	// Conceptual Code: Parallel Information Gathering
@@ -219,7 +223,7 @@ func advancedPatternSnippets(m model.LLM) {
 	// fetch_api1 and fetch_api2 run concurrently, saving to state.
 	// synthesizer runs afterwards, reading state["api1_data"] and state["api2_data"].
 	// --8<-- [end:parallel-gather-pattern]
-	_ = overallWorkflow // Avoid unused variable error
+	patternAgents["parallel-gather-pattern"] = overallWorkflow
 
 	// --8<-- [start:hierarchical-pattern]
 	// Conceptual Code: Hierarchical Research Task
@@ -250,7 +254,7 @@ func advancedPatternSnippets(m model.LLM) {
 	// ResearchAssistant calls WebSearch and Summarizer tools.
 	// Results flow back up.
 	// --8<-- [end:hierarchical-pattern]
-	_ = reportWriter // Avoid unused variable error
+	patternAgents["hierarchical-pattern"] = reportWriter
 
 	// --8<-- [start:generator-critic-pattern]
 	// Conceptual Code: Generator-Critic
@@ -274,7 +278,7 @@ func advancedPatternSnippets(m model.LLM) {
 	// generator runs -> saves draft to state["draft_text"]
 	// reviewer runs -> reads state["draft_text"], saves status to state["review_status"]
 	// --8<-- [end:generator-critic-pattern]
-	_ = reviewPipeline // Avoid unused variable error
+	patternAgents["generator-critic-pattern"] = reviewPipeline
 
 	// --8<-- [start:iterative-refinement-pattern]
 	// Conceptual Code: Iterative Code Refinement
@@ -311,17 +315,22 @@ func advancedPatternSnippets(m model.LLM) {
 	// State["current_code"] is updated each iteration.
 	// Loop stops if QualityChecker outputs 'pass' (leading to StopChecker escalating) or after 5 iterations.
 	// --8<-- [end:iterative-refinement-pattern]
-	_ = refinementLoop // Avoid unused variable error
+	patternAgents["iterative-refinement-pattern"] = refinementLoop
 
 	// --8<-- [start:human-in-loop-pattern]
 	// Conceptual Code: Using a Tool for Human Approval
-	// --- Assume externalApprovalTool exists ---
-	// func externalApprovalTool(amount float64, reason string) string { ... }
 	type externalApprovalToolArgs struct {
 		Amount float64 `json:"amount" jsonschema:"The amount for which approval is requested."`
 		Reason string  `json:"reason" jsonschema:"The reason for the approval request."`
 	}
-	var externalApprovalTool func(tool.Context, externalApprovalToolArgs) string
+	// externalApprovalTool would:
+	// 1. Send the details to a human review system (e.g., via API).
+	// 2. Poll or wait for the human response (approved/rejected).
+	// 3. Return the human's decision.
+	externalApprovalTool := func(ctx tool.Context, args externalApprovalToolArgs) string {
+		// Stand-in for the review system, which approves every request.
+		return "approved"
+	}
 	approvalTool, _ := functiontool.New(
 		functiontool.Config{
 			Name:        "external_approval_tool",
@@ -354,7 +363,7 @@ func advancedPatternSnippets(m model.LLM) {
 		AgentConfig: agent.Config{Name: "HumanApprovalWorkflow", SubAgents: []agent.Agent{prepareRequest, requestApproval, processDecision}},
 	})
 	// --8<-- [end:human-in-loop-pattern]
-	_ = approvalWorkflow // Avoid unused variable error
+	patternAgents["human-in-loop-pattern"] = approvalWorkflow
 }
 
 func conceptualSnippets() {
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+// TestExample builds the snippet agents through main, then runs the root
+// agent of each advanced pattern the script covers, in a new session per
+// prompt, and checks the events of each pattern against its own golden
+// file. The parallel pattern is left out, as the order in which its agents
+// call the model is not deterministic.
+func TestExample(t *testing.T) {
+	golden.UseScript(t, "testdata/fakellm_script.json")
+	main()
+
+	for _, c := range []struct {
+		pattern string
+		prompts []string
+	}{
+		{"coordinator-pattern", []string{"My payment failed", "I can't log in"}},
+		{"sequential-pipeline-pattern", []string{"Process order 42."}},
+		{"hierarchical-pattern", []string{"Write a report on honeybees."}},
+		{"generator-critic-pattern", []string{"Subject: the Eiffel Tower."}},
+		{"iterative-refinement-pattern", []string{"Requirements: a typed Python function adding two integers."}},
+		{"human-in-loop-pattern", []string{"Approve 100 for the team dinner."}},
+	} {
+		rec := &golden.Recorder{}
+		sessionService := rec.InMemoryService()
+		r, err := runner.New(runner.Config{AppName: "multi_agent", Agent: patternAgents[c.pattern], SessionService: sessionService})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, prompt := range c.prompts {
+			s, err := sessionService.Create(t.Context(), &session.CreateRequest{AppName: "multi_agent", UserID: "user"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden.Run(t, r, "user", s.Session.ID(), prompt)
+		}
+		golden.Check(t, c.pattern, rec.Events())
+	}
+}
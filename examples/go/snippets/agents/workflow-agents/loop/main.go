@@ -18,6 +18,8 @@ import (
 	"google.golang.org/genai"
 )
 
+var newSessionService = session.InMemoryService
+
 const (
 	appName    = "IterativeWritingPipeline"
 	userID     = "test_user_456"
@@ -154,7 +156,7 @@ Carefully apply the suggestions to improve the 'Current Document'. Output *only*
 	}
 	// --8<-- [end:init]
 
-	sessionService := session.InMemoryService()
+	sessionService := newSessionService()
 	r, err := runner.New(runner.Config{
 		AppName:        appName,
 		Agent:          iterativeWriterAgent,
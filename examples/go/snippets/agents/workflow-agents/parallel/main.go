@@ -15,6 +15,8 @@ import (
 	"google.golang.org/genai"
 )
 
+var newSessionService = session.InMemoryService
+
 const (
 	appName   = "parallel_research_app"
 	userID    = "research_user_01"
@@ -149,7 +151,7 @@ Output *only* the structured report following this format. Do not include introd
 	}
 	// --8<-- [end:init]
 
-	sessionService := session.InMemoryService()
+	sessionService := newSessionService()
 	r, err := runner.New(runner.Config{
 		AppName:        appName,
 		Agent:          pipeline,
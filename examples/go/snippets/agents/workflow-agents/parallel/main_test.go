@@ -0,0 +1,33 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/session"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+func TestExample(t *testing.T) {
+	// The researchers race for the scripted turns, so they all get the same
+	// reply, and their events are put in a fixed order before comparing.
+	golden.UseScript(t, "testdata/fakellm_script.json")
+	events := golden.Main(t, &newSessionService, main)
+	var pos []int
+	var researchers []*session.Event
+	for i, e := range events {
+		if strings.HasSuffix(e.Author, "Researcher") {
+			pos = append(pos, i)
+			researchers = append(researchers, e)
+		}
+	}
+	slices.SortStableFunc(researchers, func(a, b *session.Event) int {
+		return strings.Compare(a.Author, b.Author)
+	})
+	for i, p := range pos {
+		events[p] = researchers[i]
+	}
+	golden.Check(t, "parallel", events)
+}
@@ -14,6 +14,8 @@ import (
 	"google.golang.org/genai"
 )
 
+var newSessionService = session.InMemoryService
+
 const (
 	appName   = "CodePipelineAgent"
 	userID    = "test_user_456"
@@ -123,7 +125,7 @@ Do not add any other text before or after the code block.`,
 	}
 	// --8<-- [end:init]
 
-	sessionService := session.InMemoryService()
+	sessionService := newSessionService()
 	r, err := runner.New(runner.Config{
 		AppName:        appName,
 		Agent:          codePipelineAgent,
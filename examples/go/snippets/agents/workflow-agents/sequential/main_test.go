@@ -0,0 +1,11 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+func TestExample(t *testing.T) {
+	golden.CheckMain(t, "sequential", &newSessionService, main)
+}
@@ -17,6 +17,8 @@ import (
 	"google.golang.org/genai"
 )
 
+var newSessionService = session.InMemoryService
+
 // This file contains snippets for the artifacts documentation.
 
 // BeforeModelCallback saves any images from the user input before calling the model.
@@ -195,7 +197,8 @@ func artifactData() {
 // namespacing demonstrates the difference between session and user-scoped artifacts.
 func namespacing() {
 	// --8<-- [start:namespacing]
-	// Note: Namespacing is only supported when using the GCS ArtifactService implementation.
+	// Note: artifact.InMemoryService and the GCS ArtifactService both
+	// recognize the "user:" prefix.
 	// A session-scoped artifact is only available within the current session.
 	sessionReportFilename := "summary.txt"
 	// A user-scoped artifact is available across all sessions for the current user.
@@ -321,7 +324,7 @@ func main() {
 	// 1. Set up services
 	ctx := context.Background()
 	artifactService := artifact.InMemoryService()
-	sessionService := session.InMemoryService()
+	sessionService := newSessionService()
 
 	// 2. Set up the agent with multiple callbacks
 	model, _ := gemini.NewModel(ctx, "gemini-2.5-flash", &genai.ClientConfig{})
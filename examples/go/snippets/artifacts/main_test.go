@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+func TestExample(t *testing.T) {
+	m := golden.UseScript(t, "testdata/fakellm_script.json")
+	golden.Check(t, "artifacts", golden.Main(t, &newSessionService, main))
+
+	// loadArtifactsCallback sent the saved report to the model.
+	reqs := m.Requests()
+	if len(reqs) == 0 {
+		t.Fatal("the model was not called")
+	}
+	var sent bool
+	for _, c := range reqs[0].Contents {
+		for _, p := range c.Parts {
+			if p.InlineData != nil && p.InlineData.MIMEType == "application/pdf" {
+				sent = true
+			}
+		}
+	}
+	if !sent {
+		t.Error("the first request to the model has no PDF, want generated_report.pdf")
+	}
+}
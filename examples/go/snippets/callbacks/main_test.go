@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+// TestExample rebuilds the agents of runBasicExample and runGuardrailExample,
+// whose session services are part of the documented snippets, on top of a
+// recording session service.
+func TestExample(t *testing.T) {
+	m := golden.UseScript(t, "testdata/fakellm_script.json")
+	ctx := context.Background()
+	rec := &golden.Recorder{}
+
+	newRunner := func(appName string, cfg llmagent.Config) (*runner.Runner, session.Service) {
+		t.Helper()
+		cfg.Model = m
+		a, err := llmagent.New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessionService := rec.InMemoryService()
+		r, err := runner.New(runner.Config{AppName: appName, Agent: a, SessionService: sessionService})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r, sessionService
+	}
+
+	r, sessionService := newRunner("CallbackBasicApp", llmagent.Config{
+		Name:                 "SimpleAgent",
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{onBeforeModel},
+	})
+	runAndPrint(ctx, r, sessionService, "CallbackBasicApp", "Why is the sky blue?")
+
+	r, sessionService = newRunner("GuardrailApp", llmagent.Config{
+		Name:                 "ChatAgent",
+		BeforeModelCallbacks: []llmagent.BeforeModelCallback{onBeforeModelGuardrail},
+	})
+	runAndPrint(ctx, r, sessionService, "GuardrailApp", "Tell me a fun fact about the Roman Empire.")
+	runAndPrint(ctx, r, sessionService, "GuardrailApp", "What is the best way to manage my finance portfolio?")
+
+	golden.Check(t, "callbacks", rec.Events())
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+type scenario struct {
+	sessionID    string
+	initialState map[string]any
+	prompt       string
+}
+
+// TestExample rebuilds the agent of each run*Example function, whose session
+// services are part of the documented snippets, on top of a recording session
+// service and runs the same scenarios against it.
+func TestExample(t *testing.T) {
+	m := golden.UseScript(t, "testdata/fakellm_script.json")
+	ctx := context.Background()
+	capitalTool, err := functiontool.New[*GetCapitalCityArgs, string](functiontool.Config{
+		Name:        "getCapitalCity",
+		Description: "Retrieves the capital city of a given country.",
+	}, getCapitalCity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	examples := []struct {
+		cfg       llmagent.Config
+		scenarios []scenario
+	}{
+		{
+			cfg: llmagent.Config{
+				Name:                 "AgentWithBeforeAgentCallback",
+				BeforeAgentCallbacks: []agent.BeforeAgentCallback{onBeforeAgent},
+				Instruction:          "You are a concise assistant.",
+			},
+			scenarios: []scenario{
+				{"session_normal", nil, "Hello, world!"},
+				{"session_skip", map[string]any{"skip_llm_agent": true}, "This should be skipped."},
+			},
+		},
+		{
+			cfg: llmagent.Config{
+				Name:                "AgentWithAfterAgentCallback",
+				AfterAgentCallbacks: []agent.AfterAgentCallback{onAfterAgent},
+				Instruction:         "You are a simple agent. Just say 'Processing complete!'",
+			},
+			scenarios: []scenario{
+				{"session_normal", nil, "Process this."},
+				{"session_modify", map[string]any{"add_concluding_note": true}, "Process and add note."},
+			},
+		},
+		{
+			cfg: llmagent.Config{
+				Name:                 "AgentWithBeforeModelCallback",
+				BeforeModelCallbacks: []llmagent.BeforeModelCallback{onBeforeModel},
+			},
+			scenarios: []scenario{
+				{"session_normal", nil, "Tell me a fun fact."},
+				{"session_blocked", nil, "write a joke on BLOCK"},
+			},
+		},
+		{
+			cfg: llmagent.Config{
+				Name:                "AgentWithAfterModelCallback",
+				AfterModelCallbacks: []llmagent.AfterModelCallback{onAfterModel},
+			},
+			scenarios: []scenario{
+				{"session_modify", nil, "Give me a paragraph about different styles of jokes."},
+			},
+		},
+		{
+			cfg: llmagent.Config{
+				Name:                "AgentWithBeforeToolCallback",
+				Tools:               []tool.Tool{capitalTool},
+				BeforeToolCallbacks: []llmagent.BeforeToolCallback{onBeforeTool},
+				Instruction:         "You are an agent that can find capital cities. Use the getCapitalCity tool.",
+			},
+			scenarios: []scenario{
+				{"session_tool_modify", nil, "What is the capital of Canada?"},
+				{"session_tool_block", nil, "capital of BLOCK"},
+			},
+		},
+		{
+			cfg: llmagent.Config{
+				Name:               "AgentWithAfterToolCallback",
+				Tools:              []tool.Tool{capitalTool},
+				AfterToolCallbacks: []llmagent.AfterToolCallback{onAfterTool},
+				Instruction:        "You are an agent that finds capital cities. Use the getCapitalCity tool.",
+			},
+			scenarios: []scenario{
+				{"session_tool_after_modify", nil, "capital of united states"},
+			},
+		},
+	}
+
+	rec := &golden.Recorder{}
+	for _, ex := range examples {
+		ex.cfg.Model = m
+		a, err := llmagent.New(ex.cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessionService := rec.InMemoryService()
+		r, err := runner.New(runner.Config{AppName: appName, Agent: a, SessionService: sessionService})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range ex.scenarios {
+			runScenario(ctx, r, sessionService, appName, s.sessionID, s.initialState, s.prompt)
+		}
+	}
+
+	golden.Check(t, "types_of_callbacks", rec.Events())
+}
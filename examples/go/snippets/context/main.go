@@ -21,6 +21,8 @@ import (
 	"google.golang.org/genai"
 )
 
+var newSessionService = session.InMemoryService
+
 // --- Conceptual Snippets for adk-docs/docs/context/index.md ---
 const (
 	modelName = "gemini-2.5-flash"
@@ -32,7 +34,7 @@ const (
 func runScenario(ctx context.Context, agentToRun agent.Agent, sessionID string, initialState map[string]any, prompt string) {
 	log.Printf("Running scenario for session: %s, initial state: %v", sessionID, initialState)
 
-	sessionService := session.InMemoryService()
+	sessionService := newSessionService()
 	artifactService := artifact.InMemoryService()
 	rcfg := runner.Config{
 		AppName:         appName,
@@ -28,6 +28,8 @@ import (
 	"google.golang.org/genai"
 )
 
+var newSessionService = session.InMemoryService
+
 const (
 	appName   = "instruction_provider_app"
 	userID    = "user5678"
@@ -61,7 +63,7 @@ func dynamicInstructionProvider(ctx agent.ReadonlyContext) (string, error) {
 
 func main() {
 	ctx := context.Background()
-	sessionService := session.InMemoryService()
+	sessionService := newSessionService()
 
 	// Initialize a session with state for the dynamic provider.
 	_, err := sessionService.Create(ctx, &session.CreateRequest{
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+// TestExample records that the dynamic agent never reaches the model:
+// instructionutil.InjectSessionState looks up {{literal_braces}} as a state
+// key instead of leaving it alone, so the run stops with an error.
+func TestExample(t *testing.T) {
+	golden.CheckMain(t, "instruction_provider", &newSessionService, main)
+}
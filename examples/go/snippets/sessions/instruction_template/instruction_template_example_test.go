@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+// TestExample rebuilds the agent of main, which is documented as a whole, on
+// top of a recording session service, and checks that {topic} reached the
+// model filled in from the session state.
+func TestExample(t *testing.T) {
+	m := golden.UseScript(t, "testdata/fakellm_script.json")
+	ctx := t.Context()
+	rec := &golden.Recorder{}
+	sessionService := rec.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{
+		AppName:   appName,
+		UserID:    userID,
+		SessionID: sessionID,
+		State:     map[string]any{"topic": "friendship"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	storyGenerator, err := llmagent.New(llmagent.Config{
+		Name:        "StoryGenerator",
+		Model:       m,
+		Instruction: "Write a short story about a cat, focusing on the theme: {topic}.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: appName, Agent: storyGenerator, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Run(t, r, userID, sessionID, "Tell me a story.")
+
+	reqs := m.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("got %d model requests, want 1", len(reqs))
+	}
+	const want = "focusing on the theme: friendship."
+	if si := reqs[0].Config.SystemInstruction; si == nil || len(si.Parts) == 0 || !strings.Contains(si.Parts[0].Text, want) {
+		t.Errorf("system instruction = %+v, want it to contain %q", si, want)
+	}
+	golden.Check(t, "instruction_template", rec.Events())
+}
@@ -50,7 +50,6 @@ type Result struct {
 }
 
 // --8<-- [start:tool_search]
-
 // memorySearchToolFunc is the implementation of the memory search tool.
 // This function demonstrates accessing memory via tool.Context.
 func memorySearchToolFunc(tctx tool.Context, args Args) Result {
@@ -81,7 +80,6 @@ var memorySearchTool = must(functiontool.New[Args, Result](
 ))
 
 // --8<-- [end:tool_search]
-
 // This example demonstrates how to use the MemoryService in the Go ADK.
 // It covers two main scenarios:
 // 1. Adding a completed session to memory and recalling it in a new session.
@@ -127,7 +125,7 @@ func main() {
 
 	// Add the completed session to the Memory Service
 	fmt.Println("\n--- Adding Session 1 to Memory ---")
-	completedSession := sessionService.Get(ctx, &session.GetRequest{AppName: appName, UserID: userID, SessionID: session1ID}).Session
+	completedSession := must(sessionService.Get(ctx, &session.GetRequest{AppName: appName, UserID: userID, SessionID: session1ID})).Session
 	if err := memoryService.AddSession(ctx, completedSession); err != nil {
 		log.Fatalf("Failed to add session to memory: %v", err)
 	}
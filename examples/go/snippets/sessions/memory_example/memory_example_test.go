@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/memory"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+// TestExample replays the two scenarios of main, which is documented as a
+// whole, on top of a recording session service.
+func TestExample(t *testing.T) {
+	m := golden.UseScript(t, "testdata/fakellm_script.json")
+	ctx := t.Context()
+	rec := &golden.Recorder{}
+	sessionService := rec.InMemoryService()
+	memoryService := memory.InMemoryService()
+
+	infoCaptureAgent, err := llmagent.New(llmagent.Config{
+		Name:        "InfoCaptureAgent",
+		Model:       m,
+		Instruction: "Acknowledge the user's statement.",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner1, err := runner.New(runner.Config{
+		AppName:        appName,
+		Agent:          infoCaptureAgent,
+		SessionService: sessionService,
+		MemoryService:  memoryService,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "session_info"}); err != nil {
+		t.Fatal(err)
+	}
+	golden.Run(t, runner1, userID, "session_info", "My favorite project is Project Alpha.")
+
+	completed, err := sessionService.Get(ctx, &session.GetRequest{AppName: appName, UserID: userID, SessionID: "session_info"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := memoryService.AddSession(ctx, completed.Session); err != nil {
+		t.Fatal(err)
+	}
+
+	memoryRecallAgent, err := llmagent.New(llmagent.Config{
+		Name:        "MemoryRecallAgent",
+		Model:       m,
+		Instruction: "Answer the user's question. Use the 'search_past_conversations' tool if the answer might be in past conversations.",
+		Tools:       []tool.Tool{memorySearchTool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner2, err := runner.New(runner.Config{
+		AppName:        appName,
+		Agent:          memoryRecallAgent,
+		SessionService: sessionService,
+		MemoryService:  memoryService,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: appName, UserID: userID, SessionID: "session_recall"}); err != nil {
+		t.Fatal(err)
+	}
+	golden.Run(t, runner2, userID, "session_recall", "What is my favorite project?")
+
+	golden.Check(t, "memory_example", rec.Events())
+}
@@ -22,6 +22,8 @@ import (
 	"google.golang.org/adk/session"
 )
 
+var newSessionService = session.InMemoryService
+
 // This example demonstrates session management in the Go ADK, covering:
 // 1. Initializing different SessionService implementations.
 // 2. Creating a session and examining its properties.
@@ -34,7 +36,7 @@ func main() {
 	// 1. InMemoryService
 	// Stores all session data directly in the application's memory.
 	// All conversation data is lost if the application restarts.
-	inMemoryService := session.InMemoryService()
+	inMemoryService := newSessionService()
 	fmt.Println("Initialized InMemoryService.")
 
 	// --8<-- [start:vertexai_service]
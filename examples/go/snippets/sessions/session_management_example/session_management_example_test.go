@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/adk/session"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+// call is the stable form of one call main makes on its session service.
+// Session IDs are generated randomly and left out.
+type call struct {
+	Method  string         `json:"method"`
+	AppName string         `json:"appName"`
+	UserID  string         `json:"userId"`
+	State   map[string]any `json:"state,omitempty"`
+}
+
+type loggingService struct {
+	session.Service
+	calls []call
+}
+
+func (s *loggingService) Create(ctx context.Context, req *session.CreateRequest) (*session.CreateResponse, error) {
+	s.calls = append(s.calls, call{Method: "Create", AppName: req.AppName, UserID: req.UserID, State: req.State})
+	return s.Service.Create(ctx, req)
+}
+
+func (s *loggingService) Delete(ctx context.Context, req *session.DeleteRequest) error {
+	s.calls = append(s.calls, call{Method: "Delete", AppName: req.AppName, UserID: req.UserID})
+	return s.Service.Delete(ctx, req)
+}
+
+func TestExample(t *testing.T) {
+	svc := &loggingService{Service: session.InMemoryService()}
+	orig := newSessionService
+	newSessionService = func() session.Service { return svc }
+	t.Cleanup(func() { newSessionService = orig })
+
+	main()
+
+	resp, err := svc.List(t.Context(), &session.ListRequest{AppName: "my_go_app", UserID: "example_go_user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(resp.Sessions); n != 0 {
+		t.Errorf("%d sessions left after main, want 0", n)
+	}
+	golden.CheckValue(t, "session_management", svc.calls)
+}
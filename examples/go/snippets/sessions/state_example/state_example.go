@@ -29,8 +29,14 @@ import (
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/functiontool"
 	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/filesession"
 )
 
+// Set ADK_SESSION_DIR to a fresh directory to keep the sessions on disk, one
+// readable events.jsonl per session.
+var newSessionService = filesession.FromEnv(session.InMemoryService)
+
 const (
 	appName   = "state_example_app"
 	userID    = "user1234"
@@ -234,7 +240,7 @@ func contextStateUpdateExample(sessionService session.Service) {
 // --8<-- [end:context]
 func main() {
 	ctx := context.Background()
-	sessionService := session.InMemoryService()
+	sessionService := newSessionService()
 
 	// Initialize session with some state
 	_, err := sessionService.Create(ctx, &session.CreateRequest{
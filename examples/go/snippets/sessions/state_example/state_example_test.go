@@ -0,0 +1,21 @@
+package main
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+func TestExample(t *testing.T) {
+	golden.UseScript(t, "testdata/fakellm_script.json")
+	events := golden.Normalize(golden.Main(t, &newSessionService, main))
+	for _, e := range events {
+		// manualStateUpdateExample stamps the login with the current time.
+		if _, ok := e.StateDelta["user:last_login_ts"]; ok {
+			e.StateDelta = maps.Clone(e.StateDelta)
+			e.StateDelta["user:last_login_ts"] = "<timestamp>"
+		}
+	}
+	golden.CheckValue(t, "state_example", events)
+}
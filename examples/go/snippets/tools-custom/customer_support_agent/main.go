@@ -14,8 +14,14 @@ import (
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/functiontool"
 	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/filesession"
 )
 
+// Set ADK_SESSION_DIR to a fresh directory to keep the sessions on disk, one
+// readable events.jsonl per session.
+var newSessionService = filesession.FromEnv(session.InMemoryService)
+
 type checkAndTransferArgs struct {
 	Query string `json:"query" jsonschema:"The user's query to check for urgency."`
 }
@@ -71,7 +77,7 @@ func main() {
 		log.Fatal(err)
 	}
 
-	sessionService := session.InMemoryService()
+	sessionService := newSessionService()
 	runner, err := runner.New(runner.Config{
 		AppName:        "customer_support_agent",
 		Agent:          mainAgent,
@@ -19,6 +19,8 @@ import (
 	"google.golang.org/genai"
 )
 
+var newSessionService = session.InMemoryService
+
 func saveStoryBytes(ctx agent.CallbackContext, req *model.LLMRequest) (*model.LLMResponse, error) {
 	// Get the report data from the session state.
 	storyData, err := ctx.State().Get("story_bytes")
@@ -85,7 +87,7 @@ func main() {
 		log.Fatal(err)
 	}
 
-	sessionService := session.InMemoryService()
+	sessionService := newSessionService()
 	artifactService := artifact.InMemoryService()
 	memoryService := memory.InMemoryService()
 	runner, err := runner.New(runner.Config{
@@ -15,6 +15,8 @@ import (
 	"google.golang.org/genai"
 )
 
+var newSessionService = session.InMemoryService
+
 func main() {
 	ctx := context.Background()
 	model, err := gemini.NewModel(ctx, "gemini-2.0-flash", &genai.ClientConfig{})
@@ -43,7 +45,7 @@ func main() {
 		log.Fatal(err)
 	}
 
-	sessionService := session.InMemoryService()
+	sessionService := newSessionService()
 	runner, err := runner.New(runner.Config{
 		AppName:        "user_preference",
 		Agent:          mainAgent,
@@ -16,6 +16,8 @@ import (
 	"google.golang.org/genai"
 )
 
+var newSessionService = session.InMemoryService
+
 type getWeatherReportArgs struct {
 	City string `json:"city" jsonschema:"The city for which to get the weather report."`
 }
@@ -94,7 +96,7 @@ func main() {
 		log.Fatal(err)
 	}
 
-	sessionService := session.InMemoryService()
+	sessionService := newSessionService()
 	runner, err := runner.New(runner.Config{
 		AppName:        "weather_sentiment_agent",
 		Agent:          weatherSentimentAgent,
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+func TestExample(t *testing.T) {
+	golden.UseCassette(t, "testdata/cassette.jsonl", "testdata/fakellm_script.json")
+	golden.Check(t, "weather_sentiment", golden.Main(t, &newSessionService, main))
+}
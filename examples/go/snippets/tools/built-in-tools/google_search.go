@@ -15,6 +15,8 @@ import (
 	"google.golang.org/genai"
 )
 
+var newSessionService = session.InMemoryService
+
 func createSearchAgent(ctx context.Context) (agent.Agent, error) {
 	model, err := gemini.NewModel(ctx, "gemini-2.5-flash", &genai.ClientConfig{})
 	if err != nil {
@@ -36,7 +38,7 @@ const (
 )
 
 func callAgent(ctx context.Context, a agent.Agent, prompt string) error {
-	sessionService := session.InMemoryService()
+	sessionService := newSessionService()
 	session, err := sessionService.Create(ctx, &session.CreateRequest{
 		AppName: appName,
 		UserID:  userID,
@@ -18,6 +18,8 @@ import (
 	"google.golang.org/genai"
 )
 
+var newSessionService = session.InMemoryService
+
 // mockStockPrices provides a simple in-memory database of stock prices
 // to simulate a real-world stock data API. This allows the example to
 // demonstrate tool functionality without making external network calls.
@@ -99,7 +101,7 @@ const (
 // to manage the agent's lifecycle. It streams the agent's responses and
 // prints them to the console, handling any potential errors during the run.
 func callAgent(ctx context.Context, a agent.Agent, prompt string) {
-	sessionService := session.InMemoryService()
+	sessionService := newSessionService()
 	// Create a new session for the agent interactions.
 	session, err := sessionService.Create(ctx, &session.CreateRequest{
 		AppName: appName,
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/google/adk-docs/examples/go/internal/golden"
+)
+
+func TestExample(t *testing.T) {
+	golden.UseScript(t, "testdata/fakellm_script.json")
+	ctx := context.Background()
+
+	ticketAgent, err := createTicketAgent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &golden.Recorder{}
+	sessionService := rec.InMemoryService()
+	s, err := sessionService.Create(ctx, &session.CreateRequest{AppName: appName, UserID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: appName, Agent: ticketAgent, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	funcCallID := runTurn(ctx, r, s.Session.ID(), "Turn 1", genai.NewContentFromText("Create a high urgency ticket for me.", genai.RoleUser))
+	if funcCallID == "" {
+		t.Fatal("create_ticket_long_running was not called in turn 1")
+	}
+	willContinue := false
+	runTurn(ctx, r, s.Session.ID(), "Turn 2", &genai.Content{
+		Role: string(genai.RoleUser),
+		Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{
+			Name:         "create_ticket_long_running",
+			ID:           funcCallID,
+			Response:     map[string]any{"status": "approved", "ticket_id": "TICKET-ABC-123"},
+			WillContinue: &willContinue,
+		}}},
+	})
+
+	golden.Check(t, "long_running_tool", rec.Events())
+}
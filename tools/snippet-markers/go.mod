@@ -0,0 +1,3 @@
+module snippet-markers
+
+go 1.24.4
@@ -0,0 +1,137 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command snippet-markers validates the --8<-- [start:name] / [end:name]
+// regions the docs pull out of the Go examples, and extracts them.
+//
+// Run it from this directory:
+//
+//	go run . check
+//	go run . extract -out /tmp/snippets
+//
+// check reports regions that are unbalanced, duplicated or cross another
+// region, and snippet references in docs/*.md that name a missing file or
+// region. With -unused it also lists regions no doc references.
+//
+// extract writes every region to <out>/<file>/<region>.go the way the docs
+// render it, and reports the ones gofmt would change. A region that is not a
+// whole file, declarations or statements on its own is written but cannot be
+// checked.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const usage = `usage: snippet-markers [-root dir] [-examples dir] [-docs dir] command [flags]
+
+commands:
+  check [-unused]   report malformed regions and broken doc references
+  extract -out dir  write every region as a gofmt-checked fragment
+`
+
+func main() {
+	root := flag.String("root", "../..", "repository root")
+	examples := flag.String("examples", "examples/go", "directory with the Go examples, relative to -root")
+	docs := flag.String("docs", "docs", "directory with the Markdown docs, relative to -root")
+	flag.Usage = func() { fmt.Fprint(flag.CommandLine.Output(), usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	files, problems, err := parseTree(*root, *examples)
+	if err != nil {
+		log.Fatalf("cannot read examples: %s", err)
+	}
+
+	switch cmd, args := flag.Arg(0), flag.Args()[1:]; cmd {
+	case "check":
+		fs := flag.NewFlagSet("check", flag.ExitOnError)
+		unused := fs.Bool("unused", false, "also report regions no doc references")
+		fs.Parse(args)
+
+		refs, err := parseRefs(*root, *docs, filepath.ToSlash(*examples)+"/")
+		if err != nil {
+			log.Fatalf("cannot read docs: %s", err)
+		}
+		problems = append(problems, checkRefs(files, refs)...)
+		if *unused {
+			problems = append(problems, unreferenced(files, refs)...)
+		}
+		log.Printf("checked %d files and %d doc references", len(files), len(refs))
+
+	case "extract":
+		fs := flag.NewFlagSet("extract", flag.ExitOnError)
+		out := fs.String("out", "", "directory to write the fragments to")
+		fs.Parse(args)
+		if *out == "" {
+			log.Fatal("extract needs -out")
+		}
+
+		n, partial, ps, err := extract(files, *examples, *out)
+		if err != nil {
+			log.Fatalf("cannot write fragments: %s", err)
+		}
+		problems = append(problems, ps...)
+		log.Printf("wrote %d fragments to %s, %d of them not checked by gofmt as they do not parse on their own", n, *out, partial)
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+// extract writes the fragment of every well-formed region in files below
+// out, mirroring their paths relative to examples.
+// It returns how many fragments it wrote and how many of those did not parse
+// on their own.
+func extract(files []*sourceFile, examples, out string) (n, partial int, problems []problem, err error) {
+	for _, f := range files {
+		rel := strings.TrimPrefix(f.Path, filepath.ToSlash(examples)+"/")
+		dir := filepath.Join(out, filepath.FromSlash(strings.TrimSuffix(rel, ".go")))
+		for _, r := range f.Regions {
+			if r.End == 0 {
+				continue
+			}
+			src := f.fragment(r)
+			if formatted, ok := gofmtFragment(src); !ok {
+				partial++
+			} else if formatted != src {
+				problems = append(problems, problem{f.Path, r.Start, fmt.Sprintf("region %q is not gofmt-formatted once extracted", r.Name)})
+			}
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return n, partial, nil, err
+			}
+			if err := os.WriteFile(filepath.Join(dir, r.Name+".go"), []byte(src), 0o644); err != nil {
+				return n, partial, nil, err
+			}
+			n++
+		}
+	}
+	return n, partial, problems, nil
+}
@@ -0,0 +1,324 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// markerRE matches a snippet section marker the way pymdownx.snippets does:
+// anything may precede the marker on its line, typically a comment leader.
+var markerRE = regexp.MustCompile(`(?i)--8<--[ \t]+\[[ \t]*(start|end)[ \t]*:[ \t]*([a-z][-_0-9a-z]*)[ \t]*\]`)
+
+// refRE matches an inline snippet reference in a Markdown file, such as
+// --8<-- "examples/go/snippets/context/main.go:runScenario".
+var refRE = regexp.MustCompile(`--8<--[ \t]+"([^"]+)"`)
+
+// region is a named section of a source file, delimited by a start and an
+// end marker. Lines are 1-based; End is 0 while the region is still open.
+type region struct {
+	Name  string
+	Start int
+	End   int
+}
+
+// sourceFile holds the regions of one file and the lines they cut out of it.
+type sourceFile struct {
+	Path    string // slash-separated, relative to the repository root
+	Lines   []string
+	Regions []*region
+}
+
+// problem is a finding reported against a file and line.
+type problem struct {
+	Path string
+	Line int
+	Msg  string
+}
+
+func (p problem) String() string {
+	return fmt.Sprintf("%s:%d: %s", p.Path, p.Line, p.Msg)
+}
+
+// parseFile reads the markers of the file at root/path and reports the ones
+// that are unbalanced, duplicated or cross another region.
+func parseFile(root, path string) (*sourceFile, []problem, error) {
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+	if err != nil {
+		return nil, nil, err
+	}
+	f := &sourceFile{Path: path, Lines: splitLines(data)}
+
+	var (
+		problems []problem
+		open     []*region
+		byName   = map[string]*region{}
+	)
+	for i, line := range f.Lines {
+		m := markerRE.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		lineNo, kind, name := i+1, strings.ToLower(m[1]), m[2]
+		switch kind {
+		case "start":
+			if prev, ok := byName[name]; ok {
+				problems = append(problems, problem{path, lineNo, fmt.Sprintf("region %q already started at line %d", name, prev.Start)})
+				continue
+			}
+			r := &region{Name: name, Start: lineNo}
+			byName[name] = r
+			open = append(open, r)
+			f.Regions = append(f.Regions, r)
+		case "end":
+			j := slices.IndexFunc(open, func(r *region) bool { return r.Name == name })
+			if j < 0 {
+				if r, ok := byName[name]; ok && r.End != 0 {
+					problems = append(problems, problem{path, lineNo, fmt.Sprintf("region %q already ended at line %d", name, r.End)})
+				} else {
+					problems = append(problems, problem{path, lineNo, fmt.Sprintf("end of region %q that was never started", name)})
+				}
+				continue
+			}
+			// Regions may nest, but one that started inside this region must
+			// also end inside it.
+			for _, inner := range open[j+1:] {
+				problems = append(problems, problem{path, lineNo, fmt.Sprintf("region %q ends before region %q, started inside it at line %d", name, inner.Name, inner.Start)})
+			}
+			open[j].End = lineNo
+			open = slices.Delete(open, j, j+1)
+		}
+	}
+	for _, r := range open {
+		problems = append(problems, problem{path, r.Start, fmt.Sprintf("region %q is never ended", r.Name)})
+	}
+	return f, problems, nil
+}
+
+// region returns the region of f called name, if it is well formed.
+func (f *sourceFile) region(name string) (*region, bool) {
+	for _, r := range f.Regions {
+		if r.Name == name && r.End != 0 {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// fragment returns the text of r the way the docs render it: without the
+// marker lines of the regions nested in it, dedented, and without leading or
+// trailing blank lines.
+func (f *sourceFile) fragment(r *region) string {
+	var lines []string
+	for _, line := range f.Lines[r.Start : r.End-1] {
+		if !markerRE.MatchString(line) {
+			lines = append(lines, line)
+		}
+	}
+	blank := func(s string) bool { return strings.TrimSpace(s) == "" }
+	for len(lines) > 0 && blank(lines[0]) {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && blank(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	return dedent(lines)
+}
+
+// parseTree parses every .go file under root/dir.
+func parseTree(root, dir string) ([]*sourceFile, []problem, error) {
+	var (
+		files    []*sourceFile
+		problems []problem
+	)
+	err := filepath.WalkDir(filepath.Join(root, dir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		f, ps, err := parseFile(root, filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		problems = append(problems, ps...)
+		return nil
+	})
+	return files, problems, err
+}
+
+// reference is a snippet include found in a Markdown file.
+type reference struct {
+	Doc     string // slash-separated, relative to the repository root
+	Line    int
+	Target  string // file path, relative to the repository root
+	Section string // region name, or "" for the whole file or a line range
+}
+
+// parseRefs collects the snippet references under root/docsDir that point
+// into prefix.
+func parseRefs(root, docsDir, prefix string) ([]reference, error) {
+	var refs []reference
+	err := filepath.WalkDir(filepath.Join(root, docsDir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".md") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		for i, line := range splitLines(data) {
+			for _, m := range refRE.FindAllStringSubmatch(line, -1) {
+				if !strings.HasPrefix(m[1], prefix) {
+					continue
+				}
+				ref := reference{Doc: filepath.ToSlash(rel), Line: i + 1, Target: m[1]}
+				// "file:name" selects a region and "file:10:20" a line range.
+				if file, section, ok := strings.Cut(m[1], ":"); ok {
+					ref.Target = file
+					if !isLineRange(section) {
+						ref.Section = section
+					}
+				}
+				refs = append(refs, ref)
+			}
+		}
+		return nil
+	})
+	return refs, err
+}
+
+func isLineRange(s string) bool {
+	return strings.Trim(s, "0123456789:") == ""
+}
+
+// checkRefs reports references to files or regions that do not exist.
+func checkRefs(files []*sourceFile, refs []reference) []problem {
+	byPath := map[string]*sourceFile{}
+	for _, f := range files {
+		byPath[f.Path] = f
+	}
+	var problems []problem
+	for _, ref := range refs {
+		f, ok := byPath[ref.Target]
+		switch {
+		case !ok:
+			problems = append(problems, problem{ref.Doc, ref.Line, fmt.Sprintf("snippet file %s does not exist", ref.Target)})
+		case ref.Section != "":
+			if _, ok := f.region(ref.Section); !ok {
+				problems = append(problems, problem{ref.Doc, ref.Line, fmt.Sprintf("snippet file %s has no region %q", ref.Target, ref.Section)})
+			}
+		}
+	}
+	return problems
+}
+
+// unreferenced returns the well-formed regions no reference points at.
+func unreferenced(files []*sourceFile, refs []reference) []problem {
+	used := map[string]bool{}
+	for _, ref := range refs {
+		used[ref.Target+":"+ref.Section] = true
+	}
+	var problems []problem
+	for _, f := range files {
+		for _, r := range f.Regions {
+			if r.End != 0 && !used[f.Path+":"+r.Name] {
+				problems = append(problems, problem{f.Path, r.Start, fmt.Sprintf("region %q is not referenced by any doc", r.Name)})
+			}
+		}
+	}
+	return problems
+}
+
+// gofmtFragment formats src as a standalone fragment: a whole file, a list
+// of declarations or a list of statements. It reports false if src does not
+// parse as any of those.
+func gofmtFragment(src string) (formatted string, parses bool) {
+	if !strings.HasPrefix(src, "package ") {
+		// format.Source also accepts declarations without a package clause,
+		// but then does not treat their doc comments as such, so add one.
+		const header = "package p\n\n"
+		if out, err := format.Source([]byte(header + src)); err == nil {
+			return strings.TrimPrefix(string(out), header), true
+		}
+	}
+	out, err := format.Source([]byte(src))
+	if err != nil {
+		return "", false
+	}
+	return string(out), true
+}
+
+func splitLines(data []byte) []string {
+	var lines []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	return lines
+}
+
+// dedent removes the longest whitespace prefix shared by all non-blank lines,
+// like Python's textwrap.dedent, which pymdownx.snippets uses.
+func dedent(lines []string) string {
+	prefix := ""
+	first := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix, first = indent, false
+			continue
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(strings.TrimPrefix(line, prefix))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,226 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFile writes content to root/path, creating its directory.
+func writeFile(t *testing.T, root, path, content string) {
+	t.Helper()
+	full := filepath.Join(root, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		src      string
+		regions  []region
+		problems []string
+	}{
+		{
+			name: "nested",
+			src: `// --8<-- [start:outer]
+	// --8<-- [start:inner]
+	x := 1
+	// --8<-- [end:inner]
+// --8<-- [end:outer]
+`,
+			regions: []region{{"outer", 1, 5}, {"inner", 2, 4}},
+		},
+		{
+			name:     "unbalanced start",
+			src:      "// --8<-- [start:a]\nx := 1\n",
+			regions:  []region{{"a", 1, 0}},
+			problems: []string{`f.go:1: region "a" is never ended`},
+		},
+		{
+			name:     "unbalanced end",
+			src:      "x := 1\n// --8<-- [end:a]\n",
+			problems: []string{`f.go:2: end of region "a" that was never started`},
+		},
+		{
+			name: "duplicate",
+			src: `// --8<-- [start:a]
+// --8<-- [end:a]
+// --8<-- [start:a]
+// --8<-- [end:a]
+`,
+			regions: []region{{"a", 1, 2}},
+			problems: []string{
+				`f.go:3: region "a" already started at line 1`,
+				`f.go:4: region "a" already ended at line 2`,
+			},
+		},
+		{
+			name: "crossing",
+			src: `// --8<-- [start:a]
+// --8<-- [start:b]
+// --8<-- [end:a]
+// --8<-- [end:b]
+`,
+			regions:  []region{{"a", 1, 3}, {"b", 2, 4}},
+			problems: []string{`f.go:3: region "a" ends before region "b", started inside it at line 2`},
+		},
+		{
+			name:    "marker leaders and case",
+			src:     "x := 1 # --8<-- [ START : a ]\n/* --8<-- [end:a] */\n",
+			regions: []region{{"a", 1, 2}},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFile(t, root, "f.go", c.src)
+			f, ps, err := parseFile(root, "f.go")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var regions []region
+			for _, r := range f.Regions {
+				regions = append(regions, *r)
+			}
+			if !reflect.DeepEqual(regions, c.regions) {
+				t.Errorf("regions = %v, want %v", regions, c.regions)
+			}
+			var problems []string
+			for _, p := range ps {
+				problems = append(problems, p.String())
+			}
+			if !reflect.DeepEqual(problems, c.problems) {
+				t.Errorf("problems = %q, want %q", problems, c.problems)
+			}
+		})
+	}
+}
+
+func TestRefs(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "examples/go/a/main.go", `package main
+
+// --8<-- [start:ok]
+func f() {}
+// --8<-- [end:ok]
+
+// --8<-- [start:open]
+`)
+	writeFile(t, root, "docs/page.md", strings.Join([]string{
+		`--8<-- "examples/go/a/main.go:ok"`,
+		`--8<-- "examples/go/a/main.go:3:5"`,
+		`--8<-- "examples/go/a/main.go"`,
+		`--8<-- "examples/go/a/main.go:missing" and --8<-- "examples/go/a/main.go:open"`,
+		`--8<-- "examples/go/b/main.go:ok"`,
+		`--8<-- "examples/python/a.py:ok"`,
+	}, "\n"))
+
+	refs, err := parseRefs(root, "docs", "examples/go/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []reference{
+		{"docs/page.md", 1, "examples/go/a/main.go", "ok"},
+		{"docs/page.md", 2, "examples/go/a/main.go", ""},
+		{"docs/page.md", 3, "examples/go/a/main.go", ""},
+		{"docs/page.md", 4, "examples/go/a/main.go", "missing"},
+		{"docs/page.md", 4, "examples/go/a/main.go", "open"},
+		{"docs/page.md", 5, "examples/go/b/main.go", "ok"},
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("parseRefs() = %v, want %v", refs, want)
+	}
+
+	files, _, err := parseTree(root, "examples/go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var problems []string
+	for _, p := range checkRefs(files, refs) {
+		problems = append(problems, p.String())
+	}
+	wantProblems := []string{
+		`docs/page.md:4: snippet file examples/go/a/main.go has no region "missing"`,
+		`docs/page.md:4: snippet file examples/go/a/main.go has no region "open"`,
+		`docs/page.md:5: snippet file examples/go/b/main.go does not exist`,
+	}
+	if !reflect.DeepEqual(problems, wantProblems) {
+		t.Errorf("checkRefs() = %q, want %q", problems, wantProblems)
+	}
+}
+
+func TestFragment(t *testing.T) {
+	f := &sourceFile{Lines: strings.Split(`func f() {
+	// --8<-- [start:body]
+
+	if ok {
+		// --8<-- [start:inner]
+		run()
+		// --8<-- [end:inner]
+	}
+
+	// --8<-- [end:body]
+}`, "\n")}
+	got := f.fragment(&region{Name: "body", Start: 2, End: 10})
+	want := "if ok {\n\trun()\n}\n"
+	if got != want {
+		t.Errorf("fragment() = %q, want %q", got, want)
+	}
+}
+
+func TestDedent(t *testing.T) {
+	for _, c := range []struct {
+		lines []string
+		want  string
+	}{
+		{[]string{"\t\ta", "\t\t\tb"}, "a\n\tb\n"},
+		{[]string{"    a", "", "  b"}, "  a\n\nb\n"},
+		// Blank lines do not count, however short their indentation.
+		{[]string{"\t\ta", " ", "\t\tb"}, "a\n\nb\n"},
+		// Tabs and spaces are not equivalent.
+		{[]string{"\ta", "    b"}, "\ta\n    b\n"},
+		{nil, ""},
+	} {
+		if got := dedent(c.lines); got != c.want {
+			t.Errorf("dedent(%q) = %q, want %q", c.lines, got, c.want)
+		}
+	}
+}
+
+func TestGofmtFragment(t *testing.T) {
+	for _, c := range []struct {
+		name, src, want string
+		parses          bool
+	}{
+		{"file", "package p\nvar  x = 1\n", "package p\n\nvar x = 1\n", true},
+		{"declarations", "// F does nothing.\nfunc F( ) {}\n", "// F does nothing.\nfunc F() {}\n", true},
+		{"statements", "x:=1\nfmt.Println( x )\n", "x := 1\nfmt.Println(x)\n", true},
+		{"formatted", "x := 1\n", "x := 1\n", true},
+		{"partial", "Name: \"a\",\n}\n", "", false},
+	} {
+		got, parses := gofmtFragment(c.src)
+		if got != c.want || parses != c.parses {
+			t.Errorf("%s: gofmtFragment(%q) = %q, %v, want %q, %v", c.name, c.src, got, parses, c.want, c.parses)
+		}
+	}
+}